@@ -6,8 +6,10 @@ import (
 	"fmt"
 	"github.com/yrss1/my-shop/api-gateway/intrenal/config"
 	"github.com/yrss1/my-shop/api-gateway/intrenal/handler"
+	"github.com/yrss1/my-shop/api-gateway/intrenal/provider/auth"
 	"github.com/yrss1/my-shop/api-gateway/pkg/log"
 	"github.com/yrss1/my-shop/api-gateway/pkg/server"
+	"github.com/yrss1/my-shop/api-gateway/pkg/token"
 	"go.uber.org/zap"
 	"os"
 	"os/signal"
@@ -24,9 +26,25 @@ func Run() {
 		return
 	}
 
+	tokens, err := token.New(configs.JWT.Secret, configs.JWT.Issuer)
+	if err != nil {
+		logger.Error("ERR_INIT_TOKEN_VERIFIER", zap.Error(err))
+		return
+	}
+
+	var apiKeys *auth.Client
+	if configs.API.AuthGRPC != "" {
+		if apiKeys, err = auth.New(configs.API.AuthGRPC); err != nil {
+			logger.Error("ERR_INIT_AUTH_CLIENT", zap.Error(err))
+			return
+		}
+	}
+
 	handlers, err := handler.New(
 		handler.Dependencies{
 			Configs: configs,
+			Tokens:  tokens,
+			APIKeys: apiKeys,
 		},
 		handler.WithHTTPHandler())
 	if err != nil {
@@ -14,12 +14,15 @@ const (
 	defaultAppPort    = "8080"
 	defaultAppPath    = "/"
 	defaultAppTimeout = 60 * time.Second
+
+	defaultJWTIssuer = "my-shop-auth"
 )
 
 type (
 	Configs struct {
 		APP AppConfig
 		API ApiConfig
+		JWT JWTConfig
 	}
 
 	AppConfig struct {
@@ -29,11 +32,22 @@ type (
 		Timeout time.Duration
 	}
 
+	// ApiConfig has the addresses of the services. AuthGRPC is the gRPC
+	// address of the auth service, API keys are only accepted when it is set.
 	ApiConfig struct {
-		Order   string
-		Product string
-		Payment string
-		User    string
+		Order    string
+		Product  string
+		Payment  string
+		User     string
+		Auth     string
+		AuthGRPC string
+	}
+
+	// JWTConfig verifies the access tokens issued by the auth service. Secret
+	// and Issuer have to match the ones the auth service signs with.
+	JWTConfig struct {
+		Secret string `required:"true"`
+		Issuer string
 	}
 )
 
@@ -59,5 +73,13 @@ func New() (cfg Configs, err error) {
 		return
 	}
 
+	cfg.JWT = JWTConfig{
+		Issuer: defaultJWTIssuer,
+	}
+
+	if err = envconfig.Process("JWT", &cfg.JWT); err != nil {
+		return
+	}
+
 	return
 }
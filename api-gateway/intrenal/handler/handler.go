@@ -5,12 +5,17 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/yrss1/my-shop/api-gateway/intrenal/config"
 	"github.com/yrss1/my-shop/api-gateway/intrenal/handler/http"
+	"github.com/yrss1/my-shop/api-gateway/intrenal/provider/auth"
 	"github.com/yrss1/my-shop/api-gateway/pkg/server/response"
 	"github.com/yrss1/my-shop/api-gateway/pkg/server/router"
+	"github.com/yrss1/my-shop/api-gateway/pkg/token"
 )
 
 type Dependencies struct {
 	Configs config.Configs
+
+	Tokens  *token.Verifier
+	APIKeys *auth.Client
 }
 type Handler struct {
 	dependencies Dependencies
@@ -44,7 +49,7 @@ func WithHTTPHandler() Configuration {
 				response.StatusRequestTimeout(ctx)
 			}),
 		))
-		proxyHandler := http.NewProxyHandler()
+		proxyHandler := http.NewProxyHandler(h.dependencies.Tokens, h.dependencies.APIKeys)
 
 		api := h.HTTP.Group(h.dependencies.Configs.APP.Path)
 		{
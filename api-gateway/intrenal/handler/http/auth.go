@@ -0,0 +1,290 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/yrss1/my-shop/api-gateway/pkg/server/response"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	headerUserID   = "X-User-ID"
+	headerUserRole = "X-User-Role"
+	headerAPIKey   = "X-API-Key"
+
+	// roleAPIKey is the role passed on for requests made with an API key,
+	// whose X-User-ID is the ID of the key prefixed with "api-key:".
+	roleAPIKey = "api_key"
+)
+
+var (
+	ErrorTokenRequired = errors.New("bearer token is required")
+	ErrorForbidden     = errors.New("access denied")
+	ErrorNotFound      = errors.New("not found")
+	ErrorAPIKeyOff     = errors.New("api keys are not enabled")
+	ErrorAPIKeyService = errors.New("api keys can't be used for the service")
+)
+
+// apiKeyServices are the services that take an X-API-Key, the ones the auth
+// service scopes keys to.
+var apiKeyServices = map[string]bool{"product": true, "order": true}
+
+// authorize enforces the route policies of a service. It verifies the bearer
+// token and passes the user on to the service in the X-User-ID and X-User-Role
+// headers. Those headers are always dropped from the client request, so that
+// they can't be forged. An X-API-Key is checked against the scopes of the key
+// instead of the policies.
+func (h *ProxyHandler) authorize(service string, rules []policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request.Header.Del(headerUserID)
+		c.Request.Header.Del(headerUserRole)
+
+		key := c.GetHeader(headerAPIKey)
+		c.Request.Header.Del(headerAPIKey)
+
+		rule, params := matchPolicy(rules, c.Request.Method, c.Param("action"))
+		if rule.access == accessPublic {
+			c.Next()
+			return
+		}
+
+		if key != "" {
+			h.authorizeAPIKey(c, service, key)
+			return
+		}
+
+		value, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok {
+			response.Unauthorized(c, ErrorTokenRequired)
+			c.Abort()
+			return
+		}
+
+		claims, err := h.tokens.Parse(value)
+		if err != nil {
+			response.Unauthorized(c, err)
+			c.Abort()
+			return
+		}
+
+		c.Request.Header.Set(headerUserID, claims.Subject)
+		c.Request.Header.Set(headerUserRole, claims.Role)
+
+		if claims.Role != roleAdmin {
+			if err = rule.allow(c, claims.Subject, params); err != nil {
+				switch {
+				case errors.Is(err, ErrorForbidden):
+					response.Forbidden(c, err)
+				case errors.Is(err, ErrorNotFound):
+					response.NotFound(c, err)
+				default:
+					response.InternalServerError(c, err)
+				}
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// authorizeAPIKey lets the request through if the key is scoped to it: GET
+// and HEAD requests need the read scope of the service, others the write scope.
+// Keys are refused for services outside apiKeyServices whatever their scopes.
+func (h *ProxyHandler) authorizeAPIKey(c *gin.Context, service, key string) {
+	if h.apiKeys == nil {
+		response.Unauthorized(c, ErrorAPIKeyOff)
+		c.Abort()
+		return
+	}
+
+	if !apiKeyServices[service] {
+		response.Forbidden(c, ErrorAPIKeyService)
+		c.Abort()
+		return
+	}
+
+	action := "write"
+	if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+		action = "read"
+	}
+
+	found, err := h.apiKeys.ValidateAPIKey(c, key, service, action)
+	if err != nil {
+		switch status.Code(err) {
+		case codes.Unauthenticated:
+			response.Unauthorized(c, errors.New(status.Convert(err).Message()))
+		case codes.PermissionDenied:
+			response.Forbidden(c, errors.New(status.Convert(err).Message()))
+		default:
+			response.InternalServerError(c, err)
+		}
+		c.Abort()
+		return
+	}
+
+	c.Request.Header.Set(headerUserID, "api-key:"+found.KeyId)
+	c.Request.Header.Set(headerUserRole, roleAPIKey)
+
+	c.Next()
+}
+
+// allow checks the request of a user that is not an admin against the policy.
+func (p policy) allow(c *gin.Context, userID string, params map[string]string) (err error) {
+	switch p.access {
+	case accessUser:
+	case accessOwner:
+		ownerID, err := p.owner(c, params)
+		if err != nil {
+			return err
+		}
+		if ownerID != userID {
+			return ErrorForbidden
+		}
+	default:
+		return ErrorForbidden
+	}
+
+	if len(p.adminFields) > 0 {
+		fields := map[string]json.RawMessage{}
+		if err = readJSON(c, &fields); err != nil {
+			return
+		}
+
+		for _, field := range p.adminFields {
+			if _, ok := fields[field]; ok {
+				return fmt.Errorf("%w: %s can only be set by admins", ErrorForbidden, field)
+			}
+		}
+	}
+
+	return
+}
+
+// owner returns the ID of the user a request belongs to.
+type owner func(c *gin.Context, params map[string]string) (userID string, err error)
+
+func paramOwner(name string) owner {
+	return func(c *gin.Context, params map[string]string) (string, error) {
+		return params[name], nil
+	}
+}
+
+func queryOwner(name string) owner {
+	return func(c *gin.Context, params map[string]string) (string, error) {
+		return c.Query(name), nil
+	}
+}
+
+func bodyOwner(field string) owner {
+	return func(c *gin.Context, params map[string]string) (userID string, err error) {
+		body := map[string]any{}
+		if err = readJSON(c, &body); err != nil {
+			return
+		}
+
+		userID, _ = body[field].(string)
+		return
+	}
+}
+
+// bodyLookupOwner is lookupOwner for the ID in the JSON body field.
+func bodyLookupOwner(prefix, field string) owner {
+	lookup := lookupOwner(prefix, field)
+
+	return func(c *gin.Context, params map[string]string) (userID string, err error) {
+		body := map[string]any{}
+		if err = readJSON(c, &body); err != nil {
+			return
+		}
+
+		id, _ := body[field].(string)
+		if id == "" {
+			return "", fmt.Errorf("%w: %s is required", ErrorForbidden, field)
+		}
+
+		return lookup(c, map[string]string{field: url.PathEscape(id)})
+	}
+}
+
+// sameOwner returns the owner all of owners agree on, and no owner when they
+// don't.
+func sameOwner(owners ...owner) owner {
+	return func(c *gin.Context, params map[string]string) (userID string, err error) {
+		for i, o := range owners {
+			id, err := o(c, params)
+			if err != nil {
+				return "", err
+			}
+			if i > 0 && id != userID {
+				return "", nil
+			}
+			userID = id
+		}
+
+		return
+	}
+}
+
+// lookupOwner reads the owner from the user_id of the resource at prefix plus
+// the path parameter param, as the service that keeps it returns it.
+func lookupOwner(prefix, param string) owner {
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	return func(c *gin.Context, params map[string]string) (userID string, err error) {
+		req, err := http.NewRequestWithContext(c, http.MethodGet, prefix+params[param], nil)
+		if err != nil {
+			return
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return
+		}
+		defer resp.Body.Close()
+
+		switch {
+		case resp.StatusCode == http.StatusNotFound:
+			return "", ErrorNotFound
+		case resp.StatusCode != http.StatusOK:
+			return "", fmt.Errorf("owner lookup: unexpected status %d", resp.StatusCode)
+		}
+
+		var res struct {
+			Data struct {
+				UserID string `json:"user_id"`
+			} `json:"data"`
+		}
+		if err = json.NewDecoder(resp.Body).Decode(&res); err != nil {
+			return
+		}
+
+		return res.Data.UserID, nil
+	}
+}
+
+// readJSON decodes the request body into dest and puts the body back for the
+// proxy. A body that is not a JSON object is rejected.
+func readJSON(c *gin.Context, dest any) (err error) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	if err = json.Unmarshal(body, dest); err != nil {
+		return fmt.Errorf("%w: body is not a JSON object", ErrorForbidden)
+	}
+
+	return
+}
@@ -0,0 +1,132 @@
+package http
+
+import (
+	"github.com/yrss1/my-shop/api-gateway/intrenal/config"
+	"net/http"
+	"strings"
+)
+
+// access is who may call a route.
+type access int
+
+const (
+	// accessAdmin routes need the token of an admin. It is the zero value, so
+	// that a route is admin only unless a policy says otherwise.
+	accessAdmin access = iota
+	// accessPublic routes need no token.
+	accessPublic
+	// accessUser routes need the token of any user.
+	accessUser
+	// accessOwner routes need the token of the user the request belongs to, or
+	// of an admin.
+	accessOwner
+)
+
+const roleAdmin = "admin"
+
+// policy is a rule of the route policy table. An empty method matches every
+// method. path is relative to the prefix of the service: ":name" matches one
+// segment and a trailing "*" matches the rest of the path.
+type policy struct {
+	method string
+	path   string
+	access access
+
+	// owner finds the user the request belongs to on accessOwner routes.
+	owner owner
+	// adminFields are the JSON body fields only admins may set.
+	adminFields []string
+}
+
+// policies returns the route policy table of every service. The first policy
+// of a service that matches a request applies, and requests that no policy
+// matches are admin only.
+func policies(api config.ApiConfig) map[string][]policy {
+	orderOwner := lookupOwner(api.Order+"/orders/", "id")
+
+	return map[string][]policy{
+		"auth": {
+			{path: "/auth/users/*", access: accessAdmin},
+			{path: "/auth/lockouts", access: accessAdmin},
+			{path: "/auth/api-keys/*", access: accessAdmin},
+			{path: "/auth/*", access: accessPublic},
+		},
+		"product": {
+			{method: http.MethodGet, path: "/products/export", access: accessAdmin},
+			{method: http.MethodGet, path: "/products/:id/prices/schedules", access: accessAdmin},
+			{method: http.MethodGet, path: "/products/*", access: accessPublic},
+			{method: http.MethodGet, path: "/categories/*", access: accessPublic},
+			{method: http.MethodGet, path: "/media/*", access: accessPublic},
+		},
+		"user": {
+			{method: http.MethodGet, path: "/users/:id", access: accessOwner, owner: paramOwner("id")},
+			// users change their password with POST /auth/password/change,
+			// which asks for the current one
+			{method: http.MethodPut, path: "/users/:id", access: accessOwner, owner: paramOwner("id"), adminFields: []string{"role", "password"}},
+		},
+		"order": {
+			{method: http.MethodGet, path: "/orders/search", access: accessOwner, owner: queryOwner("userId")},
+			{method: http.MethodPost, path: "/orders", access: accessOwner, owner: bodyOwner("user_id")},
+			{method: http.MethodGet, path: "/orders/:id", access: accessOwner, owner: orderOwner},
+			{method: http.MethodGet, path: "/orders/:id/history", access: accessOwner, owner: orderOwner},
+			{method: http.MethodPost, path: "/orders/:id/cancel", access: accessOwner, owner: orderOwner},
+		},
+		"payment": {
+			{method: http.MethodGet, path: "/payments/search", access: accessOwner, owner: queryOwner("userId")},
+			{method: http.MethodPost, path: "/payments", access: accessOwner,
+				owner:       sameOwner(bodyOwner("user_id"), bodyLookupOwner(api.Order+"/orders/", "order_id")),
+				adminFields: []string{"status", "amount", "transaction_id"}},
+			{method: http.MethodGet, path: "/payments/:id", access: accessOwner, owner: lookupOwner(api.Payment+"/payments/", "id")},
+			// POST /payments/orders/:orderId/cancel compensates the payments of
+			// an order the order service cancelled, it stays admin only
+		},
+	}
+}
+
+// matchPolicy returns the policy of the request and the path parameters it
+// captured.
+func matchPolicy(rules []policy, method, path string) (rule policy, params map[string]string) {
+	for _, rule = range rules {
+		if rule.method != "" && rule.method != method {
+			continue
+		}
+		if params, ok := rule.match(path); ok {
+			return rule, params
+		}
+	}
+
+	return policy{access: accessAdmin}, nil
+}
+
+func (p policy) match(path string) (params map[string]string, ok bool) {
+	patterns := segments(p.path)
+	parts := segments(path)
+	params = map[string]string{}
+
+	for i, pattern := range patterns {
+		if pattern == "*" && i == len(patterns)-1 {
+			return params, true
+		}
+		if i >= len(parts) {
+			return nil, false
+		}
+
+		switch {
+		case strings.HasPrefix(pattern, ":"):
+			params[pattern[1:]] = parts[i]
+		case pattern != parts[i]:
+			return nil, false
+		}
+	}
+
+	return params, len(parts) == len(patterns)
+}
+
+func segments(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+
+	return strings.Split(path, "/")
+}
@@ -4,22 +4,32 @@ import (
 	"bytes"
 	"github.com/gin-gonic/gin"
 	"github.com/yrss1/my-shop/api-gateway/intrenal/config"
+	"github.com/yrss1/my-shop/api-gateway/intrenal/provider/auth"
 	"github.com/yrss1/my-shop/api-gateway/pkg/server/response"
+	"github.com/yrss1/my-shop/api-gateway/pkg/token"
 	"io"
 	"net/http"
 )
 
-func NewProxyHandler() *ProxyHandler {
-	return &ProxyHandler{}
+// NewProxyHandler returns the proxy. apiKeys may be nil, X-API-Key is then
+// rejected.
+func NewProxyHandler(tokens *token.Verifier, apiKeys *auth.Client) *ProxyHandler {
+	return &ProxyHandler{tokens: tokens, apiKeys: apiKeys}
 }
 
-type ProxyHandler struct{}
+type ProxyHandler struct {
+	tokens  *token.Verifier
+	apiKeys *auth.Client
+}
 
 func (h *ProxyHandler) Routes(routerGroup *gin.RouterGroup, config config.Configs) {
-	routerGroup.Any("/order/*action", h.handleRequest(config.API.Order))
-	routerGroup.Any("/payment/*action", h.handleRequest(config.API.Payment))
-	routerGroup.Any("/product/*action", h.handleRequest(config.API.Product))
-	routerGroup.Any("/user/*action", h.handleRequest(config.API.User))
+	policies := policies(config.API)
+
+	routerGroup.Any("/auth/*action", h.authorize("auth", policies["auth"]), h.handleRequest(config.API.Auth))
+	routerGroup.Any("/order/*action", h.authorize("order", policies["order"]), h.handleRequest(config.API.Order))
+	routerGroup.Any("/payment/*action", h.authorize("payment", policies["payment"]), h.handleRequest(config.API.Payment))
+	routerGroup.Any("/product/*action", h.authorize("product", policies["product"]), h.handleRequest(config.API.Product))
+	routerGroup.Any("/user/*action", h.authorize("user", policies["user"]), h.handleRequest(config.API.User))
 }
 
 func (h *ProxyHandler) handleRequest(targetURL string) gin.HandlerFunc {
@@ -52,6 +62,9 @@ func (h *ProxyHandler) handleRequest(targetURL string) gin.HandlerFunc {
 				req.Header.Add(key, value)
 			}
 		}
+		// the gateway is where clients connect, an address they claim
+		// themselves must not reach the services
+		req.Header.Set("X-Forwarded-For", c.RemoteIP())
 
 		resp, err := http.DefaultClient.Do(req)
 		if err != nil {
@@ -60,12 +73,12 @@ func (h *ProxyHandler) handleRequest(targetURL string) gin.HandlerFunc {
 		}
 		defer resp.Body.Close()
 
-		c.Writer.WriteHeader(resp.StatusCode)
 		for key, values := range resp.Header {
 			for _, value := range values {
 				c.Writer.Header().Add(key, value)
 			}
 		}
+		c.Writer.WriteHeader(resp.StatusCode)
 		io.Copy(c.Writer, resp.Body)
 	}
 }
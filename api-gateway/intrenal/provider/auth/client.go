@@ -0,0 +1,25 @@
+package auth
+
+import (
+	pb "github.com/yrss1/my-shop/proto/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+type Client struct {
+	conn   *grpc.ClientConn
+	client pb.AuthServiceClient
+}
+
+func New(address string) (client *Client, err error) {
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return
+	}
+	client = &Client{
+		conn:   conn,
+		client: pb.NewAuthServiceClient(conn),
+	}
+
+	return
+}
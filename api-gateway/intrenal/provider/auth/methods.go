@@ -0,0 +1,14 @@
+package auth
+
+import (
+	"context"
+	pb "github.com/yrss1/my-shop/proto/auth"
+	"time"
+)
+
+func (c *Client) ValidateAPIKey(ctx context.Context, key, service, action string) (*pb.ValidateAPIKeyResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return c.client.ValidateAPIKey(ctx, &pb.ValidateAPIKeyRequest{Key: key, Service: service, Action: action})
+}
@@ -36,6 +36,22 @@ func BadRequest(c *gin.Context, err error, data any) {
 	c.JSON(http.StatusBadRequest, h)
 }
 
+func Unauthorized(c *gin.Context, err error) {
+	h := Object{
+		Success: false,
+		Message: err.Error(),
+	}
+	c.JSON(http.StatusUnauthorized, h)
+}
+
+func Forbidden(c *gin.Context, err error) {
+	h := Object{
+		Success: false,
+		Message: err.Error(),
+	}
+	c.JSON(http.StatusForbidden, h)
+}
+
 func NotFound(c *gin.Context, err error) {
 	h := Object{
 		Success: false,
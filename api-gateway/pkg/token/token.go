@@ -0,0 +1,53 @@
+package token
+
+import (
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const TypeAccess = "access"
+
+var (
+	ErrorInvalid = errors.New("token is invalid or expired")
+)
+
+// Claims are the claims of the tokens the auth service issues. Subject is the
+// user ID.
+type Claims struct {
+	jwt.RegisteredClaims
+	Role string `json:"role,omitempty"`
+	Type string `json:"typ"`
+}
+
+// Verifier checks HS256 access tokens signed with a secret shared with the
+// auth service.
+type Verifier struct {
+	secret []byte
+	issuer string
+}
+
+func New(secret, issuer string) (v *Verifier, err error) {
+	if len(secret) < 32 {
+		err = errors.New("token: secret must be at least 32 bytes long")
+		return
+	}
+
+	v = &Verifier{
+		secret: []byte(secret),
+		issuer: issuer,
+	}
+
+	return
+}
+
+// Parse verifies the access token value and returns its claims.
+func (v *Verifier) Parse(value string) (claims Claims, err error) {
+	_, err = jwt.ParseWithClaims(value, &claims, func(*jwt.Token) (any, error) {
+		return v.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithIssuer(v.issuer), jwt.WithExpirationRequired())
+	if err != nil || claims.Type != TypeAccess || claims.Subject == "" {
+		err = ErrorInvalid
+	}
+
+	return
+}
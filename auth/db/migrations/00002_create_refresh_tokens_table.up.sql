@@ -0,0 +1,21 @@
+DO $$
+    BEGIN
+        -- TABLES --
+        CREATE TABLE IF NOT EXISTS refresh_tokens (
+                                                      created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                                      -- the jti of the token, the token itself is not kept
+                                                      id UUID PRIMARY KEY,
+                                                      -- every token rotated from the same login shares it
+                                                      session_id UUID NOT NULL,
+                                                      user_id UUID NOT NULL,
+                                                      expires_at TIMESTAMPTZ NOT NULL,
+                                                      used_at TIMESTAMPTZ,
+                                                      revoked_at TIMESTAMPTZ
+        );
+
+        -- INDEXES --
+        CREATE INDEX IF NOT EXISTS refresh_tokens_session_id_idx ON refresh_tokens (session_id);
+        CREATE INDEX IF NOT EXISTS refresh_tokens_user_id_idx ON refresh_tokens (user_id);
+
+        COMMIT;
+    END $$;
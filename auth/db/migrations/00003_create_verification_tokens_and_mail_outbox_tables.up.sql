@@ -0,0 +1,32 @@
+DO $$
+    BEGIN
+        -- TABLES --
+        CREATE TABLE IF NOT EXISTS verification_tokens (
+                                                           created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                                           id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+                                                           user_id UUID NOT NULL,
+                                                           purpose VARCHAR(20) NOT NULL,
+                                                           -- sha256 of the token, the token itself is only in the email
+                                                           token_hash CHAR(64) UNIQUE NOT NULL,
+                                                           expires_at TIMESTAMPTZ NOT NULL,
+                                                           used_at TIMESTAMPTZ
+        );
+
+        CREATE TABLE IF NOT EXISTS mail_outbox (
+                                                   created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                                   id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+                                                   recipient VARCHAR(100) NOT NULL,
+                                                   subject VARCHAR(200) NOT NULL,
+                                                   body TEXT NOT NULL,
+                                                   attempts INTEGER NOT NULL DEFAULT 0,
+                                                   last_error TEXT,
+                                                   next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                                   sent_at TIMESTAMPTZ
+        );
+
+        -- INDEXES --
+        CREATE INDEX IF NOT EXISTS verification_tokens_user_id_idx ON verification_tokens (user_id, purpose);
+        CREATE INDEX IF NOT EXISTS mail_outbox_due_idx ON mail_outbox (next_attempt_at) WHERE sent_at IS NULL;
+
+        COMMIT;
+    END $$;
@@ -0,0 +1,4 @@
+BEGIN;
+DROP TABLE IF EXISTS mail_outbox;
+DROP TABLE IF EXISTS verification_tokens;
+END;
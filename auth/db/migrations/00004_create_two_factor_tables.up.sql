@@ -0,0 +1,28 @@
+DO $$
+    BEGIN
+        -- TABLES --
+        CREATE TABLE IF NOT EXISTS two_factor_secrets (
+                                                          created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                                          updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                                          user_id UUID PRIMARY KEY,
+                                                          secret VARCHAR(64) NOT NULL,
+                                                          -- NULL until the enrolment is confirmed with a code
+                                                          enabled_at TIMESTAMPTZ,
+                                                          -- the time step of the last accepted code, so codes can't be replayed
+                                                          last_step BIGINT NOT NULL DEFAULT 0
+        );
+
+        CREATE TABLE IF NOT EXISTS recovery_codes (
+                                                      created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                                      id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+                                                      user_id UUID NOT NULL,
+                                                      -- sha256 of the code, the code itself is only shown once
+                                                      code_hash CHAR(64) NOT NULL,
+                                                      used_at TIMESTAMPTZ
+        );
+
+        -- INDEXES --
+        CREATE UNIQUE INDEX IF NOT EXISTS recovery_codes_user_id_code_hash_idx ON recovery_codes (user_id, code_hash);
+
+        COMMIT;
+    END $$;
@@ -0,0 +1,4 @@
+BEGIN;
+DROP TABLE IF EXISTS recovery_codes;
+DROP TABLE IF EXISTS two_factor_secrets;
+END;
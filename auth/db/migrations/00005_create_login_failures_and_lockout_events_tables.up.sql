@@ -0,0 +1,28 @@
+DO $$
+    BEGIN
+        -- TABLES --
+        CREATE TABLE IF NOT EXISTS login_failures (
+                                                      -- account, ip or two_factor
+                                                      scope VARCHAR(20) NOT NULL,
+                                                      -- the email, IP address or user ID failures are counted for
+                                                      subject VARCHAR(100) NOT NULL,
+                                                      failures INTEGER NOT NULL DEFAULT 0,
+                                                      last_failure_at TIMESTAMPTZ,
+                                                      locked_until TIMESTAMPTZ,
+                                                      PRIMARY KEY (scope, subject)
+        );
+
+        CREATE TABLE IF NOT EXISTS lockout_events (
+                                                      created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+                                                      id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+                                                      scope VARCHAR(20) NOT NULL,
+                                                      subject VARCHAR(100) NOT NULL,
+                                                      failures INTEGER NOT NULL,
+                                                      locked_until TIMESTAMPTZ NOT NULL
+        );
+
+        -- INDEXES --
+        CREATE INDEX IF NOT EXISTS lockout_events_created_at_idx ON lockout_events (created_at);
+
+        COMMIT;
+    END $$;
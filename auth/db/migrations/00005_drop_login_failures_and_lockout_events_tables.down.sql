@@ -0,0 +1,4 @@
+BEGIN;
+DROP TABLE IF EXISTS lockout_events;
+DROP TABLE IF EXISTS login_failures;
+END;
@@ -0,0 +1,21 @@
+DO $$
+    BEGIN
+        -- TABLES --
+        CREATE TABLE IF NOT EXISTS api_keys (
+                                                created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+                                                id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+                                                name VARCHAR(100) NOT NULL,
+                                                -- the start of the key, to recognise it by
+                                                prefix VARCHAR(20) NOT NULL,
+                                                -- sha256 of the key, the key itself is only shown once
+                                                key_hash CHAR(64) UNIQUE NOT NULL,
+                                                -- service:action pairs, as in product:read
+                                                scopes TEXT[] NOT NULL,
+                                                created_by UUID NOT NULL,
+                                                expires_at TIMESTAMPTZ,
+                                                last_used_at TIMESTAMPTZ,
+                                                revoked_at TIMESTAMPTZ
+        );
+
+        COMMIT;
+    END $$;
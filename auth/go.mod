@@ -5,12 +5,13 @@ go 1.22.5
 require (
 	github.com/gin-contrib/cors v1.7.2
 	github.com/gin-gonic/gin v1.10.0
+	github.com/golang-jwt/jwt/v5 v5.2.1
 	github.com/golang-migrate/migrate/v4 v4.17.1
 	github.com/jmoiron/sqlx v1.4.0
 	github.com/joho/godotenv v1.5.1
 	github.com/kelseyhightower/envconfig v1.4.0
 	github.com/lib/pq v1.10.9
-	github.com/yrss1/proto-definitions v0.0.0-20240817165415-2dd5c2486d3b
+	github.com/yrss1/my-shop/proto v0.0.0-00010101000000-000000000000
 	go.elastic.co/apm/module/apmzap v1.15.0
 	go.uber.org/zap v1.27.0
 	google.golang.org/grpc v1.65.0
@@ -65,3 +66,5 @@ require (
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 	howett.net/plist v0.0.0-20181124034731-591f970eefbb // indirect
 )
+
+replace github.com/yrss1/my-shop/proto => ../proto
@@ -5,11 +5,17 @@ import (
 	"flag"
 	"fmt"
 	"github.com/yrss1/my-shop/auth/internal/config"
+	"github.com/yrss1/my-shop/auth/internal/domain/lockout"
+	"github.com/yrss1/my-shop/auth/internal/domain/mail"
 	"github.com/yrss1/my-shop/auth/internal/handler"
+	"github.com/yrss1/my-shop/auth/internal/mailer/file"
+	"github.com/yrss1/my-shop/auth/internal/mailer/smtp"
 	"github.com/yrss1/my-shop/auth/internal/provider/user"
+	"github.com/yrss1/my-shop/auth/internal/repository"
 	"github.com/yrss1/my-shop/auth/internal/service/authService"
 	"github.com/yrss1/my-shop/auth/pkg/log"
 	"github.com/yrss1/my-shop/auth/pkg/server"
+	"github.com/yrss1/my-shop/auth/pkg/token"
 	"go.uber.org/zap"
 	"os"
 	"os/signal"
@@ -26,25 +32,66 @@ func Run() {
 		return
 	}
 
+	repositoryConfigs := []repository.Configuration{repository.WithPostgresStore(configs.POSTGRES.DSN)}
+	if configs.LOCKOUT.Store == "memory" {
+		repositoryConfigs = append(repositoryConfigs, repository.WithMemoryLockout())
+	}
+
+	repositories, err := repository.New(repositoryConfigs...)
+	if err != nil {
+		logger.Error("ERR_INIT_REPOSITORIES", zap.Error(err))
+		return
+	}
+
 	userClient, err := user.New(configs.API.UserGRPC)
 	if err != nil {
 		logger.Error("ERR_INIT_USER_CLIENT", zap.Error(err))
 		return
 	}
 
+	tokens, err := token.New(configs.JWT.Secret, configs.JWT.Issuer, configs.JWT.AccessTTL, configs.JWT.RefreshTTL)
+	if err != nil {
+		logger.Error("ERR_INIT_TOKEN_MANAGER", zap.Error(err))
+		return
+	}
+
+	mailer, err := newMailer(configs.MAIL)
+	if err != nil {
+		logger.Error("ERR_INIT_MAILER", zap.Error(err))
+		return
+	}
+
 	authService, err := authService.New(
-		authService.WithUserClient(userClient))
+		authService.WithUnitOfWork(repositories.UnitOfWork),
+		authService.WithUserClient(userClient),
+		authService.WithTokenManager(tokens),
+		authService.WithSessionRepository(repositories.Session),
+		authService.WithVerificationRepository(repositories.Verification),
+		authService.WithMailRepository(repositories.Mail),
+		authService.WithMailer(mailer, configs.MAIL.LinkURL),
+		authService.WithTwoFactorRepository(repositories.TwoFactor),
+		authService.WithTwoFactor(configs.TOTP.Issuer, configs.TOTP.RequiredRoles),
+		authService.WithLockoutRepository(repositories.Lockout),
+		authService.WithAPIKeyRepository(repositories.APIKey),
+		authService.WithLockoutPolicy(lockout.ScopeAccount, lockoutPolicy(configs.LOCKOUT, configs.LOCKOUT.AccountThreshold)),
+		authService.WithLockoutPolicy(lockout.ScopeIP, lockoutPolicy(configs.LOCKOUT, configs.LOCKOUT.IPThreshold)),
+		authService.WithLockoutPolicy(lockout.ScopeTwoFactor, lockoutPolicy(configs.LOCKOUT, configs.LOCKOUT.TwoFactorThreshold)))
 	if err != nil {
 		logger.Error("ERR_INIT_AUTH_SERVICE", zap.Error(err))
 		return
 	}
 
+	outboxCtx, stopOutbox := context.WithCancel(context.Background())
+	defer stopOutbox()
+	go authService.RunOutbox(outboxCtx, configs.MAIL.Interval)
+
 	handlers, err := handler.New(
 		handler.Dependencies{
 			Configs:     configs,
 			AuthService: authService,
 		},
-		handler.WithHTTPHandler())
+		handler.WithHTTPHandler(),
+		handler.WithGRPCHandler())
 	if err != nil {
 		logger.Error("ERR_INIT_HANDLERS", zap.Error(err))
 		return
@@ -82,6 +129,27 @@ func Run() {
 	}
 
 	fmt.Println("running cleanup tasks...")
+	stopOutbox()
 
 	fmt.Println("server was successful shutdown.")
 }
+
+func newMailer(cfg config.MailConfig) (mail.Mailer, error) {
+	switch cfg.Driver {
+	case "file":
+		return file.New(cfg.Dir, cfg.From)
+	case "smtp":
+		return smtp.New(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From)
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Driver)
+	}
+}
+
+func lockoutPolicy(cfg config.LockoutConfig, threshold int) lockout.Policy {
+	return lockout.Policy{
+		Threshold: threshold,
+		BaseDelay: cfg.BaseDelay,
+		MaxDelay:  cfg.MaxDelay,
+		Window:    cfg.Window,
+	}
+}
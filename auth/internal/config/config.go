@@ -15,6 +15,27 @@ const (
 	defaultAppGRPCPort = "9004"
 	defaultAppPath     = "/"
 	defaultAppTimeout  = 60 * time.Second
+
+	defaultJWTIssuer     = "my-shop-auth"
+	defaultJWTAccessTTL  = 15 * time.Minute
+	defaultJWTRefreshTTL = 30 * 24 * time.Hour
+
+	defaultMailDriver   = "file"
+	defaultMailFrom     = "no-reply@my-shop.local"
+	defaultMailDir      = "mail"
+	defaultMailLinkURL  = "http://localhost:3000"
+	defaultMailInterval = 10 * time.Second
+	defaultMailSMTPPort = "587"
+
+	defaultTOTPIssuer = "my-shop"
+
+	defaultLockoutStore              = "postgres"
+	defaultLockoutAccountThreshold   = 5
+	defaultLockoutIPThreshold        = 20
+	defaultLockoutTwoFactorThreshold = 5
+	defaultLockoutBaseDelay          = time.Minute
+	defaultLockoutMaxDelay           = time.Hour
+	defaultLockoutWindow             = 15 * time.Minute
 )
 
 type (
@@ -22,6 +43,10 @@ type (
 		APP      AppConfig
 		POSTGRES StoreConfig
 		API      APIConfig
+		JWT      JWTConfig
+		MAIL     MailConfig
+		TOTP     TOTPConfig
+		LOCKOUT  LockoutConfig
 	}
 
 	AppConfig struct {
@@ -39,6 +64,52 @@ type (
 	APIConfig struct {
 		UserGRPC string
 	}
+
+	// JWTConfig signs the tokens issued on login. Secret is shared with
+	// whoever verifies the tokens and has to be at least 32 bytes long.
+	JWTConfig struct {
+		Secret     string `required:"true"`
+		Issuer     string
+		AccessTTL  time.Duration
+		RefreshTTL time.Duration
+	}
+
+	// MailConfig sets how emails are sent. Driver is "smtp", or "file" to
+	// write them to Dir instead. LinkURL is the base URL of the pages the
+	// links in the emails open, Interval how often the outbox is delivered.
+	MailConfig struct {
+		Driver       string
+		From         string
+		Dir          string
+		LinkURL      string
+		Interval     time.Duration
+		SMTPHost     string
+		SMTPPort     string
+		SMTPUsername string
+		SMTPPassword string
+	}
+
+	// TOTPConfig sets up two-factor authentication. Issuer is the name
+	// authenticator apps show for the account. Users of RequiredRoles, a
+	// comma separated list, have to enrol before they can log in.
+	TOTPConfig struct {
+		Issuer        string
+		RequiredRoles []string
+	}
+
+	// LockoutConfig throttles failed logins. Store is "postgres", or
+	// "memory" for tests and single instance setups. The thresholds are the
+	// failures per email, per IP address and per two-factor login before
+	// they get locked out; 0 turns counting them off.
+	LockoutConfig struct {
+		Store              string
+		AccountThreshold   int
+		IPThreshold        int
+		TwoFactorThreshold int
+		BaseDelay          time.Duration
+		MaxDelay           time.Duration
+		Window             time.Duration
+	}
 )
 
 func New() (cfg Configs, err error) {
@@ -68,5 +139,50 @@ func New() (cfg Configs, err error) {
 		return
 	}
 
+	cfg.JWT = JWTConfig{
+		Issuer:     defaultJWTIssuer,
+		AccessTTL:  defaultJWTAccessTTL,
+		RefreshTTL: defaultJWTRefreshTTL,
+	}
+
+	if err = envconfig.Process("JWT", &cfg.JWT); err != nil {
+		return
+	}
+
+	cfg.MAIL = MailConfig{
+		Driver:   defaultMailDriver,
+		From:     defaultMailFrom,
+		Dir:      defaultMailDir,
+		LinkURL:  defaultMailLinkURL,
+		Interval: defaultMailInterval,
+		SMTPPort: defaultMailSMTPPort,
+	}
+
+	if err = envconfig.Process("MAIL", &cfg.MAIL); err != nil {
+		return
+	}
+
+	cfg.TOTP = TOTPConfig{
+		Issuer: defaultTOTPIssuer,
+	}
+
+	if err = envconfig.Process("TOTP", &cfg.TOTP); err != nil {
+		return
+	}
+
+	cfg.LOCKOUT = LockoutConfig{
+		Store:              defaultLockoutStore,
+		AccountThreshold:   defaultLockoutAccountThreshold,
+		IPThreshold:        defaultLockoutIPThreshold,
+		TwoFactorThreshold: defaultLockoutTwoFactorThreshold,
+		BaseDelay:          defaultLockoutBaseDelay,
+		MaxDelay:           defaultLockoutMaxDelay,
+		Window:             defaultLockoutWindow,
+	}
+
+	if err = envconfig.Process("LOCKOUT", &cfg.LOCKOUT); err != nil {
+		return
+	}
+
 	return
 }
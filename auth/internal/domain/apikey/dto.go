@@ -0,0 +1,84 @@
+package apikey
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+type Request struct {
+	Name *string `json:"name"`
+	// Scopes are service:action pairs, as in "product:read" or "order:write".
+	// Reading is GET, writing every other method.
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func (s *Request) Validate(now time.Time) error {
+	if s.Name == nil || strings.TrimSpace(*s.Name) == "" {
+		return errors.New("name: cannot be blank")
+	}
+
+	if len(*s.Name) > 100 {
+		return errors.New("name: cannot be longer than 100 characters")
+	}
+
+	if len(s.Scopes) == 0 {
+		return errors.New("scopes: cannot be blank")
+	}
+
+	for _, scope := range s.Scopes {
+		service, action, _ := strings.Cut(scope, ":")
+		if !slices.Contains(Services, service) || action != ActionRead && action != ActionWrite {
+			return fmt.Errorf("scopes: %q must be one of %s followed by :read or :write", scope, strings.Join(Services, ", "))
+		}
+	}
+
+	if s.ExpiresAt != nil && !s.ExpiresAt.After(now) {
+		return errors.New("expires_at: must be in the future")
+	}
+
+	return nil
+}
+
+type Response struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  string     `json:"created_by"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreateResponse has the key itself, which is shown only this once.
+type CreateResponse struct {
+	Response
+	Key string `json:"key"`
+}
+
+func ParseFromEntity(data Entity) Response {
+	return Response{
+		ID:         data.ID,
+		Name:       data.Name,
+		Prefix:     data.Prefix,
+		Scopes:     data.Scopes,
+		CreatedBy:  data.CreatedBy,
+		ExpiresAt:  data.ExpiresAt,
+		LastUsedAt: data.LastUsedAt,
+		RevokedAt:  data.RevokedAt,
+		CreatedAt:  data.CreatedAt,
+	}
+}
+
+func ParseFromEntities(data []Entity) (res []Response) {
+	res = make([]Response, 0, len(data))
+	for _, object := range data {
+		res = append(res, ParseFromEntity(object))
+	}
+	return
+}
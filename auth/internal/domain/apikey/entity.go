@@ -0,0 +1,52 @@
+package apikey
+
+import (
+	"github.com/lib/pq"
+	"time"
+)
+
+const (
+	ActionRead  = "read"
+	ActionWrite = "write"
+)
+
+// Services are the services keys can be scoped to, for the catalogue and order
+// integrations. Keys skip the route policies of the gateway, so the services
+// that manage users, their roles and payments are left out.
+var Services = []string{"product", "order"}
+
+// Entity is an API key. Only the hash of the key is kept; Prefix is its start,
+// to tell keys apart in lists.
+type Entity struct {
+	ID         string         `db:"id"`
+	Name       string         `db:"name"`
+	Prefix     string         `db:"prefix"`
+	KeyHash    string         `db:"key_hash"`
+	Scopes     pq.StringArray `db:"scopes"`
+	CreatedBy  string         `db:"created_by"`
+	ExpiresAt  *time.Time     `db:"expires_at"`
+	LastUsedAt *time.Time     `db:"last_used_at"`
+	RevokedAt  *time.Time     `db:"revoked_at"`
+	CreatedAt  time.Time      `db:"created_at"`
+}
+
+// Active reports whether the key can be used at now.
+func (e Entity) Active(now time.Time) bool {
+	return e.RevokedAt == nil && (e.ExpiresAt == nil || e.ExpiresAt.After(now))
+}
+
+// Allows reports whether the key is scoped to the action on the service.
+func (e Entity) Allows(service, action string) bool {
+	for _, scope := range e.Scopes {
+		if scope == Scope(service, action) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Scope returns the scope of the action on the service, as in "product:read".
+func Scope(service, action string) string {
+	return service + ":" + action
+}
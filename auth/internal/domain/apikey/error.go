@@ -0,0 +1,8 @@
+package apikey
+
+import "errors"
+
+var (
+	ErrorInvalid = errors.New("api key is invalid, expired or revoked")
+	ErrorScope   = errors.New("api key is not scoped to this action")
+)
@@ -0,0 +1,32 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+const (
+	keyPrefix = "msk_"
+	// prefixLength is how much of the key is kept to recognise it by.
+	prefixLength = len(keyPrefix) + 8
+)
+
+// NewKey returns a new random key and its prefix.
+func NewKey() (value, prefix string, err error) {
+	b := make([]byte, 32)
+	if _, err = rand.Read(b); err != nil {
+		return
+	}
+
+	value = keyPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	return value, value[:prefixLength], nil
+}
+
+// HashKey returns the hash keys are stored and looked up by.
+func HashKey(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,11 @@
+package apikey
+
+import "context"
+
+type Repository interface {
+	List(ctx context.Context) (dest []Entity, err error)
+	Add(ctx context.Context, data Entity) (dest Entity, err error)
+	GetByHash(ctx context.Context, keyHash string) (dest Entity, err error)
+	Revoke(ctx context.Context, id string) (dest Entity, err error)
+	Touch(ctx context.Context, id string) (err error)
+}
@@ -0,0 +1,234 @@
+package auth
+
+import (
+	"errors"
+	"github.com/yrss1/my-shop/auth/pkg/token"
+	"time"
+)
+
+const (
+	// RoleCustomer is the role of users that register themselves.
+	RoleCustomer = "customer"
+	RoleAdmin    = "admin"
+)
+
+const (
+	minPasswordLength = 8
+	// maxPasswordLength is the most bcrypt hashes.
+	maxPasswordLength = 72
+)
+
+type RegisterRequest struct {
+	Name     *string `json:"name"`
+	Email    *string `json:"email"`
+	Password *string `json:"password"`
+	Address  *string `json:"address"`
+}
+
+func (s *RegisterRequest) Validate() error {
+	if s.Name == nil || *s.Name == "" {
+		return errors.New("name: cannot be blank")
+	}
+
+	if s.Email == nil || *s.Email == "" {
+		return errors.New("email: cannot be blank")
+	}
+
+	return validatePassword(s.Password)
+}
+
+func validatePassword(password *string) error {
+	if password == nil || len(*password) < minPasswordLength {
+		return errors.New("password: must be at least 8 characters long")
+	}
+
+	if len(*password) > maxPasswordLength {
+		return errors.New("password: cannot be longer than 72 bytes")
+	}
+
+	return nil
+}
+
+type LoginRequest struct {
+	Email    *string `json:"email"`
+	Password *string `json:"password"`
+	// IP is the address of the client, failures are counted for it.
+	IP string `json:"-"`
+}
+
+func (s *LoginRequest) Validate() error {
+	if s.Email == nil || *s.Email == "" {
+		return errors.New("email: cannot be blank")
+	}
+
+	if s.Password == nil || *s.Password == "" {
+		return errors.New("password: cannot be blank")
+	}
+
+	return nil
+}
+
+type RefreshRequest struct {
+	RefreshToken *string `json:"refresh_token"`
+}
+
+func (s *RefreshRequest) Validate() error {
+	if s.RefreshToken == nil || *s.RefreshToken == "" {
+		return errors.New("refresh_token: cannot be blank")
+	}
+
+	return nil
+}
+
+type EmailRequest struct {
+	Email *string `json:"email"`
+}
+
+func (s *EmailRequest) Validate() error {
+	if s.Email == nil || *s.Email == "" {
+		return errors.New("email: cannot be blank")
+	}
+
+	return nil
+}
+
+// VerifyEmailRequest carries the token of the link in the verification email.
+type VerifyEmailRequest struct {
+	Token *string `json:"token"`
+}
+
+func (s *VerifyEmailRequest) Validate() error {
+	if s.Token == nil || *s.Token == "" {
+		return errors.New("token: cannot be blank")
+	}
+
+	return nil
+}
+
+// ResetPasswordRequest carries the token of the link in the password reset
+// email and the new password.
+type ResetPasswordRequest struct {
+	Token    *string `json:"token"`
+	Password *string `json:"password"`
+}
+
+func (s *ResetPasswordRequest) Validate() error {
+	if s.Token == nil || *s.Token == "" {
+		return errors.New("token: cannot be blank")
+	}
+
+	return validatePassword(s.Password)
+}
+
+// ChangePasswordRequest sets a new password for a signed in user, who has to
+// know the current one.
+type ChangePasswordRequest struct {
+	CurrentPassword *string `json:"current_password"`
+	Password        *string `json:"password"`
+	IP              string  `json:"-"`
+}
+
+func (s *ChangePasswordRequest) Validate() error {
+	if s.CurrentPassword == nil || *s.CurrentPassword == "" {
+		return errors.New("current_password: cannot be blank")
+	}
+
+	return validatePassword(s.Password)
+}
+
+// TwoFactorLoginRequest finishes a login with the challenge token it returned
+// and a code of the authenticator app or a recovery code.
+type TwoFactorLoginRequest struct {
+	ChallengeToken *string `json:"challenge_token"`
+	Code           *string `json:"code"`
+	IP             string  `json:"-"`
+}
+
+func (s *TwoFactorLoginRequest) Validate() error {
+	if s.ChallengeToken == nil || *s.ChallengeToken == "" {
+		return errors.New("challenge_token: cannot be blank")
+	}
+
+	if s.Code == nil || *s.Code == "" {
+		return errors.New("code: cannot be blank")
+	}
+
+	return nil
+}
+
+type CodeRequest struct {
+	Code *string `json:"code"`
+}
+
+func (s *CodeRequest) Validate() error {
+	if s.Code == nil || *s.Code == "" {
+		return errors.New("code: cannot be blank")
+	}
+
+	return nil
+}
+
+// LoginResponse has the tokens of the login, or the challenge to pass when
+// the login needs a second factor.
+type LoginResponse struct {
+	*TokenResponse
+	Challenge *ChallengeResponse `json:"challenge,omitempty"`
+}
+
+type ChallengeResponse struct {
+	ChallengeToken string `json:"challenge_token"`
+	// ExpiresIn is the lifetime of the challenge token in seconds.
+	ExpiresIn int `json:"expires_in"`
+	// EnrollmentRequired is set when the role of the user requires two-factor
+	// authentication and the user is yet to enrol. The challenge token then
+	// authenticates the enrolment, which finishes the login.
+	EnrollmentRequired bool `json:"enrollment_required"`
+}
+
+type EnrollResponse struct {
+	Secret string `json:"secret"`
+	// URI is the otpauth URI to show as a QR code.
+	URI string `json:"uri"`
+}
+
+// TwoFactorResponse has the recovery codes of the user, shown only this once.
+// When the enrolment finished a login it has the tokens of the login too.
+type TwoFactorResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+	*TokenResponse
+}
+
+type TwoFactorStatusResponse struct {
+	Enabled  bool `json:"enabled"`
+	Required bool `json:"required"`
+	// RecoveryCodesLeft is the number of unused recovery codes.
+	RecoveryCodesLeft int `json:"recovery_codes_left"`
+}
+
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	// ExpiresIn is the lifetime of the access token in seconds.
+	ExpiresIn int `json:"expires_in"`
+}
+
+type RevokeResponse struct {
+	// Revoked is the number of sessions that were revoked.
+	Revoked int `json:"revoked"`
+}
+
+func ParseFromPair(data token.Pair) TokenResponse {
+	return TokenResponse{
+		AccessToken:  data.Access,
+		RefreshToken: data.Refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(time.Until(data.AccessExpiresAt).Round(time.Second).Seconds()),
+	}
+}
+
+type PermissionsResponse struct {
+	UserID      string   `json:"user_id"`
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+}
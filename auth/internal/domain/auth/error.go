@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrorInvalidCredentials = errors.New("invalid email or password")
+	ErrorEmailTaken         = errors.New("email is already in use")
+	ErrorInvalidToken       = errors.New("token is invalid, expired or revoked")
+	ErrorAdminRequired      = errors.New("admin role is required")
+
+	ErrorInvalidCode       = errors.New("code is invalid")
+	ErrorTwoFactorEnabled  = errors.New("two-factor authentication is already enabled")
+	ErrorTwoFactorDisabled = errors.New("two-factor authentication is not enabled")
+	ErrorTwoFactorRequired = errors.New("two-factor authentication is required for the role")
+
+	ErrorLocked = errors.New("too many failed attempts, try again later")
+)
+
+// LockedError is returned while logins are locked out after too many
+// failures. It matches ErrorLocked.
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return ErrorLocked.Error()
+}
+
+func (e *LockedError) Unwrap() error {
+	return ErrorLocked
+}
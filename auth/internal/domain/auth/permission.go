@@ -0,0 +1,38 @@
+package auth
+
+// Permissions are named resource:action, with an :own suffix for actions
+// limited to the resources of the user. The list is advisory, for clients to
+// decide what to offer: it is kept by hand after the policy table of the
+// gateway, which stays the one that allows or refuses requests. Customers
+// can't create payments, the gateway keeps their amount to admins.
+var permissions = map[string][]string{
+	RoleCustomer: {
+		"products:read",
+		"categories:read",
+		"users:read:own",
+		"users:update:own",
+		"orders:create",
+		"orders:read:own",
+		"orders:update:own",
+		"payments:read:own",
+	},
+	RoleAdmin: {
+		"products:read",
+		"products:write",
+		"categories:read",
+		"categories:write",
+		"users:read",
+		"users:write",
+		"orders:read",
+		"orders:write",
+		"payments:read",
+		"payments:write",
+		"sessions:revoke",
+		"lockouts:read",
+	},
+}
+
+// Permissions returns the permissions of the role. Unknown roles have none.
+func Permissions(role string) []string {
+	return append([]string{}, permissions[role]...)
+}
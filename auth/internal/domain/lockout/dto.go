@@ -0,0 +1,31 @@
+package lockout
+
+import "time"
+
+type EventResponse struct {
+	ID          string    `json:"id"`
+	Scope       string    `json:"scope"`
+	Subject     string    `json:"subject"`
+	Failures    int       `json:"failures"`
+	LockedUntil time.Time `json:"locked_until"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func ParseFromEvent(data Event) EventResponse {
+	return EventResponse{
+		ID:          data.ID,
+		Scope:       data.Scope,
+		Subject:     data.Subject,
+		Failures:    data.Failures,
+		LockedUntil: data.LockedUntil,
+		CreatedAt:   data.CreatedAt,
+	}
+}
+
+func ParseFromEvents(data []Event) (res []EventResponse) {
+	res = make([]EventResponse, 0, len(data))
+	for _, object := range data {
+		res = append(res, ParseFromEvent(object))
+	}
+	return
+}
@@ -0,0 +1,45 @@
+package lockout
+
+import "time"
+
+// Scopes of the failures that are counted.
+const (
+	ScopeAccount   = "account"
+	ScopeIP        = "ip"
+	ScopeTwoFactor = "two_factor"
+)
+
+// Key is what failures are counted for: an email, an IP address or the user
+// of a two-factor login.
+type Key struct {
+	Scope   string
+	Subject string
+}
+
+// Entity counts the recent failures of a key.
+type Entity struct {
+	Scope         string     `db:"scope"`
+	Subject       string     `db:"subject"`
+	Failures      int        `db:"failures"`
+	LastFailureAt *time.Time `db:"last_failure_at"`
+	LockedUntil   *time.Time `db:"locked_until"`
+}
+
+// Locked returns how long the key is still locked out at now.
+func (e Entity) Locked(now time.Time) (remaining time.Duration, locked bool) {
+	if e.LockedUntil == nil || !e.LockedUntil.After(now) {
+		return
+	}
+
+	return e.LockedUntil.Sub(now), true
+}
+
+// Event is recorded every time a failure locks a key out.
+type Event struct {
+	ID          string    `db:"id"`
+	Scope       string    `db:"scope"`
+	Subject     string    `db:"subject"`
+	Failures    int       `db:"failures"`
+	LockedUntil time.Time `db:"locked_until"`
+	CreatedAt   time.Time `db:"created_at"`
+}
@@ -0,0 +1,74 @@
+package lockout
+
+import "time"
+
+// Policy sets when a key is locked out. From Threshold failures on every
+// failure locks the key, for BaseDelay at first and twice as long with each
+// further failure, up to MaxDelay. Failures are forgotten after Window
+// without one, counted from the end of the last lockout.
+type Policy struct {
+	Threshold int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	Window    time.Duration
+}
+
+// Enabled reports whether failures of the scope are counted at all.
+func (p Policy) Enabled() bool {
+	return p.Threshold > 0
+}
+
+// Delay returns how long the key is locked out after its nth failure.
+func (p Policy) Delay(failures int) time.Duration {
+	if failures < p.Threshold {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := p.Threshold; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, p.MaxDelay)
+}
+
+// Fail adds a failure at now to data and locks it out when the policy says
+// so. It is shared by the repositories, which only have to store the result.
+// Attempts are counted as failures before they are checked, and Release takes
+// back the ones that did not fail.
+func (p Policy) Fail(data Entity, now time.Time) (dest Entity, locked bool) {
+	dest = data
+
+	last := data.LastFailureAt
+	if data.LockedUntil != nil && (last == nil || data.LockedUntil.After(*last)) {
+		last = data.LockedUntil
+	}
+	if last == nil || now.Sub(*last) > p.Window {
+		dest.Failures = 0
+	}
+
+	dest.Failures++
+	dest.LastFailureAt = &now
+
+	if delay := p.Delay(dest.Failures); delay > 0 {
+		until := now.Add(delay)
+		dest.LockedUntil = &until
+		locked = true
+	}
+
+	return
+}
+
+// Release takes back a failure counted by Fail for an attempt that did not
+// fail, lifting the lockout it caused unless the failures left still call for
+// one.
+func (p Policy) Release(data Entity) (dest Entity) {
+	dest = data
+
+	dest.Failures = max(0, dest.Failures-1)
+	if p.Delay(dest.Failures) == 0 {
+		dest.LockedUntil = nil
+	}
+
+	return
+}
@@ -0,0 +1,126 @@
+package lockout
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPolicyDelay(t *testing.T) {
+	policy := Policy{Threshold: 3, BaseDelay: time.Minute, MaxDelay: 10 * time.Minute, Window: time.Hour}
+
+	tests := []struct {
+		name     string
+		policy   Policy
+		failures int
+		want     time.Duration
+	}{
+		{"no failures", policy, 0, 0},
+		{"below the threshold", policy, 2, 0},
+		{"at the threshold", policy, 3, time.Minute},
+		{"doubles", policy, 4, 2 * time.Minute},
+		{"doubles again", policy, 5, 4 * time.Minute},
+		{"capped", policy, 7, 10 * time.Minute},
+		{"stays capped", policy, 1000, 10 * time.Minute},
+		{"base above the max", Policy{Threshold: 1, BaseDelay: time.Hour, MaxDelay: time.Minute}, 1, time.Minute},
+		{"threshold of one", Policy{Threshold: 1, BaseDelay: time.Second, MaxDelay: time.Hour}, 3, 4 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Delay(tt.failures); got != tt.want {
+				t.Errorf("Delay(%d) = %v, want %v", tt.failures, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyEnabled(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+		want   bool
+	}{
+		{"zero", Policy{}, false},
+		{"negative threshold", Policy{Threshold: -1}, false},
+		{"threshold", Policy{Threshold: 1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Enabled(); got != tt.want {
+				t.Errorf("Enabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyFail(t *testing.T) {
+	policy := Policy{Threshold: 2, BaseDelay: time.Minute, MaxDelay: time.Hour, Window: 10 * time.Minute}
+	now := time.Date(2024, 8, 17, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+
+	tests := []struct {
+		name         string
+		data         Entity
+		wantFailures int
+		wantUntil    *time.Time
+	}{
+		{"first failure", Entity{}, 1, nil},
+		{"within the window", Entity{Failures: 1, LastFailureAt: at(-5 * time.Minute)}, 2, at(time.Minute)},
+		{"after the window", Entity{Failures: 5, LastFailureAt: at(-11 * time.Minute)}, 1, nil},
+		{"window counts from the end of the lockout", Entity{Failures: 3, LastFailureAt: at(-20 * time.Minute), LockedUntil: at(-5 * time.Minute)}, 4, at(4 * time.Minute)},
+		{"window over after the lockout", Entity{Failures: 3, LastFailureAt: at(-30 * time.Minute), LockedUntil: at(-11 * time.Minute)}, 1, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, locked := policy.Fail(tt.data, now)
+
+			if got.Failures != tt.wantFailures {
+				t.Errorf("Fail() failures = %d, want %d", got.Failures, tt.wantFailures)
+			}
+			if got.LastFailureAt == nil || !got.LastFailureAt.Equal(now) {
+				t.Errorf("Fail() last failure = %v, want %v", got.LastFailureAt, now)
+			}
+			if locked != (tt.wantUntil != nil) {
+				t.Errorf("Fail() locked = %v, want %v", locked, tt.wantUntil != nil)
+			}
+			if tt.wantUntil != nil && (got.LockedUntil == nil || !got.LockedUntil.Equal(*tt.wantUntil)) {
+				t.Errorf("Fail() locked until = %v, want %v", got.LockedUntil, *tt.wantUntil)
+			}
+		})
+	}
+}
+
+func TestPolicyRelease(t *testing.T) {
+	policy := Policy{Threshold: 2, BaseDelay: time.Minute, MaxDelay: time.Hour, Window: 10 * time.Minute}
+	until := time.Date(2024, 8, 17, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		data         Entity
+		wantFailures int
+		wantLocked   bool
+	}{
+		{"nothing counted", Entity{}, 0, false},
+		{"below the threshold", Entity{Failures: 1}, 0, false},
+		{"lifts the lockout it caused", Entity{Failures: 2, LockedUntil: &until}, 1, false},
+		{"keeps an earlier lockout", Entity{Failures: 3, LockedUntil: &until}, 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := policy.Release(tt.data)
+
+			if got.Failures != tt.wantFailures {
+				t.Errorf("Release() failures = %d, want %d", got.Failures, tt.wantFailures)
+			}
+			if (got.LockedUntil != nil) != tt.wantLocked {
+				t.Errorf("Release() locked until = %v, want locked %v", got.LockedUntil, tt.wantLocked)
+			}
+		})
+	}
+}
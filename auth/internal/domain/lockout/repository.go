@@ -0,0 +1,22 @@
+package lockout
+
+import (
+	"context"
+	"time"
+)
+
+// Repository keeps failure counters and lockout events. Attempt and Release
+// have to apply the policy atomically, so that concurrent attempts of a key
+// are all counted and none gets past a lockout.
+type Repository interface {
+	// Attempt counts an attempt of the key as a failure unless the key is
+	// locked out at now. A locked out key is returned unchanged and not
+	// allowed.
+	Attempt(ctx context.Context, key Key, policy Policy, now time.Time) (dest Entity, allowed bool, err error)
+	// Release takes back the failure counted for an allowed attempt that
+	// did not fail.
+	Release(ctx context.Context, key Key, policy Policy) (err error)
+	Reset(ctx context.Context, key Key) (err error)
+	AddEvent(ctx context.Context, data Event) (err error)
+	ListEvents(ctx context.Context, limit int) (dest []Event, err error)
+}
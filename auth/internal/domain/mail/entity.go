@@ -0,0 +1,14 @@
+package mail
+
+import "time"
+
+// Entity is an email in the outbox. It is added in the transaction of the
+// change it tells about and sent after that committed.
+type Entity struct {
+	ID        string    `db:"id"`
+	Recipient string    `db:"recipient"`
+	Subject   string    `db:"subject"`
+	Body      string    `db:"body"`
+	Attempts  int       `db:"attempts"`
+	CreatedAt time.Time `db:"created_at"`
+}
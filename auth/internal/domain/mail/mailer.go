@@ -0,0 +1,32 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"time"
+)
+
+// Mailer sends emails.
+type Mailer interface {
+	Send(ctx context.Context, data Entity) (err error)
+}
+
+// Format renders the email as a plain text message from the sender from.
+func Format(from string, data Entity) []byte {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", data.Recipient)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", data.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", data.CreatedAt.Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "Message-ID: <%s@%s>\r\n", data.ID, "my-shop")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(data.Body)
+
+	return b.Bytes()
+}
@@ -0,0 +1,13 @@
+package mail
+
+import (
+	"context"
+	"time"
+)
+
+type Repository interface {
+	Add(ctx context.Context, data Entity) (err error)
+	Claim(ctx context.Context, limit int, lease time.Duration) (dest []Entity, err error)
+	MarkSent(ctx context.Context, id string) (err error)
+	MarkFailed(ctx context.Context, id string, reason string) (err error)
+}
@@ -0,0 +1,15 @@
+package session
+
+import "time"
+
+// Entity is a refresh token. Tokens rotated from the same login share the
+// SessionID.
+type Entity struct {
+	ID        string     `db:"id"`
+	SessionID string     `db:"session_id"`
+	UserID    string     `db:"user_id"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	RevokedAt *time.Time `db:"revoked_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
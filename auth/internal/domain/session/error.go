@@ -0,0 +1,8 @@
+package session
+
+import "errors"
+
+var (
+	ErrorRevoked = errors.New("session is revoked")
+	ErrorReused  = errors.New("refresh token was already used")
+)
@@ -0,0 +1,12 @@
+package session
+
+import "context"
+
+type Repository interface {
+	Add(ctx context.Context, data Entity) (err error)
+	Rotate(ctx context.Context, id string, next Entity) (err error)
+	RevokeSession(ctx context.Context, userID, sessionID string) (err error)
+	RevokeUser(ctx context.Context, userID string) (count int, err error)
+	Active(ctx context.Context, userID, sessionID string) (active bool, err error)
+	Unused(ctx context.Context, id string) (unused bool, err error)
+}
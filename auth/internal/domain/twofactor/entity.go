@@ -0,0 +1,19 @@
+package twofactor
+
+import "time"
+
+// Entity is the TOTP secret of a user. It only guards logins once it is
+// enabled, which happens when the user confirms the enrolment with a code.
+type Entity struct {
+	UserID    string     `db:"user_id"`
+	Secret    string     `db:"secret"`
+	EnabledAt *time.Time `db:"enabled_at"`
+	// LastStep is the time step of the last accepted code.
+	LastStep  int64     `db:"last_step"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+func (e Entity) Enabled() bool {
+	return e.EnabledAt != nil
+}
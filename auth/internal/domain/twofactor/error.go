@@ -0,0 +1,7 @@
+package twofactor
+
+import "errors"
+
+var (
+	ErrorStepUsed = errors.New("code was already used")
+)
@@ -0,0 +1,38 @@
+package twofactor
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"strings"
+)
+
+// RecoveryCodeCount is how many recovery codes a user gets at a time.
+const RecoveryCodeCount = 10
+
+// NewRecoveryCodes returns a fresh set of recovery codes, formatted as
+// XXXXX-XXXXX.
+func NewRecoveryCodes() (codes []string, err error) {
+	codes = make([]string, 0, RecoveryCodeCount)
+	for range RecoveryCodeCount {
+		b := make([]byte, 7)
+		if _, err = rand.Read(b); err != nil {
+			return
+		}
+
+		value := base32.StdEncoding.EncodeToString(b)[:10]
+		codes = append(codes, value[:5]+"-"+value[5:])
+	}
+
+	return
+}
+
+// HashRecoveryCode returns the hash recovery codes are stored and looked up
+// by. Case, dashes and spaces are ignored, as users tend to type them freely.
+func HashRecoveryCode(value string) string {
+	value = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(value))
+
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
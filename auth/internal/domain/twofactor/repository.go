@@ -0,0 +1,15 @@
+package twofactor
+
+import "context"
+
+type Repository interface {
+	Get(ctx context.Context, userID string) (dest Entity, err error)
+	Save(ctx context.Context, data Entity) (err error)
+	Enable(ctx context.Context, userID string, step int64, codeHashes []string) (err error)
+	Delete(ctx context.Context, userID string) (err error)
+
+	UseStep(ctx context.Context, userID string, step int64) (err error)
+	UseRecoveryCode(ctx context.Context, userID, codeHash string) (err error)
+	ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string) (err error)
+	CountRecoveryCodes(ctx context.Context, userID string) (count int, err error)
+}
@@ -0,0 +1,20 @@
+package verification
+
+import "time"
+
+const (
+	PurposeVerifyEmail   = "verify_email"
+	PurposeResetPassword = "reset_password"
+)
+
+// Entity is a single use token sent to the email of a user to prove that the
+// user reads it.
+type Entity struct {
+	ID        string     `db:"id"`
+	UserID    string     `db:"user_id"`
+	Purpose   string     `db:"purpose"`
+	TokenHash string     `db:"token_hash"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
@@ -0,0 +1,9 @@
+package verification
+
+import "context"
+
+type Repository interface {
+	Add(ctx context.Context, data Entity) (err error)
+	Consume(ctx context.Context, purpose, tokenHash string) (dest Entity, err error)
+	Revoke(ctx context.Context, userID, purpose string) (err error)
+}
@@ -0,0 +1,24 @@
+package verification
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// NewToken returns a random URL safe token.
+func NewToken() (value string, err error) {
+	b := make([]byte, 32)
+	if _, err = rand.Read(b); err != nil {
+		return
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken returns the hash tokens are stored and looked up by.
+func HashToken(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
@@ -1 +1,102 @@
 package grpc_handler
+
+import (
+	"context"
+	"errors"
+	"github.com/yrss1/my-shop/auth/internal/domain/apikey"
+	"github.com/yrss1/my-shop/auth/internal/domain/auth"
+	"github.com/yrss1/my-shop/auth/internal/service/authService"
+	pb "github.com/yrss1/my-shop/proto/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type AuthServiceServer struct {
+	pb.UnimplementedAuthServiceServer
+	authService *authService.Service
+}
+
+func NewAuthServiceServer(s *authService.Service) *AuthServiceServer {
+	return &AuthServiceServer{authService: s}
+}
+
+func (s *AuthServiceServer) ValidateToken(ctx context.Context, req *pb.ValidateTokenRequest) (res *pb.ValidateTokenResponse, err error) {
+	claims, err := s.authService.Authenticate(ctx, req.Token)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, err.Error())
+	}
+
+	res = &pb.ValidateTokenResponse{
+		UserId:    claims.Subject,
+		Role:      claims.Role,
+		SessionId: claims.SessionID,
+		ExpiresAt: claims.ExpiresAt.Unix(),
+	}
+
+	return
+}
+
+func (s *AuthServiceServer) IntrospectToken(ctx context.Context, req *pb.IntrospectTokenRequest) (res *pb.IntrospectTokenResponse, err error) {
+	claims, active, err := s.authService.IntrospectToken(ctx, req.Token)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "internal server error: %v", err)
+	}
+
+	res = &pb.IntrospectTokenResponse{Active: active}
+	if !active {
+		return
+	}
+
+	res.TokenType = claims.Type
+	res.UserId = claims.Subject
+	res.Role = claims.Role
+	res.SessionId = claims.SessionID
+	res.TokenId = claims.ID
+	res.Issuer = claims.Issuer
+	res.IssuedAt = claims.IssuedAt.Unix()
+	res.ExpiresAt = claims.ExpiresAt.Unix()
+
+	return
+}
+
+func (s *AuthServiceServer) GetPermissions(ctx context.Context, req *pb.GetPermissionsRequest) (res *pb.GetPermissionsResponse, err error) {
+	found, err := s.authService.GetPermissions(ctx, req.Token)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrorInvalidToken):
+			return nil, status.Errorf(codes.Unauthenticated, err.Error())
+		default:
+			return nil, status.Errorf(codes.Internal, "internal server error: %v", err)
+		}
+	}
+
+	res = &pb.GetPermissionsResponse{
+		UserId:      found.UserID,
+		Role:        found.Role,
+		Permissions: found.Permissions,
+	}
+
+	return
+}
+
+func (s *AuthServiceServer) ValidateAPIKey(ctx context.Context, req *pb.ValidateAPIKeyRequest) (res *pb.ValidateAPIKeyResponse, err error) {
+	found, err := s.authService.ValidateAPIKey(ctx, req.Key, req.Service, req.Action)
+	if err != nil {
+		switch {
+		case errors.Is(err, apikey.ErrorInvalid):
+			return nil, status.Errorf(codes.Unauthenticated, err.Error())
+		case errors.Is(err, apikey.ErrorScope):
+			return nil, status.Errorf(codes.PermissionDenied, err.Error())
+		default:
+			return nil, status.Errorf(codes.Internal, "internal server error: %v", err)
+		}
+	}
+
+	res = &pb.ValidateAPIKeyResponse{
+		KeyId:  found.ID,
+		Name:   found.Name,
+		Scopes: found.Scopes,
+	}
+
+	return
+}
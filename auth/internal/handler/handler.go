@@ -4,10 +4,12 @@ import (
 	"github.com/gin-contrib/timeout"
 	"github.com/gin-gonic/gin"
 	"github.com/yrss1/my-shop/auth/internal/config"
+	"github.com/yrss1/my-shop/auth/internal/handler/grpc_handler"
 	"github.com/yrss1/my-shop/auth/internal/handler/http"
 	"github.com/yrss1/my-shop/auth/internal/service/authService"
 	"github.com/yrss1/my-shop/auth/pkg/server/response"
 	"github.com/yrss1/my-shop/auth/pkg/server/router"
+	pb "github.com/yrss1/my-shop/proto/auth"
 	"google.golang.org/grpc"
 )
 
@@ -64,10 +66,10 @@ func WithHTTPHandler() Configuration {
 	}
 }
 
-//func WithGRPCHandler() Configuration {
-//	return func(h *Handler) (err error) {
-//		h.GRPCServer = grpc.NewServer()
-//		pb.RegisterUserServiceServer(h.GRPCServer, grpc_handler.NewUserServiceServer(h.dependencies.AuthService))
-//		return
-//	}
-//}
+func WithGRPCHandler() Configuration {
+	return func(h *Handler) (err error) {
+		h.GRPCServer = grpc.NewServer()
+		pb.RegisterAuthServiceServer(h.GRPCServer, grpc_handler.NewAuthServiceServer(h.dependencies.AuthService))
+		return
+	}
+}
@@ -1,11 +1,26 @@
 package http
 
 import (
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/yrss1/my-shop/auth/internal/domain/apikey"
+	"github.com/yrss1/my-shop/auth/internal/domain/auth"
 	"github.com/yrss1/my-shop/auth/internal/service/authService"
 	"github.com/yrss1/my-shop/auth/pkg/server/response"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
+	"github.com/yrss1/my-shop/auth/pkg/store"
+	"github.com/yrss1/my-shop/auth/pkg/token"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const claimsKey = "claims"
+
+const (
+	defaultLockoutLimit = 50
+	maxLockoutLimit     = 500
 )
 
 type UserHandler struct {
@@ -20,7 +35,30 @@ func (h *UserHandler) Routes(r *gin.RouterGroup) {
 	api := r.Group("/auth")
 	{
 		api.GET("/", h.hello)
-		api.GET("/user", h.getUserByEmail)
+		api.POST("/register", h.register)
+		api.POST("/login", h.login)
+		api.POST("/login/2fa", h.loginTwoFactor)
+		api.POST("/refresh", h.refresh)
+		api.POST("/logout", h.logout)
+
+		api.POST("/verify-email", h.verifyEmail)
+		api.POST("/verify-email/resend", h.resendVerification)
+		api.POST("/password/forgot", h.forgotPassword)
+		api.POST("/password/reset", h.resetPassword)
+		api.POST("/password/change", h.authenticate, h.changePassword)
+
+		api.GET("/2fa", h.authenticate, h.getTwoFactor)
+		api.POST("/2fa/enroll", h.authenticateEnrollment, h.enrollTwoFactor)
+		api.POST("/2fa/confirm", h.authenticateEnrollment, h.confirmTwoFactor)
+		api.POST("/2fa/disable", h.authenticate, h.disableTwoFactor)
+		api.POST("/2fa/recovery-codes", h.authenticate, h.regenerateRecoveryCodes)
+
+		api.DELETE("/users/:id/sessions", h.authenticate, h.requireAdmin, h.revokeUserSessions)
+		api.GET("/lockouts", h.authenticate, h.requireAdmin, h.listLockoutEvents)
+
+		api.GET("/api-keys", h.authenticate, h.requireAdmin, h.listAPIKeys)
+		api.POST("/api-keys", h.authenticate, h.requireAdmin, h.createAPIKey)
+		api.DELETE("/api-keys/:id", h.authenticate, h.requireAdmin, h.revokeAPIKey)
 	}
 }
 
@@ -28,14 +66,447 @@ func (h *UserHandler) hello(c *gin.Context) {
 	response.OK(c, "ok")
 }
 
-func (h *UserHandler) getUserByEmail(c *gin.Context) {
-	email := c.Query("email")
+func (h *UserHandler) register(c *gin.Context) {
+	req := auth.RegisterRequest{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	res, err := h.authService.Register(c, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrorEmailTaken):
+			response.Conflict(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.Created(c, res)
+}
+
+func (h *UserHandler) login(c *gin.Context) {
+	req := auth.LoginRequest{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	req.IP = c.ClientIP()
+
+	res, err := h.authService.Login(c, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrorInvalidCredentials):
+			response.Unauthorized(c, err)
+		case errors.Is(err, auth.ErrorLocked):
+			tooManyRequests(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, res)
+}
+
+func (h *UserHandler) loginTwoFactor(c *gin.Context) {
+	req := auth.TwoFactorLoginRequest{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	req.IP = c.ClientIP()
+
+	res, err := h.authService.LoginTwoFactor(c, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrorInvalidToken), errors.Is(err, auth.ErrorInvalidCode):
+			response.Unauthorized(c, err)
+		case errors.Is(err, auth.ErrorLocked):
+			tooManyRequests(c, err)
+		case errors.Is(err, auth.ErrorTwoFactorDisabled):
+			response.Conflict(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, res)
+}
+
+func (h *UserHandler) refresh(c *gin.Context) {
+	req := auth.RefreshRequest{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	res, err := h.authService.Refresh(c, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrorInvalidToken):
+			response.Unauthorized(c, err)
+		case errors.Is(err, auth.ErrorTwoFactorRequired):
+			response.Forbidden(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, res)
+}
+
+func (h *UserHandler) logout(c *gin.Context) {
+	req := auth.RefreshRequest{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	if err := h.authService.Logout(c, req); err != nil {
+		switch {
+		case errors.Is(err, auth.ErrorInvalidToken):
+			response.Unauthorized(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, "ok")
+}
+
+func (h *UserHandler) verifyEmail(c *gin.Context) {
+	req := auth.VerifyEmailRequest{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	if err := h.authService.VerifyEmail(c, req); err != nil {
+		switch {
+		case errors.Is(err, auth.ErrorInvalidToken):
+			response.BadRequest(c, err, nil)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, "ok")
+}
+
+func (h *UserHandler) resendVerification(c *gin.Context) {
+	req := auth.EmailRequest{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	if err := h.authService.ResendVerification(c, req); err != nil {
+		response.InternalServerError(c, err)
+		return
+	}
+
+	response.OK(c, "ok")
+}
+
+func (h *UserHandler) forgotPassword(c *gin.Context) {
+	req := auth.EmailRequest{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	if err := h.authService.ForgotPassword(c, req); err != nil {
+		response.InternalServerError(c, err)
+		return
+	}
+
+	response.OK(c, "ok")
+}
+
+func (h *UserHandler) resetPassword(c *gin.Context) {
+	req := auth.ResetPasswordRequest{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	if err := h.authService.ResetPassword(c, req); err != nil {
+		switch {
+		case errors.Is(err, auth.ErrorInvalidToken):
+			response.BadRequest(c, err, nil)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, "ok")
+}
+
+func (h *UserHandler) changePassword(c *gin.Context) {
+	claims := c.MustGet(claimsKey).(token.Claims)
+
+	req := auth.ChangePasswordRequest{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	req.IP = c.ClientIP()
+
+	if err := h.authService.ChangePassword(c, claims, req); err != nil {
+		switch {
+		case errors.Is(err, auth.ErrorInvalidToken), errors.Is(err, auth.ErrorInvalidCredentials):
+			response.Unauthorized(c, err)
+		case errors.Is(err, auth.ErrorLocked):
+			tooManyRequests(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, "ok")
+}
+
+func (h *UserHandler) getTwoFactor(c *gin.Context) {
+	claims := c.MustGet(claimsKey).(token.Claims)
+
+	res, err := h.authService.GetTwoFactor(c, claims)
+	if err != nil {
+		response.InternalServerError(c, err)
+		return
+	}
+
+	response.OK(c, res)
+}
+
+func (h *UserHandler) enrollTwoFactor(c *gin.Context) {
+	claims := c.MustGet(claimsKey).(token.Claims)
+
+	res, err := h.authService.EnrollTwoFactor(c, claims)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrorTwoFactorEnabled):
+			response.Conflict(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, res)
+}
+
+func (h *UserHandler) confirmTwoFactor(c *gin.Context) {
+	claims := c.MustGet(claimsKey).(token.Claims)
+
+	req := auth.CodeRequest{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	res, err := h.authService.ConfirmTwoFactor(c, claims, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrorInvalidCode):
+			response.Unauthorized(c, err)
+		case errors.Is(err, auth.ErrorTwoFactorEnabled), errors.Is(err, auth.ErrorTwoFactorDisabled):
+			response.Conflict(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, res)
+}
+
+func (h *UserHandler) disableTwoFactor(c *gin.Context) {
+	claims := c.MustGet(claimsKey).(token.Claims)
+
+	req := auth.CodeRequest{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	if err := h.authService.DisableTwoFactor(c, claims, req); err != nil {
+		switch {
+		case errors.Is(err, auth.ErrorInvalidCode), errors.Is(err, auth.ErrorInvalidToken):
+			response.Unauthorized(c, err)
+		case errors.Is(err, auth.ErrorTwoFactorRequired):
+			response.Forbidden(c, err)
+		case errors.Is(err, auth.ErrorTwoFactorDisabled):
+			response.Conflict(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, "ok")
+}
+
+func (h *UserHandler) regenerateRecoveryCodes(c *gin.Context) {
+	claims := c.MustGet(claimsKey).(token.Claims)
+
+	req := auth.CodeRequest{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	res, err := h.authService.RegenerateRecoveryCodes(c, claims, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrorInvalidCode):
+			response.Unauthorized(c, err)
+		case errors.Is(err, auth.ErrorTwoFactorDisabled):
+			response.Conflict(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, res)
+}
+
+func (h *UserHandler) revokeUserSessions(c *gin.Context) {
+	id := c.Param("id")
+
+	res, err := h.authService.RevokeUserSessions(c, id)
+	if err != nil {
+		response.InternalServerError(c, err)
+		return
+	}
+
+	response.OK(c, res)
+}
+
+func (h *UserHandler) listLockoutEvents(c *gin.Context) {
+	limit := defaultLockoutLimit
+	if value := c.Query("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > maxLockoutLimit {
+			response.BadRequest(c, fmt.Errorf("limit: must be between 1 and %d", maxLockoutLimit), nil)
+			return
+		}
+	}
+
+	res, err := h.authService.ListLockoutEvents(c, limit)
+	if err != nil {
+		response.InternalServerError(c, err)
+		return
+	}
+
+	response.OK(c, res)
+}
+
+func (h *UserHandler) listAPIKeys(c *gin.Context) {
+	res, err := h.authService.ListAPIKeys(c)
+	if err != nil {
+		response.InternalServerError(c, err)
+		return
+	}
+
+	response.OK(c, res)
+}
+
+func (h *UserHandler) createAPIKey(c *gin.Context) {
+	claims := c.MustGet(claimsKey).(token.Claims)
 
-	res, err := h.authService.GetUserByEmail(c, email)
+	req := apikey.Request{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+	if err := req.Validate(time.Now()); err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	res, err := h.authService.CreateAPIKey(c, claims.Subject, req)
 	if err != nil {
-		if status.Code(err) == codes.NotFound {
+		response.InternalServerError(c, err)
+		return
+	}
+
+	response.Created(c, res)
+}
+
+func (h *UserHandler) revokeAPIKey(c *gin.Context) {
+	id := c.Param("id")
+
+	res, err := h.authService.RevokeAPIKey(c, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrorNotFound):
 			response.NotFound(c, err)
-		} else {
+		default:
 			response.InternalServerError(c, err)
 		}
 		return
@@ -43,3 +514,70 @@ func (h *UserHandler) getUserByEmail(c *gin.Context) {
 
 	response.OK(c, res)
 }
+
+// tooManyRequests tells the client when to try again.
+func tooManyRequests(c *gin.Context, err error) {
+	var locked *auth.LockedError
+	if errors.As(err, &locked) {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+	}
+
+	response.TooManyRequests(c, err)
+}
+
+// authenticate requires a valid bearer access token and keeps its claims in
+// the context for the handlers after it.
+func (h *UserHandler) authenticate(c *gin.Context) {
+	value, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok {
+		response.Unauthorized(c, auth.ErrorInvalidToken)
+		c.Abort()
+		return
+	}
+
+	claims, err := h.authService.Authenticate(c, value)
+	if err != nil {
+		response.Unauthorized(c, err)
+		c.Abort()
+		return
+	}
+
+	c.Set(claimsKey, claims)
+	c.Next()
+}
+
+// authenticateEnrollment is authenticate that also takes the challenge token
+// of a login, so that users who have to enrol before they can log in can do
+// so.
+func (h *UserHandler) authenticateEnrollment(c *gin.Context) {
+	value, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok {
+		response.Unauthorized(c, auth.ErrorInvalidToken)
+		c.Abort()
+		return
+	}
+
+	claims, err := h.authService.Authenticate(c, value)
+	if err != nil {
+		claims, err = h.authService.AuthenticateChallenge(c, value)
+	}
+	if err != nil {
+		response.Unauthorized(c, err)
+		c.Abort()
+		return
+	}
+
+	c.Set(claimsKey, claims)
+	c.Next()
+}
+
+// requireAdmin lets only admins past. It runs after authenticate.
+func (h *UserHandler) requireAdmin(c *gin.Context) {
+	if claims, _ := c.MustGet(claimsKey).(token.Claims); claims.Role != auth.RoleAdmin {
+		response.Forbidden(c, auth.ErrorAdminRequired)
+		c.Abort()
+		return
+	}
+
+	c.Next()
+}
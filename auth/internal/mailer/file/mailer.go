@@ -0,0 +1,50 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"github.com/yrss1/my-shop/auth/internal/domain/mail"
+	"github.com/yrss1/my-shop/auth/pkg/log"
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+)
+
+// Mailer writes emails as .eml files to a directory instead of sending them,
+// for running the service without a mail server.
+type Mailer struct {
+	dir  string
+	from string
+}
+
+func New(dir, from string) (m *Mailer, err error) {
+	if dir == "" {
+		err = errors.New("file mailer: undefined directory")
+		return
+	}
+
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		return
+	}
+
+	m = &Mailer{
+		dir:  dir,
+		from: from,
+	}
+
+	return
+}
+
+func (m *Mailer) Send(ctx context.Context, data mail.Entity) (err error) {
+	name := filepath.Join(m.dir, data.ID+".eml")
+	if err = os.WriteFile(name, mail.Format(m.from, data), 0o644); err != nil {
+		return
+	}
+
+	log.LoggerFromContext(ctx).Named("Mailer").Info("email written",
+		zap.String("to", data.Recipient),
+		zap.String("subject", data.Subject),
+		zap.String("file", name))
+
+	return
+}
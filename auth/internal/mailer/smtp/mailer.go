@@ -0,0 +1,44 @@
+package smtp
+
+import (
+	"context"
+	"errors"
+	"github.com/yrss1/my-shop/auth/internal/domain/mail"
+	"net"
+	"net/smtp"
+)
+
+// Mailer sends emails through an SMTP server. It authenticates only when a
+// username is set.
+type Mailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+func New(host, port, username, password, from string) (m *Mailer, err error) {
+	if host == "" {
+		err = errors.New("smtp mailer: undefined host")
+		return
+	}
+
+	if from == "" {
+		err = errors.New("smtp mailer: undefined sender")
+		return
+	}
+
+	m = &Mailer{
+		addr: net.JoinHostPort(host, port),
+		from: from,
+	}
+	if username != "" {
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return
+}
+
+// Send does not watch ctx, net/smtp has no way to cancel a send.
+func (m *Mailer) Send(ctx context.Context, data mail.Entity) (err error) {
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{data.Recipient}, mail.Format(m.from, data))
+}
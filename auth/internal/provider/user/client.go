@@ -1,7 +1,7 @@
 package user
 
 import (
-	pb "github.com/yrss1/proto-definitions/user"
+	pb "github.com/yrss1/my-shop/proto/user"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 )
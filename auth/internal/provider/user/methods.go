@@ -2,10 +2,17 @@ package user
 
 import (
 	"context"
-	pb "github.com/yrss1/proto-definitions/user"
+	pb "github.com/yrss1/my-shop/proto/user"
 	"time"
 )
 
+func (c *Client) GetUser(ctx context.Context, userID string) (*pb.UserResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return c.client.GetUser(ctx, &pb.GetUserRequest{UserId: userID})
+}
+
 func (c *Client) GetUserByEmail(ctx context.Context, email string) (res *pb.UserResponse, err error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
@@ -25,3 +32,24 @@ func (c *Client) RegisterUser(ctx context.Context, user *pb.UserRequest) (*pb.Us
 
 	return c.client.RegisterUser(ctx, user)
 }
+
+func (c *Client) VerifyPassword(ctx context.Context, email, password string) (*pb.UserResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return c.client.VerifyPassword(ctx, &pb.VerifyPasswordRequest{Email: email, Password: password})
+}
+
+func (c *Client) MarkEmailVerified(ctx context.Context, userID string) (*pb.UserResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return c.client.MarkEmailVerified(ctx, &pb.MarkEmailVerifiedRequest{UserId: userID})
+}
+
+func (c *Client) SetPassword(ctx context.Context, userID, password string) (*pb.UserResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return c.client.SetPassword(ctx, &pb.SetPasswordRequest{UserId: userID, Password: password})
+}
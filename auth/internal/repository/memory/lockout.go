@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"context"
+	"github.com/yrss1/my-shop/auth/internal/domain/lockout"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxEvents is how many lockout events are kept, older ones are dropped.
+const maxEvents = 1000
+
+// LockoutRepository keeps failure counters in memory. It is meant for tests
+// and single instance setups; the counters are lost on restart.
+type LockoutRepository struct {
+	mu       sync.Mutex
+	failures map[lockout.Key]lockout.Entity
+	events   []lockout.Event
+	lastID   int
+}
+
+func NewLockoutRepository() *LockoutRepository {
+	return &LockoutRepository{failures: make(map[lockout.Key]lockout.Entity)}
+}
+
+// Attempt checks and counts the attempt under one lock, so that concurrent
+// attempts can't get past a lockout.
+func (r *LockoutRepository) Attempt(ctx context.Context, key lockout.Key, policy lockout.Policy, now time.Time) (dest lockout.Entity, allowed bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.failures[key]
+	if !ok {
+		current = lockout.Entity{Scope: key.Scope, Subject: key.Subject}
+	}
+
+	if _, locked := current.Locked(now); locked {
+		return current, false, nil
+	}
+
+	dest, _ = policy.Fail(current, now)
+	r.failures[key] = dest
+	allowed = true
+
+	return
+}
+
+func (r *LockoutRepository) Release(ctx context.Context, key lockout.Key, policy lockout.Policy) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if current, ok := r.failures[key]; ok {
+		r.failures[key] = policy.Release(current)
+	}
+
+	return
+}
+
+func (r *LockoutRepository) Reset(ctx context.Context, key lockout.Key) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.failures, key)
+
+	return
+}
+
+// AddEvent keeps the event, stamped with the current time unless it has one.
+func (r *LockoutRepository) AddEvent(ctx context.Context, data lockout.Event) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastID++
+	data.ID = strconv.Itoa(r.lastID)
+	if data.CreatedAt.IsZero() {
+		data.CreatedAt = time.Now()
+	}
+
+	r.events = append(r.events, data)
+	if len(r.events) > maxEvents {
+		r.events = r.events[len(r.events)-maxEvents:]
+	}
+
+	return
+}
+
+func (r *LockoutRepository) ListEvents(ctx context.Context, limit int) (dest []lockout.Event, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	dest = make([]lockout.Event, 0, min(limit, len(r.events)))
+	for i := len(r.events) - 1; i >= 0 && len(dest) < limit; i-- {
+		dest = append(dest, r.events[i])
+	}
+
+	return
+}
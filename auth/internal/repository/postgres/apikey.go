@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"github.com/yrss1/my-shop/auth/internal/domain/apikey"
+	"github.com/yrss1/my-shop/auth/pkg/store"
+)
+
+type APIKeyRepository struct {
+	db *sqlx.DB
+}
+
+func NewAPIKeyRepository(db *sqlx.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+const apiKeyColumns = "id, name, prefix, key_hash, scopes, created_by, expires_at, last_used_at, revoked_at, created_at"
+
+func (r *APIKeyRepository) List(ctx context.Context) (dest []apikey.Entity, err error) {
+	query := `
+		SELECT ` + apiKeyColumns + `
+		FROM api_keys
+		ORDER BY created_at DESC, id`
+
+	err = store.Conn(ctx, r.db).SelectContext(ctx, &dest, query)
+
+	return
+}
+
+func (r *APIKeyRepository) Add(ctx context.Context, data apikey.Entity) (dest apikey.Entity, err error) {
+	query := `
+		INSERT INTO api_keys (name, prefix, key_hash, scopes, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + apiKeyColumns
+
+	args := []any{data.Name, data.Prefix, data.KeyHash, data.Scopes, data.CreatedBy, data.ExpiresAt}
+
+	err = store.Conn(ctx, r.db).GetContext(ctx, &dest, query, args...)
+
+	return
+}
+
+func (r *APIKeyRepository) GetByHash(ctx context.Context, keyHash string) (dest apikey.Entity, err error) {
+	query := `
+		SELECT ` + apiKeyColumns + `
+		FROM api_keys
+		WHERE key_hash=$1`
+
+	if err = store.Conn(ctx, r.db).GetContext(ctx, &dest, query, keyHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = store.ErrorNotFound
+		}
+	}
+
+	return
+}
+
+// Revoke revokes the key and returns it. Revoking a revoked key keeps the
+// time it was first revoked.
+func (r *APIKeyRepository) Revoke(ctx context.Context, id string) (dest apikey.Entity, err error) {
+	query := `
+		UPDATE api_keys
+		SET revoked_at=COALESCE(revoked_at, CURRENT_TIMESTAMP)
+		WHERE id=$1
+		RETURNING ` + apiKeyColumns
+
+	if err = store.Conn(ctx, r.db).GetContext(ctx, &dest, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = store.ErrorNotFound
+		}
+	}
+
+	return
+}
+
+// Touch records that the key was used. It writes at most once a minute per
+// key, a busy integration would otherwise update the row on every request.
+func (r *APIKeyRepository) Touch(ctx context.Context, id string) (err error) {
+	query := `
+		UPDATE api_keys
+		SET last_used_at=CURRENT_TIMESTAMP
+		WHERE id=$1 AND (last_used_at IS NULL OR last_used_at < CURRENT_TIMESTAMP - INTERVAL '1 minute')`
+
+	_, err = store.Conn(ctx, r.db).ExecContext(ctx, query, id)
+
+	return
+}
@@ -0,0 +1,127 @@
+package postgres
+
+import (
+	"context"
+	"github.com/jmoiron/sqlx"
+	"github.com/yrss1/my-shop/auth/internal/domain/lockout"
+	"github.com/yrss1/my-shop/auth/pkg/store"
+	"time"
+)
+
+type LockoutRepository struct {
+	db *sqlx.DB
+	tx store.UnitOfWork
+}
+
+func NewLockoutRepository(db *sqlx.DB, tx store.UnitOfWork) *LockoutRepository {
+	return &LockoutRepository{db: db, tx: tx}
+}
+
+const failureColumns = "scope, subject, failures, last_failure_at, locked_until"
+
+// Attempt checks and counts the attempt under a row lock, so that concurrent
+// attempts can't get past a lockout.
+func (r *LockoutRepository) Attempt(ctx context.Context, key lockout.Key, policy lockout.Policy, now time.Time) (dest lockout.Entity, allowed bool, err error) {
+	err = r.tx.Do(ctx, func(ctx context.Context) (err error) {
+		conn := store.Conn(ctx, r.db)
+
+		current, err := r.lock(ctx, conn, key)
+		if err != nil {
+			return
+		}
+
+		if _, locked := current.Locked(now); locked {
+			dest = current
+			return
+		}
+
+		dest, _ = policy.Fail(current, now)
+		allowed = true
+
+		return r.update(ctx, conn, dest)
+	})
+
+	return
+}
+
+func (r *LockoutRepository) Release(ctx context.Context, key lockout.Key, policy lockout.Policy) (err error) {
+	err = r.tx.Do(ctx, func(ctx context.Context) (err error) {
+		conn := store.Conn(ctx, r.db)
+
+		current, err := r.lock(ctx, conn, key)
+		if err != nil {
+			return
+		}
+
+		return r.update(ctx, conn, policy.Release(current))
+	})
+
+	return
+}
+
+// lock returns the counter of the key, creating it when there is none, and
+// locks its row until the transaction ends.
+func (r *LockoutRepository) lock(ctx context.Context, conn store.Querier, key lockout.Key) (dest lockout.Entity, err error) {
+	ensureQuery := `
+		INSERT INTO login_failures (scope, subject)
+		VALUES ($1, $2)
+		ON CONFLICT (scope, subject) DO NOTHING`
+
+	selectQuery := `
+		SELECT ` + failureColumns + `
+		FROM login_failures
+		WHERE scope=$1 AND subject=$2
+		FOR UPDATE`
+
+	if _, err = conn.ExecContext(ctx, ensureQuery, key.Scope, key.Subject); err != nil {
+		return
+	}
+
+	err = conn.GetContext(ctx, &dest, selectQuery, key.Scope, key.Subject)
+
+	return
+}
+
+func (r *LockoutRepository) update(ctx context.Context, conn store.Querier, data lockout.Entity) (err error) {
+	query := `
+		UPDATE login_failures
+		SET failures=$3, last_failure_at=$4, locked_until=$5
+		WHERE scope=$1 AND subject=$2`
+
+	args := []any{data.Scope, data.Subject, data.Failures, data.LastFailureAt, data.LockedUntil}
+	_, err = conn.ExecContext(ctx, query, args...)
+
+	return
+}
+
+func (r *LockoutRepository) Reset(ctx context.Context, key lockout.Key) (err error) {
+	query := "DELETE FROM login_failures WHERE scope=$1 AND subject=$2"
+
+	_, err = store.Conn(ctx, r.db).ExecContext(ctx, query, key.Scope, key.Subject)
+
+	return
+}
+
+func (r *LockoutRepository) AddEvent(ctx context.Context, data lockout.Event) (err error) {
+	query := `
+		INSERT INTO lockout_events (scope, subject, failures, locked_until)
+		VALUES ($1, $2, $3, $4)`
+
+	args := []any{data.Scope, data.Subject, data.Failures, data.LockedUntil}
+	_, err = store.Conn(ctx, r.db).ExecContext(ctx, query, args...)
+
+	return
+}
+
+// ListEvents returns the latest lockout events, newest first.
+func (r *LockoutRepository) ListEvents(ctx context.Context, limit int) (dest []lockout.Event, err error) {
+	query := `
+		SELECT id, scope, subject, failures, locked_until, created_at
+		FROM lockout_events
+		ORDER BY created_at DESC, id
+		LIMIT $1`
+
+	err = store.Conn(ctx, r.db).SelectContext(ctx, &dest, query, limit)
+
+	return
+}
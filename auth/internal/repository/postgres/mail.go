@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"github.com/jmoiron/sqlx"
+	"github.com/yrss1/my-shop/auth/internal/domain/mail"
+	"github.com/yrss1/my-shop/auth/pkg/store"
+	"time"
+)
+
+// maxMailAttempts is how often sending an email is tried before it is given
+// up on. It stays in the outbox with its last error.
+const maxMailAttempts = 10
+
+type MailRepository struct {
+	db *sqlx.DB
+}
+
+func NewMailRepository(db *sqlx.DB) *MailRepository {
+	return &MailRepository{db: db}
+}
+
+func (r *MailRepository) Add(ctx context.Context, data mail.Entity) (err error) {
+	query := `
+		INSERT INTO mail_outbox (recipient, subject, body)
+		VALUES ($1, $2, $3)`
+
+	_, err = store.Conn(ctx, r.db).ExecContext(ctx, query, data.Recipient, data.Subject, data.Body)
+
+	return
+}
+
+// Claim returns the oldest emails that are due to be sent and puts
+// their next attempt off by lease, so that no other run picks them up while
+// they are sent. Emails claimed by another instance are skipped. An email that
+// is neither marked sent nor failed is tried again once the lease is over.
+func (r *MailRepository) Claim(ctx context.Context, limit int, lease time.Duration) (dest []mail.Entity, err error) {
+	query := `
+		WITH due AS (
+			SELECT id
+			FROM mail_outbox
+			WHERE sent_at IS NULL AND attempts < $1 AND next_attempt_at <= CURRENT_TIMESTAMP
+			ORDER BY created_at, id
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE mail_outbox m
+		SET next_attempt_at=CURRENT_TIMESTAMP + $3 * INTERVAL '1 second'
+		FROM due
+		WHERE m.id = due.id
+		RETURNING m.id, m.recipient, m.subject, m.body, m.attempts, m.created_at`
+
+	err = store.Conn(ctx, r.db).SelectContext(ctx, &dest, query, maxMailAttempts, limit, lease.Seconds())
+
+	return
+}
+
+func (r *MailRepository) MarkSent(ctx context.Context, id string) (err error) {
+	query := `
+		UPDATE mail_outbox
+		SET sent_at=CURRENT_TIMESTAMP, last_error=NULL
+		WHERE id=$1`
+
+	_, err = store.Conn(ctx, r.db).ExecContext(ctx, query, id)
+
+	return
+}
+
+// MarkFailed records a failed attempt and backs off the next one
+// exponentially, from a minute up to an hour.
+func (r *MailRepository) MarkFailed(ctx context.Context, id string, reason string) (err error) {
+	query := `
+		UPDATE mail_outbox
+		SET attempts=attempts+1, last_error=$1,
+			next_attempt_at=CURRENT_TIMESTAMP + LEAST(POWER(2, attempts), 60) * INTERVAL '1 minute'
+		WHERE id=$2`
+
+	_, err = store.Conn(ctx, r.db).ExecContext(ctx, query, reason, id)
+
+	return
+}
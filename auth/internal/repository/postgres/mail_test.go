@@ -0,0 +1,96 @@
+package postgres
+
+import (
+	"context"
+	"github.com/jmoiron/sqlx"
+	"github.com/yrss1/my-shop/auth/internal/domain/mail"
+	"github.com/yrss1/my-shop/auth/pkg/store"
+	"testing"
+	"time"
+)
+
+const claimLimit = 10
+
+// TestMailClaim claims an email with lease, does then with it and claims the
+// outbox again. The transaction keeps CURRENT_TIMESTAMP still, so a lease
+// that is not over yet stays so.
+func TestMailClaim(t *testing.T) {
+	tests := []struct {
+		name  string
+		lease time.Duration
+		then  func(ctx context.Context, r *MailRepository, id string) error
+		want  int
+	}{
+		{
+			name:  "a leased email is skipped",
+			lease: 1500 * time.Millisecond,
+			want:  0,
+		},
+		{
+			name:  "an email is claimed again once its lease is over",
+			lease: 0,
+			want:  1,
+		},
+		{
+			name:  "a failed email waits for its retry",
+			lease: 0,
+			then: func(ctx context.Context, r *MailRepository, id string) error {
+				return r.MarkFailed(ctx, id, "refused")
+			},
+			want: 0,
+		},
+		{
+			name:  "a sent email is not claimed again",
+			lease: 0,
+			then: func(ctx context.Context, r *MailRepository, id string) error {
+				return r.MarkSent(ctx, id)
+			},
+			want: 0,
+		},
+		{
+			name:  "an email is given up on after the last attempt",
+			lease: 0,
+			then: func(ctx context.Context, r *MailRepository, id string) (err error) {
+				_, err = store.Conn(ctx, r.db).ExecContext(ctx, "UPDATE mail_outbox SET attempts=$1 WHERE id=$2", maxMailAttempts, id)
+				return
+			},
+			want: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var first, second []mail.Entity
+			inRollback(t, func(ctx context.Context, db *sqlx.DB, tx store.UnitOfWork) (err error) {
+				r := NewMailRepository(db)
+
+				// only the email of the test is due
+				if _, err = store.Conn(ctx, db).ExecContext(ctx, "UPDATE mail_outbox SET sent_at=CURRENT_TIMESTAMP WHERE sent_at IS NULL"); err != nil {
+					return
+				}
+				if err = r.Add(ctx, mail.Entity{Recipient: "a@example.com", Subject: "subject", Body: "body"}); err != nil {
+					return
+				}
+
+				if first, err = r.Claim(ctx, claimLimit, tt.lease); err != nil || len(first) != 1 {
+					return
+				}
+				if tt.then != nil {
+					if err = tt.then(ctx, r, first[0].ID); err != nil {
+						return
+					}
+				}
+
+				second, err = r.Claim(ctx, claimLimit, tt.lease)
+				return
+			})
+
+			if len(first) != 1 {
+				t.Fatalf("first Claim() = %d emails, want 1", len(first))
+			}
+			if len(second) != tt.want {
+				t.Errorf("second Claim() = %d emails, want %d", len(second), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"github.com/yrss1/my-shop/auth/pkg/store"
+	"os"
+	"testing"
+)
+
+var errRollback = errors.New("rollback")
+
+// inRollback runs fn in a transaction of the migrated database in
+// POSTGRES_DSN and rolls it back, so that the tests leave nothing behind. The
+// test is skipped without a database. fn reports through its error rather
+// than t.Fatal, which would commit the transaction.
+func inRollback(t *testing.T, fn func(ctx context.Context, db *sqlx.DB, tx store.UnitOfWork) error) {
+	t.Helper()
+
+	dsn := os.Getenv("POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_DSN is not set")
+	}
+
+	db, err := store.New(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Client.Close()
+
+	tx := store.NewTxManager(db.Client)
+	err = tx.Do(context.Background(), func(ctx context.Context) error {
+		if err := fn(ctx, db.Client, tx); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,135 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"github.com/yrss1/my-shop/auth/internal/domain/session"
+	"github.com/yrss1/my-shop/auth/pkg/store"
+)
+
+type SessionRepository struct {
+	db *sqlx.DB
+	tx store.UnitOfWork
+}
+
+func NewSessionRepository(db *sqlx.DB, tx store.UnitOfWork) *SessionRepository {
+	return &SessionRepository{db: db, tx: tx}
+}
+
+func (r *SessionRepository) Add(ctx context.Context, data session.Entity) (err error) {
+	query := `
+		INSERT INTO refresh_tokens (id, session_id, user_id, expires_at)
+		VALUES ($1, $2, $3, $4)`
+
+	args := []any{data.ID, data.SessionID, data.UserID, data.ExpiresAt}
+
+	_, err = store.Conn(ctx, r.db).ExecContext(ctx, query, args...)
+
+	return
+}
+
+// Rotate marks the refresh token id as used and adds next to its session in
+// its place. A token that was already used means it leaked: the whole session
+// is revoked and Rotate fails with session.ErrorReused.
+func (r *SessionRepository) Rotate(ctx context.Context, id string, next session.Entity) (err error) {
+	query := `
+		SELECT id, session_id, user_id, expires_at, used_at, revoked_at, created_at
+		FROM refresh_tokens
+		WHERE id=$1
+		FOR UPDATE`
+
+	var reused bool
+	err = r.tx.Do(ctx, func(ctx context.Context) (err error) {
+		conn := store.Conn(ctx, r.db)
+
+		var current session.Entity
+		if err = conn.GetContext(ctx, &current, query, id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				err = store.ErrorNotFound
+			}
+			return
+		}
+
+		switch {
+		case current.RevokedAt != nil:
+			return session.ErrorRevoked
+		case current.UsedAt != nil:
+			// the revocation has to be committed, so the error is only
+			// returned once the transaction is done
+			reused = true
+			_, err = r.revoke(ctx, conn, "session_id=$1", current.SessionID)
+			return
+		}
+
+		if _, err = conn.ExecContext(ctx, "UPDATE refresh_tokens SET used_at=CURRENT_TIMESTAMP WHERE id=$1", id); err != nil {
+			return
+		}
+
+		next.SessionID = current.SessionID
+		next.UserID = current.UserID
+
+		return r.Add(ctx, next)
+	})
+	if err == nil && reused {
+		err = session.ErrorReused
+	}
+
+	return
+}
+
+// RevokeSession revokes every token of the session of the user. Revoking a
+// revoked session changes nothing.
+func (r *SessionRepository) RevokeSession(ctx context.Context, userID, sessionID string) (err error) {
+	_, err = r.revoke(ctx, store.Conn(ctx, r.db), "user_id=$1 AND session_id=$2", userID, sessionID)
+
+	return
+}
+
+// RevokeUser revokes every session of the user and returns how many were
+// still active.
+func (r *SessionRepository) RevokeUser(ctx context.Context, userID string) (count int, err error) {
+	return r.revoke(ctx, store.Conn(ctx, r.db), "user_id=$1", userID)
+}
+
+// Active reports whether the session of the user has a refresh token that is
+// neither revoked nor expired.
+func (r *SessionRepository) Active(ctx context.Context, userID, sessionID string) (active bool, err error) {
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM refresh_tokens
+			WHERE user_id=$1 AND session_id=$2 AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP)`
+
+	err = store.Conn(ctx, r.db).GetContext(ctx, &active, query, userID, sessionID)
+
+	return
+}
+
+// Unused reports whether the refresh token id can still be traded, that is
+// neither used, revoked nor expired.
+func (r *SessionRepository) Unused(ctx context.Context, id string) (unused bool, err error) {
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM refresh_tokens
+			WHERE id=$1 AND used_at IS NULL AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP)`
+
+	err = store.Conn(ctx, r.db).GetContext(ctx, &unused, query, id)
+
+	return
+}
+
+func (r *SessionRepository) revoke(ctx context.Context, conn store.Querier, conds string, args ...any) (count int, err error) {
+	query := `
+		WITH revoked AS (
+			UPDATE refresh_tokens
+			SET revoked_at=CURRENT_TIMESTAMP
+			WHERE ` + conds + ` AND revoked_at IS NULL
+			RETURNING session_id
+		)
+		SELECT COUNT(DISTINCT session_id) FROM revoked`
+
+	err = conn.GetContext(ctx, &count, query, args...)
+
+	return
+}
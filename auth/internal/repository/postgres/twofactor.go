@@ -0,0 +1,154 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/yrss1/my-shop/auth/internal/domain/twofactor"
+	"github.com/yrss1/my-shop/auth/pkg/store"
+)
+
+type TwoFactorRepository struct {
+	db *sqlx.DB
+	tx store.UnitOfWork
+}
+
+func NewTwoFactorRepository(db *sqlx.DB, tx store.UnitOfWork) *TwoFactorRepository {
+	return &TwoFactorRepository{db: db, tx: tx}
+}
+
+func (r *TwoFactorRepository) Get(ctx context.Context, userID string) (dest twofactor.Entity, err error) {
+	query := `
+		SELECT user_id, secret, enabled_at, last_step, created_at, updated_at
+		FROM two_factor_secrets
+		WHERE user_id=$1`
+
+	if err = store.Conn(ctx, r.db).GetContext(ctx, &dest, query, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = store.ErrorNotFound
+		}
+	}
+
+	return
+}
+
+// Save stores a new secret for an enrolment that is yet to be confirmed. It
+// replaces an earlier unconfirmed secret but never an enabled one.
+func (r *TwoFactorRepository) Save(ctx context.Context, data twofactor.Entity) (err error) {
+	query := `
+		INSERT INTO two_factor_secrets (user_id, secret)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret=EXCLUDED.secret, last_step=0, updated_at=CURRENT_TIMESTAMP
+		WHERE two_factor_secrets.enabled_at IS NULL`
+
+	_, err = store.Conn(ctx, r.db).ExecContext(ctx, query, data.UserID, data.Secret)
+
+	return
+}
+
+// Enable confirms the enrolment with the step of the code it was confirmed
+// with and stores the first recovery codes.
+func (r *TwoFactorRepository) Enable(ctx context.Context, userID string, step int64, codeHashes []string) (err error) {
+	query := `
+		UPDATE two_factor_secrets
+		SET enabled_at=CURRENT_TIMESTAMP, last_step=$2, updated_at=CURRENT_TIMESTAMP
+		WHERE user_id=$1 AND enabled_at IS NULL`
+
+	err = r.tx.Do(ctx, func(ctx context.Context) (err error) {
+		result, err := store.Conn(ctx, r.db).ExecContext(ctx, query, userID, step)
+		if err != nil {
+			return
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			return store.ErrorNotFound
+		}
+
+		return r.ReplaceRecoveryCodes(ctx, userID, codeHashes)
+	})
+
+	return
+}
+
+func (r *TwoFactorRepository) Delete(ctx context.Context, userID string) (err error) {
+	err = r.tx.Do(ctx, func(ctx context.Context) (err error) {
+		conn := store.Conn(ctx, r.db)
+
+		if _, err = conn.ExecContext(ctx, "DELETE FROM recovery_codes WHERE user_id=$1", userID); err != nil {
+			return
+		}
+
+		_, err = conn.ExecContext(ctx, "DELETE FROM two_factor_secrets WHERE user_id=$1", userID)
+		return
+	})
+
+	return
+}
+
+// UseStep records step as the step of the last accepted code. It fails with
+// twofactor.ErrorStepUsed when a code of that step or a later one was already
+// accepted, also when two logins race with the same code.
+func (r *TwoFactorRepository) UseStep(ctx context.Context, userID string, step int64) (err error) {
+	query := `
+		UPDATE two_factor_secrets
+		SET last_step=$2, updated_at=CURRENT_TIMESTAMP
+		WHERE user_id=$1 AND last_step < $2`
+
+	result, err := store.Conn(ctx, r.db).ExecContext(ctx, query, userID, step)
+	if err != nil {
+		return
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		err = twofactor.ErrorStepUsed
+	}
+
+	return
+}
+
+// UseRecoveryCode marks an unused recovery code of the user as used.
+func (r *TwoFactorRepository) UseRecoveryCode(ctx context.Context, userID, codeHash string) (err error) {
+	query := `
+		UPDATE recovery_codes
+		SET used_at=CURRENT_TIMESTAMP
+		WHERE user_id=$1 AND code_hash=$2 AND used_at IS NULL`
+
+	result, err := store.Conn(ctx, r.db).ExecContext(ctx, query, userID, codeHash)
+	if err != nil {
+		return
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		err = store.ErrorNotFound
+	}
+
+	return
+}
+
+func (r *TwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string) (err error) {
+	query := `
+		INSERT INTO recovery_codes (user_id, code_hash)
+		SELECT $1, UNNEST($2::text[])`
+
+	err = r.tx.Do(ctx, func(ctx context.Context) (err error) {
+		conn := store.Conn(ctx, r.db)
+
+		if _, err = conn.ExecContext(ctx, "DELETE FROM recovery_codes WHERE user_id=$1", userID); err != nil {
+			return
+		}
+
+		_, err = conn.ExecContext(ctx, query, userID, pq.Array(codeHashes))
+		return
+	})
+
+	return
+}
+
+// CountRecoveryCodes returns how many recovery codes of the user are unused.
+func (r *TwoFactorRepository) CountRecoveryCodes(ctx context.Context, userID string) (count int, err error) {
+	query := "SELECT COUNT(*) FROM recovery_codes WHERE user_id=$1 AND used_at IS NULL"
+
+	err = store.Conn(ctx, r.db).GetContext(ctx, &count, query, userID)
+
+	return
+}
@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"github.com/yrss1/my-shop/auth/internal/domain/verification"
+	"github.com/yrss1/my-shop/auth/pkg/store"
+)
+
+type VerificationRepository struct {
+	db *sqlx.DB
+}
+
+func NewVerificationRepository(db *sqlx.DB) *VerificationRepository {
+	return &VerificationRepository{db: db}
+}
+
+func (r *VerificationRepository) Add(ctx context.Context, data verification.Entity) (err error) {
+	query := `
+		INSERT INTO verification_tokens (user_id, purpose, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)`
+
+	args := []any{data.UserID, data.Purpose, data.TokenHash, data.ExpiresAt}
+
+	_, err = store.Conn(ctx, r.db).ExecContext(ctx, query, args...)
+
+	return
+}
+
+// Consume marks the token as used and returns it. A token that is unknown,
+// used or expired is not found.
+func (r *VerificationRepository) Consume(ctx context.Context, purpose, tokenHash string) (dest verification.Entity, err error) {
+	query := `
+		UPDATE verification_tokens
+		SET used_at=CURRENT_TIMESTAMP
+		WHERE purpose=$1 AND token_hash=$2 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+		RETURNING id, user_id, purpose, token_hash, expires_at, used_at, created_at`
+
+	if err = store.Conn(ctx, r.db).GetContext(ctx, &dest, query, purpose, tokenHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = store.ErrorNotFound
+		}
+	}
+
+	return
+}
+
+// Revoke uses up the unused tokens of the user for the purpose, so that only
+// the latest token sent works.
+func (r *VerificationRepository) Revoke(ctx context.Context, userID, purpose string) (err error) {
+	query := `
+		UPDATE verification_tokens
+		SET used_at=CURRENT_TIMESTAMP
+		WHERE user_id=$1 AND purpose=$2 AND used_at IS NULL`
+
+	_, err = store.Conn(ctx, r.db).ExecContext(ctx, query, userID, purpose)
+
+	return
+}
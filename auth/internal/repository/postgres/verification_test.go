@@ -0,0 +1,141 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"github.com/yrss1/my-shop/auth/internal/domain/verification"
+	"github.com/yrss1/my-shop/auth/pkg/store"
+	"testing"
+	"time"
+)
+
+const (
+	testUserID  = "00000000-0000-4000-8000-000000000001"
+	otherUserID = "00000000-0000-4000-8000-000000000002"
+)
+
+func TestVerificationConsume(t *testing.T) {
+	tests := []struct {
+		name      string
+		expiresIn time.Duration
+		used      bool
+		revoked   bool
+		purpose   string
+		wantErr   error
+	}{
+		{"unused token", time.Hour, false, false, verification.PurposeResetPassword, nil},
+		{"used token", time.Hour, true, false, verification.PurposeResetPassword, store.ErrorNotFound},
+		{"expired token", -time.Minute, false, false, verification.PurposeResetPassword, store.ErrorNotFound},
+		{"revoked token", time.Hour, false, true, verification.PurposeResetPassword, store.ErrorNotFound},
+		{"token of another purpose", time.Hour, false, false, verification.PurposeVerifyEmail, store.ErrorNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := verification.Entity{
+				UserID:    testUserID,
+				Purpose:   verification.PurposeResetPassword,
+				TokenHash: verification.HashToken(tt.name),
+				ExpiresAt: time.Now().Add(tt.expiresIn),
+			}
+
+			var got verification.Entity
+			var gotErr error
+			inRollback(t, func(ctx context.Context, db *sqlx.DB, tx store.UnitOfWork) (err error) {
+				r := NewVerificationRepository(db)
+
+				if err = r.Add(ctx, token); err != nil {
+					return
+				}
+				if tt.used {
+					if _, err = r.Consume(ctx, token.Purpose, token.TokenHash); err != nil {
+						return
+					}
+				}
+				if tt.revoked {
+					if err = r.Revoke(ctx, token.UserID, token.Purpose); err != nil {
+						return
+					}
+				}
+
+				got, gotErr = r.Consume(ctx, tt.purpose, token.TokenHash)
+				return
+			})
+
+			if !errors.Is(gotErr, tt.wantErr) {
+				t.Fatalf("Consume() error = %v, want %v", gotErr, tt.wantErr)
+			}
+			if tt.wantErr == nil && (got.UserID != testUserID || got.UsedAt == nil) {
+				t.Errorf("Consume() = %+v, want the token of %s marked used", got, testUserID)
+			}
+		})
+	}
+}
+
+// TestVerificationRevoke revokes the tokens sent before the latest one, like
+// sendToken does, and checks which of them still work.
+func TestVerificationRevoke(t *testing.T) {
+	tokens := []struct {
+		name    string
+		userID  string
+		purpose string
+		latest  bool
+		want    bool
+	}{
+		{"older token", testUserID, verification.PurposeResetPassword, false, false},
+		{"latest token", testUserID, verification.PurposeResetPassword, true, true},
+		{"token of another purpose", testUserID, verification.PurposeVerifyEmail, false, true},
+		{"token of another user", otherUserID, verification.PurposeResetPassword, false, true},
+	}
+
+	got := make(map[string]bool)
+	inRollback(t, func(ctx context.Context, db *sqlx.DB, tx store.UnitOfWork) (err error) {
+		r := NewVerificationRepository(db)
+
+		add := func(latest bool) (err error) {
+			for _, token := range tokens {
+				if token.latest != latest {
+					continue
+				}
+				data := verification.Entity{
+					UserID:    token.userID,
+					Purpose:   token.purpose,
+					TokenHash: verification.HashToken(token.name),
+					ExpiresAt: time.Now().Add(time.Hour),
+				}
+				if err = r.Add(ctx, data); err != nil {
+					return
+				}
+			}
+			return
+		}
+
+		if err = add(false); err != nil {
+			return
+		}
+		if err = r.Revoke(ctx, testUserID, verification.PurposeResetPassword); err != nil {
+			return
+		}
+		if err = add(true); err != nil {
+			return
+		}
+
+		for _, token := range tokens {
+			_, err = r.Consume(ctx, token.purpose, verification.HashToken(token.name))
+			switch {
+			case err == nil:
+				got[token.name] = true
+			case !errors.Is(err, store.ErrorNotFound):
+				return
+			}
+		}
+		return nil
+	})
+
+	for _, token := range tokens {
+		if got[token.name] != token.want {
+			t.Errorf("%s: consumed = %v, want %v", token.name, got[token.name], token.want)
+		}
+	}
+}
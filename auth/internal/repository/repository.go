@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"github.com/yrss1/my-shop/auth/internal/domain/apikey"
+	"github.com/yrss1/my-shop/auth/internal/domain/lockout"
+	"github.com/yrss1/my-shop/auth/internal/domain/mail"
+	"github.com/yrss1/my-shop/auth/internal/domain/session"
+	"github.com/yrss1/my-shop/auth/internal/domain/twofactor"
+	"github.com/yrss1/my-shop/auth/internal/domain/verification"
+	"github.com/yrss1/my-shop/auth/internal/repository/memory"
+	"github.com/yrss1/my-shop/auth/internal/repository/postgres"
+	"github.com/yrss1/my-shop/auth/pkg/store"
+)
+
+type Configuration func(r *Repository) error
+
+type Repository struct {
+	postgres store.SQLX
+
+	UnitOfWork   store.UnitOfWork
+	Session      session.Repository
+	Verification verification.Repository
+	Mail         mail.Repository
+	TwoFactor    twofactor.Repository
+	Lockout      lockout.Repository
+	APIKey       apikey.Repository
+}
+
+func New(configs ...Configuration) (s *Repository, err error) {
+	s = &Repository{}
+
+	for _, cfg := range configs {
+		if err = cfg(s); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func WithPostgresStore(dbName string) Configuration {
+	return func(r *Repository) (err error) {
+		r.postgres, err = store.New(dbName)
+		if err != nil {
+			return
+		}
+		if err = store.Migrate(dbName); err != nil {
+			return
+		}
+
+		r.UnitOfWork = store.NewTxManager(r.postgres.Client)
+		r.Session = postgres.NewSessionRepository(r.postgres.Client, r.UnitOfWork)
+		r.Verification = postgres.NewVerificationRepository(r.postgres.Client)
+		r.Mail = postgres.NewMailRepository(r.postgres.Client)
+		r.TwoFactor = postgres.NewTwoFactorRepository(r.postgres.Client, r.UnitOfWork)
+		r.Lockout = postgres.NewLockoutRepository(r.postgres.Client, r.UnitOfWork)
+		r.APIKey = postgres.NewAPIKeyRepository(r.postgres.Client)
+
+		return
+	}
+}
+
+// WithMemoryLockout keeps the failed login counters in memory instead of
+// Postgres. It has to come after WithPostgresStore.
+func WithMemoryLockout() Configuration {
+	return func(r *Repository) (err error) {
+		r.Lockout = memory.NewLockoutRepository()
+		return
+	}
+}
@@ -0,0 +1,108 @@
+package authService
+
+import (
+	"context"
+	"errors"
+	"github.com/yrss1/my-shop/auth/internal/domain/apikey"
+	"github.com/yrss1/my-shop/auth/pkg/log"
+	"github.com/yrss1/my-shop/auth/pkg/store"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+func (s *Service) ListAPIKeys(ctx context.Context) (res []apikey.Response, err error) {
+	logger := log.LoggerFromContext(ctx).Named("ListAPIKeys")
+
+	data, err := s.apiKeyRepository.List(ctx)
+	if err != nil {
+		logger.Error("failed to select", zap.Error(err))
+		return
+	}
+
+	res = apikey.ParseFromEntities(data)
+
+	return
+}
+
+// CreateAPIKey creates a key for the admin createdBy. The key is returned
+// only here, just its hash is stored.
+func (s *Service) CreateAPIKey(ctx context.Context, createdBy string, req apikey.Request) (res apikey.CreateResponse, err error) {
+	logger := log.LoggerFromContext(ctx).Named("CreateAPIKey")
+
+	value, prefix, err := apikey.NewKey()
+	if err != nil {
+		logger.Error("failed to create key", zap.Error(err))
+		return
+	}
+
+	data := apikey.Entity{
+		Name:      strings.TrimSpace(*req.Name),
+		Prefix:    prefix,
+		KeyHash:   apikey.HashKey(value),
+		Scopes:    req.Scopes,
+		CreatedBy: createdBy,
+		ExpiresAt: req.ExpiresAt,
+	}
+
+	data, err = s.apiKeyRepository.Add(ctx, data)
+	if err != nil {
+		logger.Error("failed to create", zap.Error(err))
+		return
+	}
+
+	res = apikey.CreateResponse{
+		Response: apikey.ParseFromEntity(data),
+		Key:      value,
+	}
+
+	return
+}
+
+func (s *Service) RevokeAPIKey(ctx context.Context, id string) (res apikey.Response, err error) {
+	logger := log.LoggerFromContext(ctx).Named("RevokeAPIKey").With(zap.String("id", id))
+
+	data, err := s.apiKeyRepository.Revoke(ctx, id)
+	if err != nil {
+		if !errors.Is(err, store.ErrorNotFound) {
+			logger.Error("failed to revoke", zap.Error(err))
+		}
+		return
+	}
+
+	res = apikey.ParseFromEntity(data)
+
+	return
+}
+
+// ValidateAPIKey returns the key if it is active and scoped to the action on
+// the service, and records that it was used.
+func (s *Service) ValidateAPIKey(ctx context.Context, value, service, action string) (res apikey.Response, err error) {
+	logger := log.LoggerFromContext(ctx).Named("ValidateAPIKey")
+
+	data, err := s.apiKeyRepository.GetByHash(ctx, apikey.HashKey(value))
+	switch {
+	case errors.Is(err, store.ErrorNotFound):
+		err = apikey.ErrorInvalid
+		return
+	case err != nil:
+		logger.Error("failed to get key", zap.Error(err))
+		return
+	case !data.Active(time.Now()):
+		err = apikey.ErrorInvalid
+		return
+	case !data.Allows(service, action):
+		err = apikey.ErrorScope
+		return
+	}
+
+	if err = s.apiKeyRepository.Touch(ctx, data.ID); err != nil {
+		// the key is valid either way
+		logger.Warn("failed to record key use", zap.Error(err), zap.String("id", data.ID))
+		err = nil
+	}
+
+	res = apikey.ParseFromEntity(data)
+
+	return
+}
@@ -2,17 +2,170 @@ package authService
 
 import (
 	"context"
+	"errors"
+	"github.com/yrss1/my-shop/auth/internal/domain/auth"
+	"github.com/yrss1/my-shop/auth/internal/domain/lockout"
+	"github.com/yrss1/my-shop/auth/internal/domain/user"
 	"github.com/yrss1/my-shop/auth/pkg/log"
-	pb "github.com/yrss1/proto-definitions/user"
+	"github.com/yrss1/my-shop/auth/pkg/token"
+	pb "github.com/yrss1/my-shop/proto/user"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"time"
 )
 
-func (s *Service) GetUserByEmail(ctx context.Context, email string) (res *pb.UserResponse, err error) {
-	logger := log.LoggerFromContext(ctx).Named("GetUserByEmail").With(zap.String("email", email))
+func (s *Service) Register(ctx context.Context, req auth.RegisterRequest) (res user.Response, err error) {
+	logger := log.LoggerFromContext(ctx).Named("Register").With(zap.String("email", *req.Email))
 
-	res, err = s.userClient.GetUserByEmail(ctx, email)
+	data := &pb.UserRequest{User: &pb.Request{
+		Name:     *req.Name,
+		Email:    *req.Email,
+		Password: *req.Password,
+		Role:     auth.RoleCustomer,
+	}}
+	if req.Address != nil {
+		data.User.Address = *req.Address
+	}
+
+	created, err := s.userClient.RegisterUser(ctx, data)
+	if err != nil {
+		if status.Code(err) == codes.AlreadyExists {
+			err = auth.ErrorEmailTaken
+			return
+		}
+		logger.Error("failed to register user", zap.Error(err))
+		return
+	}
+
+	res = user.Response{
+		ID:      created.User.Id,
+		Name:    created.User.Name,
+		Email:   created.User.Email,
+		Address: created.User.Address,
+		Role:    created.User.Role,
+	}
+
+	// the user is registered either way, a lost email can be sent again
+	if err = s.sendVerification(ctx, created.User); err != nil {
+		logger.Error("failed to send verification email", zap.Error(err))
+		err = nil
+	}
+
+	return
+}
+
+// Login checks the credentials with the user service and issues a new token
+// pair for the user. An unknown email and a wrong password give the same error.
+// Users with two-factor authentication, or whose role requires it, get a
+// challenge to pass with LoginTwoFactor instead of the tokens.
+func (s *Service) Login(ctx context.Context, req auth.LoginRequest) (res auth.LoginResponse, err error) {
+	logger := log.LoggerFromContext(ctx).Named("Login").With(zap.String("email", *req.Email))
+
+	account := accountKey(*req.Email)
+	keys := s.lockoutKeys(account, lockout.Key{Scope: lockout.ScopeIP, Subject: req.IP})
+	now := time.Now()
+	attempts, err := s.attemptLockout(ctx, keys, now)
+	if err != nil {
+		if !errors.Is(err, auth.ErrorLocked) {
+			logger.Error("failed to check lockout", zap.Error(err))
+		}
+		return
+	}
+
+	found, err := s.userClient.VerifyPassword(ctx, *req.Email, *req.Password)
+	if err != nil {
+		switch status.Code(err) {
+		case codes.NotFound, codes.Unauthenticated:
+			err = auth.ErrorInvalidCredentials
+			s.failLockout(ctx, attempts, now)
+		default:
+			s.releaseLockout(ctx, keys)
+			logger.Error("failed to verify password", zap.Error(err))
+		}
+		return
+	}
+	s.resetLockout(ctx, account, keys)
+
+	enabled, err := s.twoFactorEnabled(ctx, found.User.Id)
+	if err != nil {
+		logger.Error("failed to get two-factor secret", zap.Error(err))
+		return
+	}
+
+	if enabled || s.requiredRoles[found.User.Role] {
+		value, expiresAt, err := s.tokens.IssueChallenge(found.User.Id, found.User.Role)
+		if err != nil {
+			logger.Error("failed to issue challenge", zap.Error(err))
+			return res, err
+		}
+
+		res.Challenge = &auth.ChallengeResponse{
+			ChallengeToken:     value,
+			ExpiresIn:          int(time.Until(expiresAt).Round(time.Second).Seconds()),
+			EnrollmentRequired: !enabled,
+		}
+		return res, nil
+	}
+
+	tokens, err := s.startSession(ctx, found.User.Id, found.User.Role)
 	if err != nil {
-		logger.Error("failed to get by email", zap.Error(err))
+		logger.Error("failed to start session", zap.Error(err))
+		return
+	}
+	res.TokenResponse = &tokens
+
+	return
+}
+
+// ChangePassword sets a new password for the user of claims once the current
+// one checks out, and revokes every session of the user like ResetPassword.
+// Wrong current passwords count towards the lockout of the account like failed
+// logins.
+func (s *Service) ChangePassword(ctx context.Context, claims token.Claims, req auth.ChangePasswordRequest) (err error) {
+	logger := log.LoggerFromContext(ctx).Named("ChangePassword").With(zap.String("user_id", claims.Subject))
+
+	found, err := s.userClient.GetUser(ctx, claims.Subject)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			err = auth.ErrorInvalidToken
+			return
+		}
+		logger.Error("failed to get user", zap.Error(err))
+		return
+	}
+
+	account := accountKey(found.User.Email)
+	keys := s.lockoutKeys(account, lockout.Key{Scope: lockout.ScopeIP, Subject: req.IP})
+	now := time.Now()
+	attempts, err := s.attemptLockout(ctx, keys, now)
+	if err != nil {
+		if !errors.Is(err, auth.ErrorLocked) {
+			logger.Error("failed to check lockout", zap.Error(err))
+		}
+		return
+	}
+
+	if _, err = s.userClient.VerifyPassword(ctx, found.User.Email, *req.CurrentPassword); err != nil {
+		switch status.Code(err) {
+		case codes.NotFound, codes.Unauthenticated:
+			err = auth.ErrorInvalidCredentials
+			s.failLockout(ctx, attempts, now)
+		default:
+			s.releaseLockout(ctx, keys)
+			logger.Error("failed to verify password", zap.Error(err))
+		}
+		return
+	}
+	s.resetLockout(ctx, account, keys)
+
+	if _, err = s.userClient.SetPassword(ctx, claims.Subject, *req.Password); err != nil {
+		logger.Error("failed to set password", zap.Error(err))
+		return
+	}
+
+	if _, err = s.sessionRepository.RevokeUser(ctx, claims.Subject); err != nil {
+		logger.Error("failed to revoke sessions", zap.Error(err))
 		return
 	}
 
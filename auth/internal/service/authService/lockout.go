@@ -0,0 +1,137 @@
+package authService
+
+import (
+	"context"
+	"github.com/yrss1/my-shop/auth/internal/domain/auth"
+	"github.com/yrss1/my-shop/auth/internal/domain/lockout"
+	"github.com/yrss1/my-shop/auth/pkg/log"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+func (s *Service) ListLockoutEvents(ctx context.Context, limit int) (res []lockout.EventResponse, err error) {
+	logger := log.LoggerFromContext(ctx).Named("ListLockoutEvents")
+
+	data, err := s.lockoutRepository.ListEvents(ctx, limit)
+	if err != nil {
+		logger.Error("failed to select", zap.Error(err))
+		return
+	}
+
+	res = lockout.ParseFromEvents(data)
+
+	return
+}
+
+// lockoutKeys returns the keys failures are counted for, leaving out scopes
+// without a policy and unknown subjects.
+func (s *Service) lockoutKeys(keys ...lockout.Key) (dest []lockout.Key) {
+	for _, key := range keys {
+		if key.Subject != "" && s.lockoutPolicies[key.Scope].Enabled() {
+			dest = append(dest, key)
+		}
+	}
+
+	return
+}
+
+// accountKey returns the lockout key of the account with email, the same
+// however the email is typed.
+func accountKey(email string) lockout.Key {
+	return lockout.Key{Scope: lockout.ScopeAccount, Subject: strings.ToLower(strings.TrimSpace(email))}
+}
+
+// attemptLockout counts an attempt at now for each of the keys, failing with
+// an auth.LockedError, with the longest time left, while any of them is
+// locked out. Checking and counting is one repository call, so concurrent
+// attempts can't get past a lockout. The attempt counts as a failure until
+// releaseLockout or resetLockout takes it back; failLockout confirms it with
+// the counters it returns.
+func (s *Service) attemptLockout(ctx context.Context, keys []lockout.Key, now time.Time) (attempts []lockout.Entity, err error) {
+	var counted []lockout.Key
+	var retryAfter time.Duration
+	for _, key := range keys {
+		data, allowed, err := s.lockoutRepository.Attempt(ctx, key, s.lockoutPolicies[key.Scope], now)
+		if err != nil {
+			s.releaseLockout(ctx, counted)
+			return nil, err
+		}
+
+		if !allowed {
+			remaining, _ := data.Locked(now)
+			retryAfter = max(retryAfter, remaining)
+			continue
+		}
+		counted = append(counted, key)
+		attempts = append(attempts, data)
+	}
+
+	if retryAfter > 0 {
+		// a refused attempt is no failure of the keys that let it through
+		s.releaseLockout(ctx, counted)
+		return nil, &auth.LockedError{RetryAfter: retryAfter}
+	}
+
+	return
+}
+
+// failLockout confirms that the attempts counted by attemptLockout at now
+// failed, recording an event for every key the failure locked out. Attempts
+// that succeed lift the lockout they counted towards and leave no event.
+// Recording is best effort.
+func (s *Service) failLockout(ctx context.Context, attempts []lockout.Entity, now time.Time) {
+	logger := log.LoggerFromContext(ctx).Named("Lockout")
+
+	for _, data := range attempts {
+		if _, locked := data.Locked(now); !locked {
+			continue
+		}
+
+		logger.Warn("locked out",
+			zap.String("scope", data.Scope),
+			zap.String("subject", data.Subject),
+			zap.Int("failures", data.Failures),
+			zap.Timep("locked_until", data.LockedUntil))
+
+		event := lockout.Event{
+			Scope:       data.Scope,
+			Subject:     data.Subject,
+			Failures:    data.Failures,
+			LockedUntil: *data.LockedUntil,
+			CreatedAt:   now,
+		}
+		if err := s.lockoutRepository.AddEvent(ctx, event); err != nil {
+			logger.Error("failed to add lockout event", zap.Error(err), zap.String("scope", data.Scope))
+		}
+	}
+}
+
+// releaseLockout takes back the attempt counted for each of the keys when it
+// did not fail. Storing it is best effort.
+func (s *Service) releaseLockout(ctx context.Context, keys []lockout.Key) {
+	for _, key := range keys {
+		if err := s.lockoutRepository.Release(ctx, key, s.lockoutPolicies[key.Scope]); err != nil {
+			log.LoggerFromContext(ctx).Named("Lockout").Error("failed to release attempt", zap.Error(err), zap.String("scope", key.Scope))
+		}
+	}
+}
+
+// resetLockout forgets the failures of account after a success and takes back
+// the attempt counted for the other keys. Only the account is reset, a success
+// from an address says nothing about the other logins tried from it.
+func (s *Service) resetLockout(ctx context.Context, account lockout.Key, keys []lockout.Key) {
+	var others []lockout.Key
+	for _, key := range keys {
+		if key != account {
+			others = append(others, key)
+			continue
+		}
+
+		if err := s.lockoutRepository.Reset(ctx, key); err != nil {
+			log.LoggerFromContext(ctx).Named("Lockout").Error("failed to reset failures", zap.Error(err), zap.String("scope", key.Scope))
+		}
+	}
+
+	s.releaseLockout(ctx, others)
+}
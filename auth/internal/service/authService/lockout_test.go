@@ -0,0 +1,200 @@
+package authService
+
+import (
+	"context"
+	"errors"
+	"github.com/yrss1/my-shop/auth/internal/domain/auth"
+	"github.com/yrss1/my-shop/auth/internal/domain/lockout"
+	"github.com/yrss1/my-shop/auth/internal/repository/memory"
+	"github.com/yrss1/my-shop/auth/pkg/log"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+// attempt is one login of a lockout scenario: from ip for email, at after
+// from the start, failing unless success is set. retryAfter is the lockout it
+// should be refused with, zero when it should be let through.
+type attempt struct {
+	email      string
+	ip         string
+	after      time.Duration
+	success    bool
+	retryAfter time.Duration
+}
+
+func TestLockout(t *testing.T) {
+	policy := lockout.Policy{Threshold: 3, BaseDelay: time.Minute, MaxDelay: 4 * time.Minute, Window: 15 * time.Minute}
+	relaxed := lockout.Policy{Threshold: 100, BaseDelay: time.Minute, MaxDelay: time.Hour, Window: 15 * time.Minute}
+
+	tests := []struct {
+		name     string
+		account  lockout.Policy
+		ip       lockout.Policy
+		attempts []attempt
+		events   int
+	}{
+		{
+			name:    "backoff grows up to the max delay",
+			account: policy,
+			ip:      relaxed,
+			attempts: []attempt{
+				{email: "a", ip: "1"},
+				{email: "a", ip: "1"},
+				{email: "a", ip: "1"},
+				{email: "a", ip: "1", after: 30 * time.Second, retryAfter: 30 * time.Second},
+				{email: "a", ip: "1", after: time.Minute},
+				{email: "a", ip: "1", after: 2 * time.Minute, retryAfter: time.Minute},
+				{email: "a", ip: "1", after: 3 * time.Minute},
+				{email: "a", ip: "1", after: 3 * time.Minute, retryAfter: 4 * time.Minute},
+				{email: "a", ip: "1", after: 7 * time.Minute},
+				{email: "a", ip: "1", after: 7 * time.Minute, retryAfter: 4 * time.Minute},
+			},
+			events: 4,
+		},
+		{
+			name:    "failures are forgotten after the window",
+			account: policy,
+			ip:      relaxed,
+			attempts: []attempt{
+				{email: "a", ip: "1"},
+				{email: "a", ip: "1"},
+				{email: "a", ip: "1", after: 16 * time.Minute},
+				{email: "a", ip: "1", after: 16 * time.Minute},
+				{email: "a", ip: "1", after: 16 * time.Minute},
+				{email: "a", ip: "1", after: 16 * time.Minute, retryAfter: time.Minute},
+			},
+			events: 1,
+		},
+		{
+			name:    "a successful login resets the account",
+			account: policy,
+			ip:      relaxed,
+			attempts: []attempt{
+				{email: "a", ip: "1"},
+				{email: "a", ip: "1"},
+				{email: "a", ip: "1", success: true},
+				{email: "a", ip: "1"},
+				{email: "a", ip: "1"},
+				{email: "a", ip: "1"},
+				{email: "a", ip: "1", retryAfter: time.Minute},
+			},
+			events: 1,
+		},
+		{
+			name:    "a successful login at the threshold lifts its own lockout",
+			account: policy,
+			ip:      relaxed,
+			attempts: []attempt{
+				{email: "a", ip: "1"},
+				{email: "a", ip: "1"},
+				{email: "a", ip: "1", success: true},
+				{email: "a", ip: "1", success: true},
+			},
+		},
+		{
+			name:    "an address is limited across accounts",
+			account: relaxed,
+			ip:      policy,
+			attempts: []attempt{
+				{email: "a", ip: "1"},
+				{email: "b", ip: "1"},
+				{email: "c", ip: "1"},
+				{email: "d", ip: "1", retryAfter: time.Minute},
+				{email: "d", ip: "2"},
+			},
+			events: 1,
+		},
+		{
+			name:    "a successful login does not reset its address",
+			account: relaxed,
+			ip:      policy,
+			attempts: []attempt{
+				{email: "a", ip: "1"},
+				{email: "b", ip: "1"},
+				{email: "c", ip: "1", success: true},
+				{email: "d", ip: "1"},
+				{email: "e", ip: "1", retryAfter: time.Minute},
+			},
+			events: 1,
+		},
+		{
+			name:    "successful logins do not count for their address",
+			account: relaxed,
+			ip:      policy,
+			attempts: []attempt{
+				{email: "a", ip: "1", success: true},
+				{email: "b", ip: "1", success: true},
+				{email: "c", ip: "1", success: true},
+				{email: "d", ip: "1", success: true},
+				{email: "e", ip: "1", success: true},
+			},
+		},
+		{
+			name:    "a refused attempt does not count for the other keys",
+			account: policy,
+			ip:      lockout.Policy{Threshold: 5, BaseDelay: time.Minute, MaxDelay: time.Hour, Window: 15 * time.Minute},
+			attempts: []attempt{
+				{email: "a", ip: "1"},
+				{email: "a", ip: "1"},
+				{email: "a", ip: "1"},
+				{email: "a", ip: "1", retryAfter: time.Minute},
+				{email: "a", ip: "1", retryAfter: time.Minute},
+				{email: "b", ip: "1"},
+				{email: "c", ip: "1"},
+				{email: "d", ip: "1", retryAfter: time.Minute},
+			},
+			events: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repository := memory.NewLockoutRepository()
+			s, err := New(
+				WithLockoutRepository(repository),
+				WithLockoutPolicy(lockout.ScopeAccount, tt.account),
+				WithLockoutPolicy(lockout.ScopeIP, tt.ip))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := log.ContextWithLogger(context.Background(), zap.NewNop())
+			start := time.Date(2024, 8, 17, 12, 0, 0, 0, time.UTC)
+
+			for i, a := range tt.attempts {
+				account := lockout.Key{Scope: lockout.ScopeAccount, Subject: a.email}
+				keys := s.lockoutKeys(account, lockout.Key{Scope: lockout.ScopeIP, Subject: a.ip})
+
+				now := start.Add(a.after)
+				attempts, err := s.attemptLockout(ctx, keys, now)
+
+				var locked *auth.LockedError
+				switch {
+				case a.retryAfter == 0 && err != nil:
+					t.Fatalf("attempt %d: error = %v, want it let through", i, err)
+				case a.retryAfter > 0 && !errors.As(err, &locked):
+					t.Fatalf("attempt %d: error = %v, want a lockout", i, err)
+				case a.retryAfter > 0 && locked.RetryAfter != a.retryAfter:
+					t.Fatalf("attempt %d: retry after %v, want %v", i, locked.RetryAfter, a.retryAfter)
+				case a.retryAfter > 0:
+					continue
+				}
+
+				if a.success {
+					s.resetLockout(ctx, account, keys)
+				} else {
+					s.failLockout(ctx, attempts, now)
+				}
+			}
+
+			events, err := repository.ListEvents(ctx, 100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(events) != tt.events {
+				t.Errorf("events = %d, want %d", len(events), tt.events)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package authService
+
+import (
+	"context"
+	"github.com/yrss1/my-shop/auth/pkg/log"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	// outboxBatch is how many emails one run of the outbox sends at most.
+	outboxBatch = 50
+	// outboxLease is how long claimed emails are kept from other runs. It
+	// outlasts sending a batch, so an email is only sent twice when a run
+	// dies before marking it.
+	outboxLease = 5 * time.Minute
+)
+
+// RunOutbox sends the emails of the outbox every interval until ctx is done.
+// Failed emails are retried later with a growing delay.
+func (s *Service) RunOutbox(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.deliverOutbox(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverOutbox claims a batch of emails and sends them. Claiming commits on
+// its own, so no transaction is held open while the mail server is talked to.
+func (s *Service) deliverOutbox(ctx context.Context) {
+	logger := log.LoggerFromContext(ctx).Named("RunOutbox")
+
+	due, err := s.mailRepository.Claim(ctx, outboxBatch, outboxLease)
+	if err != nil {
+		if ctx.Err() == nil {
+			logger.Error("failed to claim outbox", zap.Error(err))
+		}
+		return
+	}
+
+	for _, data := range due {
+		if err = s.mailer.Send(ctx, data); err != nil {
+			logger.Warn("failed to send email",
+				zap.String("id", data.ID),
+				zap.Int("attempts", data.Attempts+1),
+				zap.Error(err))
+
+			err = s.mailRepository.MarkFailed(ctx, data.ID, err.Error())
+		} else {
+			err = s.mailRepository.MarkSent(ctx, data.ID)
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Error("failed to mark email", zap.String("id", data.ID), zap.Error(err))
+			}
+			return
+		}
+	}
+}
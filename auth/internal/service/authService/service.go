@@ -1,13 +1,38 @@
 package authService
 
 import (
+	"github.com/yrss1/my-shop/auth/internal/domain/apikey"
+	"github.com/yrss1/my-shop/auth/internal/domain/lockout"
+	"github.com/yrss1/my-shop/auth/internal/domain/mail"
+	"github.com/yrss1/my-shop/auth/internal/domain/session"
+	"github.com/yrss1/my-shop/auth/internal/domain/twofactor"
+	"github.com/yrss1/my-shop/auth/internal/domain/verification"
 	"github.com/yrss1/my-shop/auth/internal/provider/user"
+	"github.com/yrss1/my-shop/auth/pkg/store"
+	"github.com/yrss1/my-shop/auth/pkg/token"
+	"strings"
 )
 
 type Configuration func(s *Service) error
 
 type Service struct {
-	userClient *user.Client
+	unitOfWork             store.UnitOfWork
+	userClient             *user.Client
+	tokens                 *token.Manager
+	sessionRepository      session.Repository
+	verificationRepository verification.Repository
+	mailRepository         mail.Repository
+	twoFactorRepository    twofactor.Repository
+	lockoutRepository      lockout.Repository
+	apiKeyRepository       apikey.Repository
+
+	mailer  mail.Mailer
+	linkURL string
+
+	totpIssuer    string
+	requiredRoles map[string]bool
+
+	lockoutPolicies map[string]lockout.Policy
 }
 
 func New(configs ...Configuration) (s *Service, err error) {
@@ -22,9 +47,100 @@ func New(configs ...Configuration) (s *Service, err error) {
 	return
 }
 
+func WithUnitOfWork(unitOfWork store.UnitOfWork) Configuration {
+	return func(s *Service) error {
+		s.unitOfWork = unitOfWork
+		return nil
+	}
+}
+
 func WithUserClient(userClient *user.Client) Configuration {
 	return func(s *Service) error {
 		s.userClient = userClient
 		return nil
 	}
 }
+
+func WithTokenManager(tokens *token.Manager) Configuration {
+	return func(s *Service) error {
+		s.tokens = tokens
+		return nil
+	}
+}
+
+func WithSessionRepository(sessionRepository session.Repository) Configuration {
+	return func(s *Service) error {
+		s.sessionRepository = sessionRepository
+		return nil
+	}
+}
+
+func WithVerificationRepository(verificationRepository verification.Repository) Configuration {
+	return func(s *Service) error {
+		s.verificationRepository = verificationRepository
+		return nil
+	}
+}
+
+func WithMailRepository(mailRepository mail.Repository) Configuration {
+	return func(s *Service) error {
+		s.mailRepository = mailRepository
+		return nil
+	}
+}
+
+// WithMailer sets how the outbox is delivered. linkURL is the base URL of the
+// pages the links in the emails open.
+func WithMailer(mailer mail.Mailer, linkURL string) Configuration {
+	return func(s *Service) error {
+		s.mailer = mailer
+		s.linkURL = strings.TrimRight(linkURL, "/")
+		return nil
+	}
+}
+
+func WithTwoFactorRepository(twoFactorRepository twofactor.Repository) Configuration {
+	return func(s *Service) error {
+		s.twoFactorRepository = twoFactorRepository
+		return nil
+	}
+}
+
+// WithTwoFactor sets the issuer shown in authenticator apps and the roles
+// that have to use two-factor authentication.
+func WithTwoFactor(issuer string, requiredRoles []string) Configuration {
+	return func(s *Service) error {
+		s.totpIssuer = issuer
+		s.requiredRoles = make(map[string]bool, len(requiredRoles))
+		for _, role := range requiredRoles {
+			s.requiredRoles[strings.TrimSpace(role)] = true
+		}
+		return nil
+	}
+}
+
+func WithLockoutRepository(lockoutRepository lockout.Repository) Configuration {
+	return func(s *Service) error {
+		s.lockoutRepository = lockoutRepository
+		return nil
+	}
+}
+
+// WithLockoutPolicy sets when failures of the scope lock it out. Scopes
+// without a policy are not counted.
+func WithLockoutPolicy(scope string, policy lockout.Policy) Configuration {
+	return func(s *Service) error {
+		if s.lockoutPolicies == nil {
+			s.lockoutPolicies = make(map[string]lockout.Policy)
+		}
+		s.lockoutPolicies[scope] = policy
+		return nil
+	}
+}
+
+func WithAPIKeyRepository(apiKeyRepository apikey.Repository) Configuration {
+	return func(s *Service) error {
+		s.apiKeyRepository = apiKeyRepository
+		return nil
+	}
+}
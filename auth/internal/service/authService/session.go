@@ -0,0 +1,166 @@
+package authService
+
+import (
+	"context"
+	"errors"
+	"github.com/yrss1/my-shop/auth/internal/domain/auth"
+	"github.com/yrss1/my-shop/auth/internal/domain/session"
+	"github.com/yrss1/my-shop/auth/pkg/log"
+	"github.com/yrss1/my-shop/auth/pkg/store"
+	"github.com/yrss1/my-shop/auth/pkg/token"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// startSession issues a token pair for a new session of the user.
+func (s *Service) startSession(ctx context.Context, userID, role string) (res auth.TokenResponse, err error) {
+	sessionID, err := token.NewID()
+	if err != nil {
+		return
+	}
+
+	pair, err := s.tokens.Issue(userID, role, sessionID)
+	if err != nil {
+		return
+	}
+
+	data := session.Entity{
+		ID:        pair.RefreshID,
+		SessionID: sessionID,
+		UserID:    userID,
+		ExpiresAt: pair.RefreshExpiresAt,
+	}
+
+	if err = s.sessionRepository.Add(ctx, data); err != nil {
+		return
+	}
+
+	res = auth.ParseFromPair(pair)
+
+	return
+}
+
+// Refresh trades a refresh token for a new token pair of the same session. The
+// refresh token can only be used once; using it again revokes the session. The
+// user is loaded again so that the new tokens carry their current role, and
+// the new refresh token expires with the one it replaces. A session of a user
+// whose role now requires two-factor authentication they have not enabled is
+// revoked, so that they have to log in and enrol.
+func (s *Service) Refresh(ctx context.Context, req auth.RefreshRequest) (res auth.TokenResponse, err error) {
+	logger := log.LoggerFromContext(ctx).Named("Refresh")
+
+	claims, err := s.tokens.Parse(*req.RefreshToken, token.TypeRefresh)
+	if err != nil {
+		err = auth.ErrorInvalidToken
+		return
+	}
+	logger = logger.With(zap.String("user_id", claims.Subject), zap.String("session_id", claims.SessionID))
+
+	found, err := s.userClient.GetUser(ctx, claims.Subject)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			err = auth.ErrorInvalidToken
+			return
+		}
+		logger.Error("failed to get user", zap.Error(err))
+		return
+	}
+
+	if s.requiredRoles[found.User.Role] {
+		enabled, err := s.twoFactorEnabled(ctx, found.User.Id)
+		if err != nil {
+			logger.Error("failed to get two-factor secret", zap.Error(err))
+			return res, err
+		}
+
+		if !enabled {
+			if err = s.sessionRepository.RevokeSession(ctx, claims.Subject, claims.SessionID); err != nil {
+				logger.Error("failed to revoke session", zap.Error(err))
+				return res, err
+			}
+			return res, auth.ErrorTwoFactorRequired
+		}
+	}
+
+	pair, err := s.tokens.IssueUntil(found.User.Id, found.User.Role, claims.SessionID, claims.ExpiresAt.Time)
+	if err != nil {
+		logger.Error("failed to issue tokens", zap.Error(err))
+		return
+	}
+
+	next := session.Entity{
+		ID:        pair.RefreshID,
+		ExpiresAt: pair.RefreshExpiresAt,
+	}
+
+	err = s.sessionRepository.Rotate(ctx, claims.ID, next)
+	switch {
+	case errors.Is(err, session.ErrorReused):
+		logger.Warn("refresh token reused, session revoked")
+		err = auth.ErrorInvalidToken
+		return
+	case errors.Is(err, store.ErrorNotFound), errors.Is(err, session.ErrorRevoked):
+		err = auth.ErrorInvalidToken
+		return
+	case err != nil:
+		logger.Error("failed to rotate refresh token", zap.Error(err))
+		return
+	}
+
+	res = auth.ParseFromPair(pair)
+
+	return
+}
+
+// Logout revokes the session of the refresh token. Access tokens already
+// issued stay valid until they expire.
+func (s *Service) Logout(ctx context.Context, req auth.RefreshRequest) (err error) {
+	logger := log.LoggerFromContext(ctx).Named("Logout")
+
+	claims, err := s.tokens.Parse(*req.RefreshToken, token.TypeRefresh)
+	if err != nil {
+		err = auth.ErrorInvalidToken
+		return
+	}
+
+	err = s.sessionRepository.RevokeSession(ctx, claims.Subject, claims.SessionID)
+	if err != nil {
+		logger.Error("failed to revoke session", zap.Error(err), zap.String("session_id", claims.SessionID))
+		return
+	}
+
+	return
+}
+
+// RevokeUserSessions revokes every session of the user, signing them out
+// everywhere once their access tokens expire.
+func (s *Service) RevokeUserSessions(ctx context.Context, userID string) (res auth.RevokeResponse, err error) {
+	logger := log.LoggerFromContext(ctx).Named("RevokeUserSessions").With(zap.String("user_id", userID))
+
+	res.Revoked, err = s.sessionRepository.RevokeUser(ctx, userID)
+	if err != nil {
+		logger.Error("failed to revoke sessions", zap.Error(err))
+		return
+	}
+
+	return
+}
+
+// Authenticate returns the claims of a valid access token.
+func (s *Service) Authenticate(ctx context.Context, value string) (claims token.Claims, err error) {
+	if claims, err = s.tokens.Parse(value, token.TypeAccess); err != nil {
+		err = auth.ErrorInvalidToken
+	}
+
+	return
+}
+
+// AuthenticateChallenge returns the claims of a valid login challenge token.
+func (s *Service) AuthenticateChallenge(ctx context.Context, value string) (claims token.Claims, err error) {
+	if claims, err = s.tokens.Parse(value, token.TypeChallenge); err != nil {
+		err = auth.ErrorInvalidToken
+	}
+
+	return
+}
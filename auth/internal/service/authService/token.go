@@ -0,0 +1,51 @@
+package authService
+
+import (
+	"context"
+	"github.com/yrss1/my-shop/auth/internal/domain/auth"
+	"github.com/yrss1/my-shop/auth/pkg/log"
+	"github.com/yrss1/my-shop/auth/pkg/token"
+	"go.uber.org/zap"
+)
+
+// IntrospectToken returns the claims of an access or refresh token and
+// whether it is usable: an access token while its session is not revoked, a
+// refresh token until it is used. Tokens that are not usable are no error.
+func (s *Service) IntrospectToken(ctx context.Context, value string) (claims token.Claims, active bool, err error) {
+	logger := log.LoggerFromContext(ctx).Named("IntrospectToken")
+
+	claims, err = s.tokens.ParseAny(value)
+	if err != nil || claims.Type != token.TypeAccess && claims.Type != token.TypeRefresh {
+		return token.Claims{}, false, nil
+	}
+
+	// a refresh token is usable only once, while an access token stays usable
+	// for as long as its session is not revoked
+	if claims.Type == token.TypeRefresh {
+		active, err = s.sessionRepository.Unused(ctx, claims.ID)
+	} else {
+		active, err = s.sessionRepository.Active(ctx, claims.Subject, claims.SessionID)
+	}
+	if err != nil {
+		logger.Error("failed to get session", zap.Error(err), zap.String("session_id", claims.SessionID))
+		return
+	}
+
+	return
+}
+
+// GetPermissions returns the permissions of the user of an access token.
+func (s *Service) GetPermissions(ctx context.Context, value string) (res auth.PermissionsResponse, err error) {
+	claims, err := s.Authenticate(ctx, value)
+	if err != nil {
+		return
+	}
+
+	res = auth.PermissionsResponse{
+		UserID:      claims.Subject,
+		Role:        claims.Role,
+		Permissions: auth.Permissions(claims.Role),
+	}
+
+	return
+}
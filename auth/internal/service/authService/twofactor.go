@@ -0,0 +1,290 @@
+package authService
+
+import (
+	"context"
+	"errors"
+	"github.com/yrss1/my-shop/auth/internal/domain/auth"
+	"github.com/yrss1/my-shop/auth/internal/domain/lockout"
+	"github.com/yrss1/my-shop/auth/internal/domain/twofactor"
+	"github.com/yrss1/my-shop/auth/pkg/log"
+	"github.com/yrss1/my-shop/auth/pkg/store"
+	"github.com/yrss1/my-shop/auth/pkg/token"
+	"github.com/yrss1/my-shop/auth/pkg/totp"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+// LoginTwoFactor finishes a login that returned a challenge with a code of the
+// authenticator app or a recovery code.
+func (s *Service) LoginTwoFactor(ctx context.Context, req auth.TwoFactorLoginRequest) (res auth.TokenResponse, err error) {
+	logger := log.LoggerFromContext(ctx).Named("LoginTwoFactor")
+
+	claims, err := s.tokens.Parse(*req.ChallengeToken, token.TypeChallenge)
+	if err != nil {
+		err = auth.ErrorInvalidToken
+		return
+	}
+	logger = logger.With(zap.String("user_id", claims.Subject))
+
+	account := lockout.Key{Scope: lockout.ScopeTwoFactor, Subject: claims.Subject}
+	keys := s.lockoutKeys(account, lockout.Key{Scope: lockout.ScopeIP, Subject: req.IP})
+	now := time.Now()
+	attempts, err := s.attemptLockout(ctx, keys, now)
+	if err != nil {
+		if !errors.Is(err, auth.ErrorLocked) {
+			logger.Error("failed to check lockout", zap.Error(err))
+		}
+		return
+	}
+
+	if err = s.checkCode(ctx, claims.Subject, *req.Code); err != nil {
+		// only a wrong code is a failure
+		switch {
+		case errors.Is(err, auth.ErrorInvalidCode):
+			s.failLockout(ctx, attempts, now)
+		case errors.Is(err, auth.ErrorTwoFactorDisabled):
+			s.releaseLockout(ctx, keys)
+		default:
+			s.releaseLockout(ctx, keys)
+			logger.Error("failed to check code", zap.Error(err))
+		}
+		return
+	}
+	s.resetLockout(ctx, account, keys)
+
+	if res, err = s.startSession(ctx, claims.Subject, claims.Role); err != nil {
+		logger.Error("failed to start session", zap.Error(err))
+		return
+	}
+
+	return
+}
+
+func (s *Service) GetTwoFactor(ctx context.Context, claims token.Claims) (res auth.TwoFactorStatusResponse, err error) {
+	logger := log.LoggerFromContext(ctx).Named("GetTwoFactor").With(zap.String("user_id", claims.Subject))
+
+	res.Required = s.requiredRoles[claims.Role]
+
+	if res.Enabled, err = s.twoFactorEnabled(ctx, claims.Subject); err != nil || !res.Enabled {
+		if err != nil {
+			logger.Error("failed to get two-factor secret", zap.Error(err))
+		}
+		return
+	}
+
+	if res.RecoveryCodesLeft, err = s.twoFactorRepository.CountRecoveryCodes(ctx, claims.Subject); err != nil {
+		logger.Error("failed to count recovery codes", zap.Error(err))
+		return
+	}
+
+	return
+}
+
+// EnrollTwoFactor creates a new secret for the user. It is not used for logins
+// until ConfirmTwoFactor confirms it, so an enrolment that is given up leaves
+// the account as it was.
+func (s *Service) EnrollTwoFactor(ctx context.Context, claims token.Claims) (res auth.EnrollResponse, err error) {
+	logger := log.LoggerFromContext(ctx).Named("EnrollTwoFactor").With(zap.String("user_id", claims.Subject))
+
+	enabled, err := s.twoFactorEnabled(ctx, claims.Subject)
+	if err != nil {
+		logger.Error("failed to get two-factor secret", zap.Error(err))
+		return
+	}
+	if enabled {
+		err = auth.ErrorTwoFactorEnabled
+		return
+	}
+
+	found, err := s.userClient.GetUser(ctx, claims.Subject)
+	if err != nil {
+		logger.Error("failed to get user", zap.Error(err))
+		return
+	}
+
+	data := twofactor.Entity{UserID: claims.Subject}
+	if data.Secret, err = totp.NewSecret(); err != nil {
+		logger.Error("failed to create secret", zap.Error(err))
+		return
+	}
+
+	if err = s.twoFactorRepository.Save(ctx, data); err != nil {
+		logger.Error("failed to save secret", zap.Error(err))
+		return
+	}
+
+	res = auth.EnrollResponse{
+		Secret: data.Secret,
+		URI:    totp.URI(s.totpIssuer, found.User.Email, data.Secret),
+	}
+
+	return
+}
+
+// ConfirmTwoFactor enables two-factor authentication with the first code of
+// the enrolled secret and returns the recovery codes. Confirming with a login
+// challenge finishes that login.
+func (s *Service) ConfirmTwoFactor(ctx context.Context, claims token.Claims, req auth.CodeRequest) (res auth.TwoFactorResponse, err error) {
+	logger := log.LoggerFromContext(ctx).Named("ConfirmTwoFactor").With(zap.String("user_id", claims.Subject))
+
+	data, err := s.twoFactorRepository.Get(ctx, claims.Subject)
+	switch {
+	case errors.Is(err, store.ErrorNotFound):
+		err = auth.ErrorTwoFactorDisabled
+		return
+	case err != nil:
+		logger.Error("failed to get two-factor secret", zap.Error(err))
+		return
+	case data.Enabled():
+		err = auth.ErrorTwoFactorEnabled
+		return
+	}
+
+	step, ok := totp.Validate(data.Secret, *req.Code, time.Now(), data.LastStep)
+	if !ok {
+		err = auth.ErrorInvalidCode
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		logger.Error("failed to create recovery codes", zap.Error(err))
+		return
+	}
+
+	if err = s.twoFactorRepository.Enable(ctx, claims.Subject, step, hashes); err != nil {
+		if errors.Is(err, store.ErrorNotFound) {
+			// confirmed by a concurrent request
+			err = auth.ErrorTwoFactorEnabled
+			return
+		}
+		logger.Error("failed to enable two-factor authentication", zap.Error(err))
+		return
+	}
+	res.RecoveryCodes = codes
+
+	if claims.Type == token.TypeChallenge {
+		tokens, err := s.startSession(ctx, claims.Subject, claims.Role)
+		if err != nil {
+			logger.Error("failed to start session", zap.Error(err))
+			return res, err
+		}
+		res.TokenResponse = &tokens
+	}
+
+	return
+}
+
+// DisableTwoFactor turns two-factor authentication off after checking a code.
+// Users whose role requires it can't turn it off.
+func (s *Service) DisableTwoFactor(ctx context.Context, claims token.Claims, req auth.CodeRequest) (err error) {
+	logger := log.LoggerFromContext(ctx).Named("DisableTwoFactor").With(zap.String("user_id", claims.Subject))
+
+	// the role of the token may be out of date
+	found, err := s.userClient.GetUser(ctx, claims.Subject)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			err = auth.ErrorInvalidToken
+			return
+		}
+		logger.Error("failed to get user", zap.Error(err))
+		return
+	}
+
+	if s.requiredRoles[found.User.Role] {
+		err = auth.ErrorTwoFactorRequired
+		return
+	}
+
+	if err = s.checkCode(ctx, claims.Subject, *req.Code); err != nil {
+		if !errors.Is(err, auth.ErrorInvalidCode) && !errors.Is(err, auth.ErrorTwoFactorDisabled) {
+			logger.Error("failed to check code", zap.Error(err))
+		}
+		return
+	}
+
+	if err = s.twoFactorRepository.Delete(ctx, claims.Subject); err != nil {
+		logger.Error("failed to delete two-factor secret", zap.Error(err))
+		return
+	}
+
+	return
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the user after
+// checking a code.
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, claims token.Claims, req auth.CodeRequest) (res auth.TwoFactorResponse, err error) {
+	logger := log.LoggerFromContext(ctx).Named("RegenerateRecoveryCodes").With(zap.String("user_id", claims.Subject))
+
+	if err = s.checkCode(ctx, claims.Subject, *req.Code); err != nil {
+		if !errors.Is(err, auth.ErrorInvalidCode) && !errors.Is(err, auth.ErrorTwoFactorDisabled) {
+			logger.Error("failed to check code", zap.Error(err))
+		}
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		logger.Error("failed to create recovery codes", zap.Error(err))
+		return
+	}
+
+	if err = s.twoFactorRepository.ReplaceRecoveryCodes(ctx, claims.Subject, hashes); err != nil {
+		logger.Error("failed to replace recovery codes", zap.Error(err))
+		return
+	}
+	res.RecoveryCodes = codes
+
+	return
+}
+
+func (s *Service) twoFactorEnabled(ctx context.Context, userID string) (enabled bool, err error) {
+	data, err := s.twoFactorRepository.Get(ctx, userID)
+	if errors.Is(err, store.ErrorNotFound) {
+		return false, nil
+	}
+
+	return data.Enabled(), err
+}
+
+// checkCode accepts a code of the authenticator app once, or an unused
+// recovery code.
+func (s *Service) checkCode(ctx context.Context, userID, code string) (err error) {
+	data, err := s.twoFactorRepository.Get(ctx, userID)
+	if errors.Is(err, store.ErrorNotFound) || err == nil && !data.Enabled() {
+		return auth.ErrorTwoFactorDisabled
+	}
+	if err != nil {
+		return
+	}
+
+	if step, ok := totp.Validate(data.Secret, code, time.Now(), data.LastStep); ok {
+		err = s.twoFactorRepository.UseStep(ctx, userID, step)
+		if errors.Is(err, twofactor.ErrorStepUsed) {
+			err = auth.ErrorInvalidCode
+		}
+		return
+	}
+
+	err = s.twoFactorRepository.UseRecoveryCode(ctx, userID, twofactor.HashRecoveryCode(code))
+	if errors.Is(err, store.ErrorNotFound) {
+		err = auth.ErrorInvalidCode
+	}
+
+	return
+}
+
+func newRecoveryCodes() (codes, hashes []string, err error) {
+	if codes, err = twofactor.NewRecoveryCodes(); err != nil {
+		return
+	}
+
+	hashes = make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashes = append(hashes, twofactor.HashRecoveryCode(code))
+	}
+
+	return
+}
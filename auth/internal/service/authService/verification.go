@@ -0,0 +1,205 @@
+package authService
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/yrss1/my-shop/auth/internal/domain/auth"
+	"github.com/yrss1/my-shop/auth/internal/domain/mail"
+	"github.com/yrss1/my-shop/auth/internal/domain/verification"
+	"github.com/yrss1/my-shop/auth/pkg/log"
+	"github.com/yrss1/my-shop/auth/pkg/store"
+	pb "github.com/yrss1/my-shop/proto/user"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+const (
+	verifyEmailTTL   = 24 * time.Hour
+	resetPasswordTTL = time.Hour
+)
+
+// The bodies of the emails are formatted with the name of the user and the
+// link.
+const (
+	verifyEmailBody = `Hello %s,
+
+please confirm your email address by opening this link:
+
+%s
+
+The link expires in 24 hours.
+`
+	resetPasswordBody = `Hello %s,
+
+you can set a new password by opening this link:
+
+%s
+
+The link expires in 1 hour. If you did not ask to reset your password, ignore
+this email; your password stays as it is.
+`
+)
+
+// VerifyEmail marks the email of the user the token was sent to as verified.
+// The token is used up first, so that it cannot be replayed while the user
+// service is called.
+func (s *Service) VerifyEmail(ctx context.Context, req auth.VerifyEmailRequest) (err error) {
+	logger := log.LoggerFromContext(ctx).Named("VerifyEmail")
+
+	data, err := s.verificationRepository.Consume(ctx, verification.PurposeVerifyEmail, verification.HashToken(*req.Token))
+	if err != nil {
+		if errors.Is(err, store.ErrorNotFound) {
+			err = auth.ErrorInvalidToken
+			return
+		}
+		logger.Error("failed to consume token", zap.Error(err))
+		return
+	}
+	logger = logger.With(zap.String("user_id", data.UserID))
+
+	if _, err = s.userClient.MarkEmailVerified(ctx, data.UserID); err != nil {
+		if status.Code(err) == codes.NotFound {
+			err = auth.ErrorInvalidToken
+			return
+		}
+		logger.Error("failed to mark email verified", zap.Error(err))
+		return
+	}
+
+	return
+}
+
+// ResendVerification sends a new verification email, the links of the earlier
+// ones stop working. Unknown and verified emails are ignored, so that the
+// answer tells nothing about which emails are registered.
+func (s *Service) ResendVerification(ctx context.Context, req auth.EmailRequest) (err error) {
+	logger := log.LoggerFromContext(ctx).Named("ResendVerification").With(zap.String("email", *req.Email))
+
+	found, err := s.userClient.GetUserByEmail(ctx, *req.Email)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			err = nil
+			return
+		}
+		logger.Error("failed to get user by email", zap.Error(err))
+		return
+	}
+	if found.User.EmailVerified {
+		return
+	}
+
+	if err = s.sendVerification(ctx, found.User); err != nil {
+		logger.Error("failed to send verification email", zap.Error(err))
+		return
+	}
+
+	return
+}
+
+// ForgotPassword sends a password reset email. Like ResendVerification it
+// succeeds for unknown emails too.
+func (s *Service) ForgotPassword(ctx context.Context, req auth.EmailRequest) (err error) {
+	logger := log.LoggerFromContext(ctx).Named("ForgotPassword").With(zap.String("email", *req.Email))
+
+	found, err := s.userClient.GetUserByEmail(ctx, *req.Email)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			err = nil
+			return
+		}
+		logger.Error("failed to get user by email", zap.Error(err))
+		return
+	}
+
+	err = s.sendToken(ctx, found.User, verification.PurposeResetPassword, resetPasswordTTL,
+		"Reset your password", "/reset-password", resetPasswordBody)
+	if err != nil {
+		logger.Error("failed to send password reset email", zap.Error(err))
+		return
+	}
+
+	return
+}
+
+// ResetPassword sets the password of the user the token was sent to, lifts the
+// lockout of the account and revokes every session of the user. The token is
+// used up before the password is set and is not given back when that fails;
+// the user asks for a new one.
+func (s *Service) ResetPassword(ctx context.Context, req auth.ResetPasswordRequest) (err error) {
+	logger := log.LoggerFromContext(ctx).Named("ResetPassword")
+
+	data, err := s.verificationRepository.Consume(ctx, verification.PurposeResetPassword, verification.HashToken(*req.Token))
+	if err != nil {
+		if errors.Is(err, store.ErrorNotFound) {
+			err = auth.ErrorInvalidToken
+			return
+		}
+		logger.Error("failed to consume token", zap.Error(err))
+		return
+	}
+	logger = logger.With(zap.String("user_id", data.UserID))
+
+	updated, err := s.userClient.SetPassword(ctx, data.UserID, *req.Password)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			err = auth.ErrorInvalidToken
+			return
+		}
+		logger.Error("failed to set password", zap.Error(err))
+		return
+	}
+
+	// the failures were guesses at the password that has just been replaced
+	account := accountKey(updated.User.Email)
+	s.resetLockout(ctx, account, s.lockoutKeys(account))
+
+	if _, err = s.sessionRepository.RevokeUser(ctx, data.UserID); err != nil {
+		logger.Error("failed to revoke sessions", zap.Error(err))
+		return
+	}
+
+	return
+}
+
+func (s *Service) sendVerification(ctx context.Context, data *pb.Response) (err error) {
+	return s.sendToken(ctx, data, verification.PurposeVerifyEmail, verifyEmailTTL,
+		"Confirm your email", "/verify-email", verifyEmailBody)
+}
+
+// sendToken creates a token for the user that replaces the unused ones of the
+// purpose and queues an email with a link to path carrying it. The email is
+// only sent once the token is stored.
+func (s *Service) sendToken(ctx context.Context, data *pb.Response, purpose string, ttl time.Duration, subject, path, body string) (err error) {
+	value, err := verification.NewToken()
+	if err != nil {
+		return
+	}
+
+	link := s.linkURL + path + "?token=" + value
+
+	return s.unitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+		if err = s.verificationRepository.Revoke(ctx, data.Id, purpose); err != nil {
+			return
+		}
+
+		token := verification.Entity{
+			UserID:    data.Id,
+			Purpose:   purpose,
+			TokenHash: verification.HashToken(value),
+			ExpiresAt: time.Now().Add(ttl),
+		}
+		if err = s.verificationRepository.Add(ctx, token); err != nil {
+			return
+		}
+
+		message := mail.Entity{
+			Recipient: data.Email,
+			Subject:   subject,
+			Body:      fmt.Sprintf(body, data.Name, link),
+		}
+		return s.mailRepository.Add(ctx, message)
+	})
+}
@@ -0,0 +1,235 @@
+package authService
+
+import (
+	"context"
+	"errors"
+	"github.com/yrss1/my-shop/auth/internal/domain/auth"
+	"github.com/yrss1/my-shop/auth/internal/domain/lockout"
+	"github.com/yrss1/my-shop/auth/internal/domain/session"
+	"github.com/yrss1/my-shop/auth/internal/domain/verification"
+	"github.com/yrss1/my-shop/auth/internal/provider/user"
+	"github.com/yrss1/my-shop/auth/internal/repository/memory"
+	"github.com/yrss1/my-shop/auth/pkg/log"
+	"github.com/yrss1/my-shop/auth/pkg/store"
+	pb "github.com/yrss1/my-shop/proto/user"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// userServer fakes the password calls of the user service for one user.
+type userServer struct {
+	pb.UnimplementedUserServiceServer
+
+	mu       sync.Mutex
+	user     *pb.Response
+	password string
+}
+
+func (u *userServer) SetPassword(ctx context.Context, req *pb.SetPasswordRequest) (*pb.UserResponse, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if req.UserId != u.user.Id {
+		return nil, status.Error(codes.NotFound, req.UserId)
+	}
+	u.password = req.Password
+
+	return &pb.UserResponse{User: u.user}, nil
+}
+
+// verificationRepository keeps tokens in memory, used up and expiring like
+// the Postgres one.
+type verificationRepository struct {
+	mu     sync.Mutex
+	tokens map[string]verification.Entity
+}
+
+func (r *verificationRepository) Add(ctx context.Context, data verification.Entity) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tokens[data.TokenHash] = data
+	return
+}
+
+func (r *verificationRepository) Consume(ctx context.Context, purpose, tokenHash string) (dest verification.Entity, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	dest, ok := r.tokens[tokenHash]
+	if !ok || dest.Purpose != purpose || dest.UsedAt != nil || !dest.ExpiresAt.After(time.Now()) {
+		return verification.Entity{}, store.ErrorNotFound
+	}
+
+	now := time.Now()
+	dest.UsedAt = &now
+	r.tokens[tokenHash] = dest
+	return
+}
+
+func (r *verificationRepository) Revoke(ctx context.Context, userID, purpose string) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for hash, data := range r.tokens {
+		if data.UserID == userID && data.Purpose == purpose && data.UsedAt == nil {
+			data.UsedAt = &now
+			r.tokens[hash] = data
+		}
+	}
+	return
+}
+
+// sessionRepository keeps sessions in memory. Methods the tests don't need
+// panic through the nil Repository it embeds.
+type sessionRepository struct {
+	session.Repository
+
+	mu       sync.Mutex
+	sessions []session.Entity
+}
+
+func (r *sessionRepository) Add(ctx context.Context, data session.Entity) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sessions = append(r.sessions, data)
+	return
+}
+
+func (r *sessionRepository) RevokeUser(ctx context.Context, userID string) (count int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for i, data := range r.sessions {
+		if data.UserID == userID && data.RevokedAt == nil {
+			r.sessions[i].RevokedAt = &now
+			count++
+		}
+	}
+	return
+}
+
+func (r *sessionRepository) Active(ctx context.Context, userID, sessionID string) (active bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, data := range r.sessions {
+		if data.UserID == userID && data.SessionID == sessionID && data.RevokedAt == nil {
+			active = true
+		}
+	}
+	return
+}
+
+// newUserClient serves users on a local port for the duration of the test.
+func newUserClient(t *testing.T, users *userServer) *user.Client {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := grpc.NewServer()
+	pb.RegisterUserServiceServer(server, users)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	client, err := user.New(listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+// TestResetPassword resets the password of a user who locked the account out
+// and has a session open, with a token that may be used up or expired.
+func TestResetPassword(t *testing.T) {
+	policy := lockout.Policy{Threshold: 3, BaseDelay: time.Minute, MaxDelay: time.Hour, Window: 15 * time.Minute}
+
+	tests := []struct {
+		name      string
+		expiresIn time.Duration
+		used      bool
+		wantErr   error
+	}{
+		{"valid token", time.Hour, false, nil},
+		{"used token", time.Hour, true, auth.ErrorInvalidToken},
+		{"expired token", -time.Minute, false, auth.ErrorInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &userServer{user: &pb.Response{Id: "u1", Email: "A@example.com"}, password: "old"}
+			tokens := &verificationRepository{tokens: make(map[string]verification.Entity)}
+			sessions := &sessionRepository{}
+
+			s, err := New(
+				WithUserClient(newUserClient(t, users)),
+				WithVerificationRepository(tokens),
+				WithSessionRepository(sessions),
+				WithLockoutRepository(memory.NewLockoutRepository()),
+				WithLockoutPolicy(lockout.ScopeAccount, policy))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := log.ContextWithLogger(context.Background(), zap.NewNop())
+
+			keys := s.lockoutKeys(accountKey("a@example.com"))
+			for i := 0; i < policy.Threshold; i++ {
+				attempts, err := s.attemptLockout(ctx, keys, time.Now())
+				if err != nil {
+					t.Fatal(err)
+				}
+				s.failLockout(ctx, attempts, time.Now())
+			}
+
+			sessions.Add(ctx, session.Entity{ID: "r1", SessionID: "s1", UserID: "u1"})
+			tokens.Add(ctx, verification.Entity{
+				UserID:    "u1",
+				Purpose:   verification.PurposeResetPassword,
+				TokenHash: verification.HashToken("token"),
+				ExpiresAt: time.Now().Add(tt.expiresIn),
+			})
+			if tt.used {
+				tokens.Consume(ctx, verification.PurposeResetPassword, verification.HashToken("token"))
+			}
+
+			token, password := "token", "new"
+			err = s.ResetPassword(ctx, auth.ResetPasswordRequest{Token: &token, Password: &password})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ResetPassword() error = %v, want %v", err, tt.wantErr)
+			}
+
+			reset := tt.wantErr == nil
+			if wantPassword := map[bool]string{true: "new", false: "old"}[reset]; users.password != wantPassword {
+				t.Errorf("password = %s, want %s", users.password, wantPassword)
+			}
+			if active, _ := sessions.Active(ctx, "u1", "s1"); active == reset {
+				t.Errorf("session active = %v, want %v", active, !reset)
+			}
+			if _, err := s.attemptLockout(ctx, keys, time.Now()); errors.Is(err, auth.ErrorLocked) == reset {
+				t.Errorf("attempt error = %v, want locked = %v", err, !reset)
+			}
+
+			// a token works once
+			if reset {
+				err = s.ResetPassword(ctx, auth.ResetPasswordRequest{Token: &token, Password: &password})
+				if !errors.Is(err, auth.ErrorInvalidToken) {
+					t.Errorf("second ResetPassword() error = %v, want %v", err, auth.ErrorInvalidToken)
+				}
+			}
+		})
+	}
+}
@@ -36,6 +36,22 @@ func BadRequest(c *gin.Context, err error, data any) {
 	c.JSON(http.StatusBadRequest, h)
 }
 
+func Unauthorized(c *gin.Context, err error) {
+	h := Object{
+		Success: false,
+		Message: err.Error(),
+	}
+	c.JSON(http.StatusUnauthorized, h)
+}
+
+func Forbidden(c *gin.Context, err error) {
+	h := Object{
+		Success: false,
+		Message: err.Error(),
+	}
+	c.JSON(http.StatusForbidden, h)
+}
+
 func NotFound(c *gin.Context, err error) {
 	h := Object{
 		Success: false,
@@ -44,6 +60,22 @@ func NotFound(c *gin.Context, err error) {
 	c.JSON(http.StatusNotFound, h)
 }
 
+func Conflict(c *gin.Context, err error) {
+	h := Object{
+		Success: false,
+		Message: err.Error(),
+	}
+	c.JSON(http.StatusConflict, h)
+}
+
+func TooManyRequests(c *gin.Context, err error) {
+	h := Object{
+		Success: false,
+		Message: err.Error(),
+	}
+	c.JSON(http.StatusTooManyRequests, h)
+}
+
 func InternalServerError(c *gin.Context, err error) {
 	h := Object{
 		Success: false,
@@ -0,0 +1,158 @@
+package token
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"time"
+)
+
+const (
+	TypeAccess  = "access"
+	TypeRefresh = "refresh"
+	// TypeChallenge is the type of the token of a login waiting for its
+	// second factor.
+	TypeChallenge = "challenge"
+
+	challengeTTL = 5 * time.Minute
+)
+
+var (
+	ErrorInvalid = errors.New("token is invalid or expired")
+)
+
+// Claims are the claims of the tokens of a user. Subject is the user ID and
+// SessionID the login the tokens were issued for.
+type Claims struct {
+	jwt.RegisteredClaims
+	Role      string `json:"role,omitempty"`
+	Type      string `json:"typ"`
+	SessionID string `json:"sid,omitempty"`
+}
+
+// Pair is an access token with the refresh token issued alongside it.
+type Pair struct {
+	Access          string
+	AccessExpiresAt time.Time
+
+	Refresh          string
+	RefreshID        string
+	RefreshExpiresAt time.Time
+}
+
+// Manager issues and parses HS256 signed tokens.
+type Manager struct {
+	secret     []byte
+	issuer     string
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+func New(secret, issuer string, accessTTL, refreshTTL time.Duration) (m *Manager, err error) {
+	if len(secret) < 32 {
+		err = errors.New("token: secret must be at least 32 bytes long")
+		return
+	}
+
+	m = &Manager{
+		secret:     []byte(secret),
+		issuer:     issuer,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+	}
+
+	return
+}
+
+// Issue signs a new access and refresh token for the user in the session.
+func (m *Manager) Issue(subject, role, sessionID string) (pair Pair, err error) {
+	return m.IssueUntil(subject, role, sessionID, time.Now().Add(m.refreshTTL))
+}
+
+// IssueUntil is Issue with the refresh token expiring at refreshExpiresAt
+// rather than after the refresh TTL, so that rotating a refresh token does not
+// extend its session.
+func (m *Manager) IssueUntil(subject, role, sessionID string, refreshExpiresAt time.Time) (pair Pair, err error) {
+	now := time.Now()
+	claims := Claims{Role: role, SessionID: sessionID}
+	claims.Subject = subject
+
+	claims.Type = TypeAccess
+	pair.AccessExpiresAt = now.Add(m.accessTTL)
+	if pair.Access, _, err = m.sign(claims, now, pair.AccessExpiresAt); err != nil {
+		return
+	}
+
+	claims.Type = TypeRefresh
+	pair.RefreshExpiresAt = refreshExpiresAt
+	pair.Refresh, pair.RefreshID, err = m.sign(claims, now, pair.RefreshExpiresAt)
+
+	return
+}
+
+// IssueChallenge signs a short lived token that proves the user passed the
+// first factor of a login.
+func (m *Manager) IssueChallenge(subject, role string) (value string, expiresAt time.Time, err error) {
+	now := time.Now()
+	claims := Claims{Role: role, Type: TypeChallenge}
+	claims.Subject = subject
+
+	expiresAt = now.Add(challengeTTL)
+	value, _, err = m.sign(claims, now, expiresAt)
+
+	return
+}
+
+// Parse verifies value and returns its claims. Tokens of another type than typ
+// are rejected, so that a refresh token can't be used for access and the
+// other way round.
+func (m *Manager) Parse(value, typ string) (claims Claims, err error) {
+	if claims, err = m.ParseAny(value); err == nil && claims.Type != typ {
+		err = ErrorInvalid
+	}
+
+	return
+}
+
+// ParseAny verifies value and returns its claims whatever its type, for
+// describing tokens rather than accepting them.
+func (m *Manager) ParseAny(value string) (claims Claims, err error) {
+	_, err = jwt.ParseWithClaims(value, &claims, func(*jwt.Token) (any, error) {
+		return m.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithIssuer(m.issuer), jwt.WithExpirationRequired())
+	if err != nil {
+		err = ErrorInvalid
+	}
+
+	return
+}
+
+func (m *Manager) sign(claims Claims, issuedAt, expiresAt time.Time) (value, id string, err error) {
+	if id, err = NewID(); err != nil {
+		return
+	}
+
+	claims.ID = id
+	claims.Issuer = m.issuer
+	claims.IssuedAt = jwt.NewNumericDate(issuedAt)
+	claims.ExpiresAt = jwt.NewNumericDate(expiresAt)
+
+	value, err = jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.secret)
+
+	return
+}
+
+// NewID returns a random UUID, as used for token and session IDs.
+func NewID() (id string, err error) {
+	b := make([]byte, 16)
+	if _, err = rand.Read(b); err != nil {
+		return
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	id = fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+
+	return
+}
@@ -0,0 +1,181 @@
+package token
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"testing"
+	"time"
+)
+
+const (
+	testSecret = "0123456789abcdef0123456789abcdef"
+	testIssuer = "auth"
+)
+
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+
+	m, err := New(testSecret, testIssuer, time.Minute, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return m
+}
+
+// forge signs claims the way Manager does, but with any method and key.
+func forge(t *testing.T, method jwt.SigningMethod, key any, claims Claims) string {
+	t.Helper()
+
+	value, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return value
+}
+
+func validClaims(typ string) Claims {
+	now := time.Now()
+	claims := Claims{Role: "customer", Type: typ, SessionID: "s1"}
+	claims.Subject = "u1"
+	claims.ID = "t1"
+	claims.Issuer = testIssuer
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(now.Add(time.Minute))
+	return claims
+}
+
+func TestParse(t *testing.T) {
+	m := newTestManager(t)
+
+	pair, err := m.Issue("u1", "admin", "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	challenge, _, err := m.IssueChallenge("u1", "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expired := validClaims(TypeAccess)
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Second))
+
+	noExpiry := validClaims(TypeAccess)
+	noExpiry.ExpiresAt = nil
+
+	otherIssuer := validClaims(TypeAccess)
+	otherIssuer.Issuer = "someone else"
+
+	tests := []struct {
+		name    string
+		value   string
+		typ     string
+		wantErr bool
+	}{
+		{"access token", pair.Access, TypeAccess, false},
+		{"refresh token", pair.Refresh, TypeRefresh, false},
+		{"challenge token", challenge, TypeChallenge, false},
+		{"refresh token for access", pair.Refresh, TypeAccess, true},
+		{"access token for refresh", pair.Access, TypeRefresh, true},
+		{"challenge token for access", challenge, TypeAccess, true},
+		{"access token for challenge", pair.Access, TypeChallenge, true},
+		{"forged with the secret", forge(t, jwt.SigningMethodHS256, []byte(testSecret), validClaims(TypeAccess)), TypeAccess, false},
+		{"expired", forge(t, jwt.SigningMethodHS256, []byte(testSecret), expired), TypeAccess, true},
+		{"without expiry", forge(t, jwt.SigningMethodHS256, []byte(testSecret), noExpiry), TypeAccess, true},
+		{"other issuer", forge(t, jwt.SigningMethodHS256, []byte(testSecret), otherIssuer), TypeAccess, true},
+		{"other secret", forge(t, jwt.SigningMethodHS256, []byte("another secret of at least 32 bytes"), validClaims(TypeAccess)), TypeAccess, true},
+		{"HS512 with the secret", forge(t, jwt.SigningMethodHS512, []byte(testSecret), validClaims(TypeAccess)), TypeAccess, true},
+		{"RS256", forge(t, jwt.SigningMethodRS256, rsaKey, validClaims(TypeAccess)), TypeAccess, true},
+		{"none", forge(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, validClaims(TypeAccess)), TypeAccess, true},
+		{"tampered", pair.Access + "x", TypeAccess, true},
+		{"garbage", "not.a.token", TypeAccess, true},
+		{"empty", "", TypeAccess, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := m.Parse(tt.value, tt.typ)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrorInvalid) {
+					t.Errorf("Parse() error = %v, want %v", err, ErrorInvalid)
+				}
+				return
+			}
+
+			if claims.Subject != "u1" || claims.Type != tt.typ {
+				t.Errorf("Parse() claims = %+v", claims)
+			}
+		})
+	}
+}
+
+func TestIssue(t *testing.T) {
+	m := newTestManager(t)
+
+	pair, err := m.Issue("u1", "admin", "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		typ   string
+		ttl   time.Duration
+	}{
+		{"access", pair.Access, TypeAccess, time.Minute},
+		{"refresh", pair.Refresh, TypeRefresh, time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := m.Parse(tt.value, tt.typ)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			if claims.Subject != "u1" || claims.Role != "admin" || claims.SessionID != "s1" || claims.Issuer != testIssuer {
+				t.Errorf("Parse() claims = %+v", claims)
+			}
+			if got := claims.ExpiresAt.Sub(claims.IssuedAt.Time); got != tt.ttl {
+				t.Errorf("lifetime = %v, want %v", got, tt.ttl)
+			}
+		})
+	}
+
+	refresh, _ := m.Parse(pair.Refresh, TypeRefresh)
+	if refresh.ID != pair.RefreshID {
+		t.Errorf("refresh token ID = %s, want %s", refresh.ID, pair.RefreshID)
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  string
+		wantErr bool
+	}{
+		{"long enough", testSecret, false},
+		{"too short", testSecret[:31], true},
+		{"empty", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.secret, testIssuer, time.Minute, time.Hour); (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
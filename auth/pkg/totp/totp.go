@@ -0,0 +1,103 @@
+// Package totp implements time based one-time passwords (RFC 6238) the way
+// authenticator apps use them: HMAC-SHA1, 6 digits and 30 second steps.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	Digits = 6
+	Period = 30 * time.Second
+
+	// skew is how many steps a code may be off, for clocks that drift and
+	// codes typed in at the end of their step.
+	skew = 1
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewSecret returns a random 160 bit secret, base32 encoded as apps expect it.
+func NewSecret() (secret string, err error) {
+	b := make([]byte, 20)
+	if _, err = rand.Read(b); err != nil {
+		return
+	}
+
+	return encoding.EncodeToString(b), nil
+}
+
+// URI returns the otpauth URI apps enrol the secret from, usually shown as a
+// QR code.
+func URI(issuer, account, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(Digits))
+	query.Set("period", fmt.Sprint(int(Period.Seconds())))
+
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// Step returns the time step t falls in.
+func Step(t time.Time) int64 {
+	return t.Unix() / int64(Period.Seconds())
+}
+
+// Code returns the code of the secret for the step.
+func Code(secret string, step int64) (code string, err error) {
+	key, err := encoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", Digits, value%1000000), nil
+}
+
+// Validate checks code against the steps around now and returns the step it
+// matched. Steps up to after are not accepted, so that passing the step of
+// the last accepted code makes every code single use.
+func Validate(secret, code string, now time.Time, after int64) (step int64, ok bool) {
+	if len(code) != Digits {
+		return
+	}
+
+	current := Step(now)
+	for step = current - skew; step <= current+skew; step++ {
+		if step <= after {
+			continue
+		}
+
+		expected, err := Code(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,90 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 key of the RFC 6238 test vectors, "12345678901234567890".
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// rfcVectors are the SHA1 test vectors of RFC 6238, appendix B, cut to the
+// last 6 of their 8 digits.
+var rfcVectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestCode(t *testing.T) {
+	for _, tt := range rfcVectors {
+		t.Run(time.Unix(tt.unix, 0).UTC().Format(time.RFC3339), func(t *testing.T) {
+			got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+			if err != nil {
+				t.Fatalf("Code() error = %v", err)
+			}
+			if got != tt.code {
+				t.Errorf("Code() = %s, want %s", got, tt.code)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	step := Step(now)
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		now      time.Time
+		after    int64
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", rfcSecret, "081804", now, 0, step, true},
+		{"lowercase secret", strings.ToLower(rfcSecret), "081804", now, 0, step, true},
+		{"previous step", rfcSecret, "081804", now.Add(Period), 0, step, true},
+		{"next step", rfcSecret, "081804", now.Add(-Period), 0, step, true},
+		{"two steps late", rfcSecret, "081804", now.Add(2 * Period), 0, 0, false},
+		{"two steps early", rfcSecret, "081804", now.Add(-2 * Period), 0, 0, false},
+		{"already used", rfcSecret, "081804", now, step, 0, false},
+		{"after an earlier step", rfcSecret, "081804", now, step - 1, step, true},
+		{"wrong code", rfcSecret, "081805", now, 0, 0, false},
+		{"too short", rfcSecret, "81804", now, 0, 0, false},
+		{"too long", rfcSecret, "0081804", now, 0, 0, false},
+		{"empty", rfcSecret, "", now, 0, 0, false},
+		{"invalid secret", "not base32!", "081804", now, 0, 0, false},
+		{"other vector", rfcSecret, "287082", time.Unix(59, 0), 0, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, gotOK := Validate(tt.secret, tt.code, tt.now, tt.after)
+			if gotOK != tt.wantOK || gotStep != tt.wantStep {
+				t.Errorf("Validate() = %d, %v, want %d, %v", gotStep, gotOK, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestNewSecret(t *testing.T) {
+	secret, err := NewSecret()
+	if err != nil {
+		t.Fatalf("NewSecret() error = %v", err)
+	}
+	if len(secret) != 32 {
+		t.Errorf("NewSecret() = %q, want 32 base32 characters", secret)
+	}
+	if _, err := Code(secret, 1); err != nil {
+		t.Errorf("Code() of a new secret error = %v", err)
+	}
+}
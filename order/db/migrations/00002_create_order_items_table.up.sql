@@ -0,0 +1,28 @@
+DO $$
+    BEGIN
+        -- TABLES --
+        CREATE TABLE IF NOT EXISTS order_items (
+                                                   created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                                   updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                                   id BIGSERIAL PRIMARY KEY,
+                                                   order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+                                                   product_id UUID NOT NULL,
+                                                   product_name VARCHAR(200) NOT NULL,
+                                                   quantity INTEGER NOT NULL CHECK (quantity > 0),
+                                                   unit_price DECIMAL(10, 2) NOT NULL,
+                                                   UNIQUE (order_id, product_id)
+        );
+
+        CREATE INDEX IF NOT EXISTS order_items_order_id_idx ON order_items (order_id);
+
+        -- DATA --
+        INSERT INTO order_items (created_at, updated_at, order_id, product_id, product_name, quantity, unit_price)
+        SELECT op.created_at, op.updated_at, op.order_id, op.product_id, p.name, 1, p.price
+        FROM order_product op
+                 JOIN products p ON p.id = op.product_id
+        ON CONFLICT (order_id, product_id) DO NOTHING;
+
+        DROP TABLE IF EXISTS order_product;
+
+        COMMIT;
+    END $$;
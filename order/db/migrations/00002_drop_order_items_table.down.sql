@@ -0,0 +1,19 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS order_product (
+                                             created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                             updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                             order_id BIGINT NOT NULL,
+                                             product_id UUID NOT NULL,
+                                             PRIMARY KEY (order_id, product_id),
+                                             FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+                                             FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+);
+
+INSERT INTO order_product (created_at, updated_at, order_id, product_id)
+SELECT oi.created_at, oi.updated_at, oi.order_id, oi.product_id
+FROM order_items oi
+         JOIN products p ON p.id = oi.product_id
+ON CONFLICT DO NOTHING;
+
+DROP TABLE IF EXISTS order_items CASCADE;
+END;
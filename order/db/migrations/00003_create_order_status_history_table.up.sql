@@ -0,0 +1,28 @@
+DO $$
+    BEGIN
+        -- DATA --
+        UPDATE orders SET status = 'delivered' WHERE status = 'completed';
+
+        -- TABLES --
+        CREATE TABLE IF NOT EXISTS order_status_history (
+                                                            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                                            id BIGSERIAL PRIMARY KEY,
+                                                            order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+                                                            from_status VARCHAR(50),
+                                                            to_status VARCHAR(50) NOT NULL,
+                                                            actor VARCHAR(100) NOT NULL,
+                                                            reason TEXT
+        );
+
+        CREATE INDEX IF NOT EXISTS order_status_history_order_id_idx ON order_status_history (order_id);
+
+        ALTER TABLE orders ADD CONSTRAINT orders_status_check CHECK (status IN (
+            'new', 'awaiting_payment', 'paid', 'processing', 'shipped', 'delivered', 'cancelled', 'refunded'
+        ));
+
+        INSERT INTO order_status_history (created_at, order_id, from_status, to_status, actor)
+        SELECT created_at, id, NULL, status, 'migration'
+        FROM orders;
+
+        COMMIT;
+    END $$;
@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_status_check;
+DROP TABLE IF EXISTS order_status_history CASCADE;
+END;
@@ -0,0 +1,14 @@
+DO $$
+    BEGIN
+        -- COLUMNS --
+        ALTER TABLE order_items ADD COLUMN IF NOT EXISTS variant_id UUID;
+        ALTER TABLE order_items ADD COLUMN IF NOT EXISTS sku VARCHAR(64);
+
+        -- INDEXES --
+        -- an order may hold several variants of the same product
+        ALTER TABLE order_items DROP CONSTRAINT IF EXISTS order_items_order_id_product_id_key;
+        CREATE UNIQUE INDEX IF NOT EXISTS order_items_line_idx
+            ON order_items (order_id, product_id, COALESCE(variant_id, '00000000-0000-0000-0000-000000000000'));
+
+        COMMIT;
+    END $$;
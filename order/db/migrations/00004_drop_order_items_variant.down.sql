@@ -0,0 +1,7 @@
+BEGIN;
+DROP INDEX IF EXISTS order_items_line_idx;
+DELETE FROM order_items WHERE variant_id IS NOT NULL;
+ALTER TABLE order_items ADD CONSTRAINT order_items_order_id_product_id_key UNIQUE (order_id, product_id);
+ALTER TABLE order_items DROP COLUMN IF EXISTS sku;
+ALTER TABLE order_items DROP COLUMN IF EXISTS variant_id;
+END;
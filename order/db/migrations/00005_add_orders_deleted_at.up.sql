@@ -0,0 +1,8 @@
+DO $$
+    BEGIN
+        -- COLUMNS --
+        -- deleted orders keep their items, history and payments and can be restored
+        ALTER TABLE orders ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+        COMMIT;
+    END $$;
@@ -0,0 +1,4 @@
+BEGIN;
+DELETE FROM orders WHERE deleted_at IS NOT NULL;
+ALTER TABLE orders DROP COLUMN IF EXISTS deleted_at;
+END;
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"github.com/yrss1/my-shop/order/internal/config"
 	"github.com/yrss1/my-shop/order/internal/handler"
+	"github.com/yrss1/my-shop/order/internal/provider/payment"
+	"github.com/yrss1/my-shop/order/internal/provider/product"
 	"github.com/yrss1/my-shop/order/internal/repository"
 	"github.com/yrss1/my-shop/order/internal/service/orderService"
 	"github.com/yrss1/my-shop/order/pkg/log"
@@ -32,8 +34,23 @@ func Run() {
 		return
 	}
 
+	productClient, err := product.New(configs.API.Product)
+	if err != nil {
+		logger.Error("ERR_INIT_PRODUCT_CLIENT", zap.Error(err))
+		return
+	}
+
+	paymentClient, err := payment.New(configs.API.Payment)
+	if err != nil {
+		logger.Error("ERR_INIT_PAYMENT_CLIENT", zap.Error(err))
+		return
+	}
+
 	orderService, err := orderService.New(
+		orderService.WithUnitOfWork(repositories.UnitOfWork),
 		orderService.WithOrderRepository(repositories.Order),
+		orderService.WithProductClient(productClient),
+		orderService.WithPaymentClient(paymentClient),
 	)
 	if err != nil {
 		logger.Error("ERR_INIT_ORDER_SERVICE", zap.Error(err))
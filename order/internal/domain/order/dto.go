@@ -2,14 +2,27 @@ package order
 
 import (
 	"errors"
+	"fmt"
+	"time"
 )
 
+// SortFields are the fields order lists can be sorted by.
+var SortFields = []string{"id", "created_at", "total_price", "status"}
+
 type Request struct {
-	ID         string   `json:"id"`
-	UserID     *string  `json:"user_id"`
-	Products   []string `json:"products"`
-	TotalPrice *float64 `json:"total_price"`
-	Status     *string  `json:"status"`
+	ID         string        `json:"id"`
+	UserID     *string       `json:"user_id"`
+	Items      []ItemRequest `json:"items"`
+	TotalPrice *float64      `json:"total_price"`
+	Status     *string       `json:"status"`
+}
+
+// ItemRequest orders a product, or one of its variants when VariantID is set.
+// Products that have variants can only be ordered by variant.
+type ItemRequest struct {
+	ProductID string  `json:"product_id"`
+	VariantID *string `json:"variant_id,omitempty"`
+	Quantity  int     `json:"quantity"`
 }
 
 func (s *Request) Validate() error {
@@ -17,22 +30,20 @@ func (s *Request) Validate() error {
 		return errors.New("user_id: cannot be blank")
 	}
 
-	if s.Products == nil {
-		return errors.New("products: cannot be blank")
+	if s.Items == nil {
+		return errors.New("items: cannot be blank")
 	}
 
-	if len(s.Products) == 0 {
-		return errors.New("products: cannot be empty")
+	if len(s.Items) == 0 {
+		return errors.New("items: cannot be empty")
 	}
 
-	if s.TotalPrice == nil {
-		return errors.New("total_price: cannot be blank")
+	if err := s.validateItems(); err != nil {
+		return err
 	}
 
-	if s.Status == nil {
-		return errors.New("status: cannot be blank")
-	} else if *s.Status != "new" && *s.Status != "processing" && *s.Status != "completed" {
-		return errors.New("status: invalid value")
+	if s.Status != nil && *s.Status != StatusNew {
+		return errors.New("status: new orders must start as " + StatusNew)
 	}
 
 	return nil
@@ -40,44 +51,149 @@ func (s *Request) Validate() error {
 
 func (s *Request) IsEmpty(check string) error {
 	if check == "update" {
-		if s.UserID == nil && s.Products == nil &&
-			s.TotalPrice == nil && s.Status == nil {
+		if s.UserID == nil && s.Items == nil && s.TotalPrice == nil {
 			return errors.New("data: cannot be blank")
 		}
 
-		if s.Products != nil && len(s.Products) == 0 {
-			return errors.New("products: cannot be empty")
+		if s.Status != nil {
+			return errors.New("status: use POST /orders/{id}/status to change it")
 		}
 
-		if s.Status != nil && *s.Status != "new" && *s.Status != "processing" && *s.Status != "completed" {
-			return errors.New("status: invalid value")
+		if s.Items != nil && len(s.Items) == 0 {
+			return errors.New("items: cannot be empty")
+		}
+
+		if err := s.validateItems(); err != nil {
+			return err
 		}
+
 	}
 
 	if check == "search" {
 		if s.UserID == nil && s.Status == nil {
 			return errors.New("invalid query: userId or status is required")
 		}
+
+		if s.Status != nil && !IsValidStatus(*s.Status) {
+			return errors.New("status: invalid value")
+		}
+	}
+
+	return nil
+}
+
+func (s *Request) validateItems() error {
+	seen := make(map[string]bool, len(s.Items))
+	for i, item := range s.Items {
+		if item.ProductID == "" {
+			return fmt.Errorf("items[%d].product_id: cannot be blank", i)
+		}
+		if item.Quantity <= 0 {
+			return fmt.Errorf("items[%d].quantity: must be positive", i)
+		}
+		if item.VariantID != nil && *item.VariantID == "" {
+			return fmt.Errorf("items[%d].variant_id: cannot be blank", i)
+		}
+
+		key := item.ProductID
+		if item.VariantID != nil {
+			key += "/" + *item.VariantID
+		}
+		if seen[key] {
+			return fmt.Errorf("items[%d]: duplicate item %s", i, key)
+		}
+		seen[key] = true
+	}
+
+	return nil
+}
+
+type TransitionRequest struct {
+	Status string  `json:"status"`
+	Actor  string  `json:"actor"`
+	Reason *string `json:"reason"`
+}
+
+func (s *TransitionRequest) Validate() error {
+	if s.Status == "" {
+		return errors.New("status: cannot be blank")
+	}
+
+	if !IsValidStatus(s.Status) {
+		return errors.New("status: invalid value")
+	}
+
+	if s.Status == StatusCancelled {
+		return errors.New("status: use POST /orders/{id}/cancel to cancel an order")
+	}
+
+	if s.Actor == "" {
+		return errors.New("actor: cannot be blank")
+	}
+
+	return nil
+}
+
+type CancelRequest struct {
+	Actor  string `json:"actor"`
+	Reason string `json:"reason"`
+}
+
+func (s *CancelRequest) Validate() error {
+	if s.Actor == "" {
+		return errors.New("actor: cannot be blank")
+	}
+
+	if s.Reason == "" {
+		return errors.New("reason: cannot be blank")
 	}
 
 	return nil
 }
 
 type Response struct {
-	ID         string   `json:"id"`
-	UserID     string   `json:"user_id"`
-	Products   []string `json:"products"`
-	TotalPrice float64  `json:"total_price"`
-	Status     string   `json:"status"`
+	ID         string         `json:"id"`
+	UserID     string         `json:"user_id"`
+	Items      []ItemResponse `json:"items"`
+	TotalPrice float64        `json:"total_price"`
+	Status     string         `json:"status"`
+	Version    int            `json:"version"`
+
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+type ItemResponse struct {
+	ProductID   string  `json:"product_id"`
+	VariantID   *string `json:"variant_id,omitempty"`
+	SKU         *string `json:"sku,omitempty"`
+	ProductName string  `json:"product_name"`
+	Quantity    int     `json:"quantity"`
+	UnitPrice   float64 `json:"unit_price"`
+	LineTotal   float64 `json:"line_total"`
 }
 
 func ParseFromEntity(data Entity) (res Response) {
 	res = Response{
 		ID:         data.ID,
 		UserID:     *data.UserID,
-		Products:   data.Products,
+		Items:      make([]ItemResponse, 0, len(data.Items)),
 		TotalPrice: *data.TotalPrice,
 		Status:     *data.Status,
+		DeletedAt:  data.DeletedAt,
+	}
+	if data.Version != nil {
+		res.Version = *data.Version
+	}
+	for _, item := range data.Items {
+		res.Items = append(res.Items, ItemResponse{
+			ProductID:   item.ProductID,
+			VariantID:   item.VariantID,
+			SKU:         item.SKU,
+			ProductName: item.ProductName,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.UnitPrice,
+			LineTotal:   item.LineTotal(),
+		})
 	}
 	return
 }
@@ -89,3 +205,44 @@ func ParseFromEntities(data []Entity) (res []Response) {
 	}
 	return
 }
+
+type HistoryResponse struct {
+	FromStatus string    `json:"from_status,omitempty"`
+	ToStatus   string    `json:"to_status"`
+	Actor      string    `json:"actor"`
+	Reason     string    `json:"reason,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func ParseFromHistory(data []History) (res []HistoryResponse) {
+	res = make([]HistoryResponse, 0)
+	for _, object := range data {
+		item := HistoryResponse{
+			ToStatus:  object.ToStatus,
+			Actor:     object.Actor,
+			CreatedAt: object.CreatedAt,
+		}
+		if object.FromStatus != nil {
+			item.FromStatus = *object.FromStatus
+		}
+		if object.Reason != nil {
+			item.Reason = *object.Reason
+		}
+		res = append(res, item)
+	}
+	return
+}
+
+// Compensation is the outcome of one compensating action taken while
+// cancelling an order. Result is one of done, skipped or failed.
+type Compensation struct {
+	Action string `json:"action"`
+	Target string `json:"target"`
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+type CancelResponse struct {
+	Order         Response       `json:"order"`
+	Compensations []Compensation `json:"compensations"`
+}
@@ -1,9 +1,57 @@
 package order
 
+import (
+	"math"
+	"time"
+)
+
 type Entity struct {
 	ID         string   `db:"id"`
 	UserID     *string  `db:"user_id"`
-	Products   []string `db:"products"`
+	Items      []Item   `db:"items"`
 	TotalPrice *float64 `db:"total_price"`
 	Status     *string  `db:"status"`
+	Version    *int     `db:"version"`
+
+	DeletedAt *time.Time `db:"deleted_at"`
+}
+
+// Item is an order line. ProductName, SKU and UnitPrice are a snapshot of the
+// catalogue taken when the line was priced. VariantID and SKU are set when the
+// line is a variant of the product.
+type Item struct {
+	OrderID     string  `db:"order_id"`
+	ProductID   string  `db:"product_id"`
+	VariantID   *string `db:"variant_id"`
+	SKU         *string `db:"sku"`
+	ProductName string  `db:"product_name"`
+	Quantity    int     `db:"quantity"`
+	UnitPrice   float64 `db:"unit_price"`
+}
+
+func (i Item) LineTotal() float64 {
+	return float64(Cents(i.UnitPrice)*int64(i.Quantity)) / 100
+}
+
+// History is one recorded status transition of an order.
+type History struct {
+	OrderID    string    `db:"order_id"`
+	FromStatus *string   `db:"from_status"`
+	ToStatus   string    `db:"to_status"`
+	Actor      string    `db:"actor"`
+	Reason     *string   `db:"reason"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+// TotalPrice sums the line totals in cents to avoid float drift.
+func TotalPrice(items []Item) float64 {
+	var cents int64
+	for _, item := range items {
+		cents += Cents(item.UnitPrice) * int64(item.Quantity)
+	}
+	return float64(cents) / 100
+}
+
+func Cents(price float64) int64 {
+	return int64(math.Round(price * 100))
 }
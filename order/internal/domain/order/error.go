@@ -0,0 +1,16 @@
+package order
+
+import (
+	"errors"
+)
+
+var (
+	ErrorUnknownProduct     = errors.New("products: unknown product")
+	ErrorUnknownVariant     = errors.New("items: unknown product variant")
+	ErrorVariantRequired    = errors.New("items: variant_id is required for products with variants")
+	ErrorTotalPriceMismatch = errors.New("total_price: does not match catalogue prices")
+	ErrorInsufficientStock  = errors.New("items: insufficient stock")
+	ErrorInvalidTransition  = errors.New("status: transition is not allowed")
+	ErrorNotCancellable     = errors.New("status: order can no longer be cancelled")
+	ErrorItemsLocked        = errors.New("items: can no longer be changed once the order is paid")
+)
@@ -0,0 +1,46 @@
+package order
+
+const (
+	StatusNew             = "new"
+	StatusAwaitingPayment = "awaiting_payment"
+	StatusPaid            = "paid"
+	StatusProcessing      = "processing"
+	StatusShipped         = "shipped"
+	StatusDelivered       = "delivered"
+	StatusCancelled       = "cancelled"
+	StatusRefunded        = "refunded"
+)
+
+// transitions lists, for every status, the statuses an order may move to.
+// cancelled and refunded are terminal.
+var transitions = map[string][]string{
+	StatusNew:             {StatusAwaitingPayment, StatusCancelled},
+	StatusAwaitingPayment: {StatusPaid, StatusCancelled},
+	StatusPaid:            {StatusProcessing, StatusCancelled, StatusRefunded},
+	StatusProcessing:      {StatusShipped, StatusCancelled},
+	StatusShipped:         {StatusDelivered},
+	StatusDelivered:       {StatusRefunded},
+	StatusCancelled:       {},
+	StatusRefunded:        {},
+}
+
+// HoldsReservation reports whether the stock of orders with the status is held
+// by a reservation that can still change. Payment commits the reservation, and
+// cancelling or refunding releases it.
+func HoldsReservation(status string) bool {
+	return status == StatusNew || status == StatusAwaitingPayment
+}
+
+func IsValidStatus(status string) bool {
+	_, ok := transitions[status]
+	return ok
+}
+
+func CanTransition(from, to string) bool {
+	for _, status := range transitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package order
+
+import "testing"
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want bool
+	}{
+		{"new to awaiting payment", StatusNew, StatusAwaitingPayment, true},
+		{"new to cancelled", StatusNew, StatusCancelled, true},
+		{"new skips payment", StatusNew, StatusPaid, false},
+		{"awaiting payment to paid", StatusAwaitingPayment, StatusPaid, true},
+		{"paid to processing", StatusPaid, StatusProcessing, true},
+		{"paid to refunded", StatusPaid, StatusRefunded, true},
+		{"processing to shipped", StatusProcessing, StatusShipped, true},
+		{"processing back to paid", StatusProcessing, StatusPaid, false},
+		{"shipped to delivered", StatusShipped, StatusDelivered, true},
+		{"shipped can't be cancelled", StatusShipped, StatusCancelled, false},
+		{"delivered to refunded", StatusDelivered, StatusRefunded, true},
+		{"cancelled is terminal", StatusCancelled, StatusNew, false},
+		{"refunded is terminal", StatusRefunded, StatusPaid, false},
+		{"same status", StatusPaid, StatusPaid, false},
+		{"unknown from", "lost", StatusCancelled, false},
+		{"unknown to", StatusNew, "lost", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanTransition(tt.from, tt.to); got != tt.want {
+				t.Errorf("CanTransition(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHoldsReservation(t *testing.T) {
+	tests := []struct {
+		status string
+		want   bool
+	}{
+		{StatusNew, true},
+		{StatusAwaitingPayment, true},
+		{StatusPaid, false},
+		{StatusProcessing, false},
+		{StatusShipped, false},
+		{StatusDelivered, false},
+		{StatusCancelled, false},
+		{StatusRefunded, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			if got := HoldsReservation(tt.status); got != tt.want {
+				t.Errorf("HoldsReservation(%s) = %v, want %v", tt.status, got, tt.want)
+			}
+		})
+	}
+}
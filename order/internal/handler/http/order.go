@@ -1,13 +1,16 @@
 package http
 
 import (
+	"context"
 	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/yrss1/my-shop/order/internal/domain/order"
 	"github.com/yrss1/my-shop/order/internal/service/orderService"
 	"github.com/yrss1/my-shop/order/pkg/helpers"
+	"github.com/yrss1/my-shop/order/pkg/pagination"
 	"github.com/yrss1/my-shop/order/pkg/server/response"
 	"github.com/yrss1/my-shop/order/pkg/store"
+	"strconv"
 )
 
 type OrderHandler struct {
@@ -27,6 +30,11 @@ func (h *OrderHandler) Routes(r *gin.RouterGroup) {
 		api.GET("/:id", h.get)
 		api.PUT("/:id", h.update)
 		api.DELETE("/:id", h.delete)
+		api.POST("/:id/restore", h.restore)
+
+		api.POST("/:id/status", h.transition)
+		api.POST("/:id/cancel", h.cancel)
+		api.GET("/:id/history", h.history)
 
 		api.GET("/search", h.search)
 
@@ -35,21 +43,38 @@ func (h *OrderHandler) Routes(r *gin.RouterGroup) {
 
 // list godoc
 // @Summary List orders
-// @Description Get all orders
+// @Description Get a page of orders
 // @Tags orders
 // @Accept  json
 // @Produce  json
+// @Param limit query int false "Page size"
+// @Param sort query string false "Sort field, prefixed with - for descending" Enums(id, -id, created_at, -created_at, total_price, -total_price, status, -status)
+// @Param cursor query string false "Cursor of the next page"
+// @Param include_deleted query bool false "Also list deleted orders"
 // @Success 200 {array} order.Response
+// @Failure 400 {object} response.Object
 // @Failure 500 {object} response.Object
 // @Router /orders [get]
 func (h *OrderHandler) list(c *gin.Context) {
-	res, err := h.orderService.ListOrders(c)
+	page, err := pagination.Parse(c.Query("limit"), c.Query("sort"), c.Query("cursor"), order.SortFields...)
+	if err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	ctx, err := deletedContext(c)
+	if err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	res, next, err := h.orderService.ListOrders(ctx, page)
 	if err != nil {
 		response.InternalServerError(c, err)
 		return
 	}
 
-	response.OK(c, res)
+	response.OKPage(c, res, next)
 }
 
 // add godoc
@@ -61,6 +86,8 @@ func (h *OrderHandler) list(c *gin.Context) {
 // @Param order body order.Request true "Order request"
 // @Success 200 {object} order.Response
 // @Failure 400 {object} response.Object
+// @Failure 409 {object} response.Object
+// @Failure 422 {object} response.Object
 // @Failure 500 {object} response.Object
 // @Router /orders [post]
 func (h *OrderHandler) add(c *gin.Context) {
@@ -73,10 +100,18 @@ func (h *OrderHandler) add(c *gin.Context) {
 		response.BadRequest(c, err, req)
 		return
 	}
-	// нужно добавить проверку на продукты
+
 	res, err := h.orderService.CreateOrder(c, req)
 	if err != nil {
-		response.InternalServerError(c, err)
+		switch {
+		case errors.Is(err, order.ErrorUnknownProduct), errors.Is(err, order.ErrorUnknownVariant),
+			errors.Is(err, order.ErrorVariantRequired), errors.Is(err, order.ErrorTotalPriceMismatch):
+			response.UnprocessableEntity(c, err, req)
+		case errors.Is(err, order.ErrorInsufficientStock):
+			response.Conflict(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
 		return
 	}
 
@@ -90,14 +125,23 @@ func (h *OrderHandler) add(c *gin.Context) {
 // @Accept  json
 // @Produce  json
 // @Param id path string true "Order ID"
+// @Param include_deleted query bool false "Also get a deleted order"
 // @Success 200 {object} order.Response
+// @Header 200 {string} ETag "Version of the order, for If-Match"
+// @Failure 400 {object} response.Object
 // @Failure 404 {object} response.Object
 // @Failure 500 {object} response.Object
 // @Router /orders/{id} [get]
 func (h *OrderHandler) get(c *gin.Context) {
 	id := c.Param("id")
 
-	res, err := h.orderService.GetOrder(c, id)
+	ctx, err := deletedContext(c)
+	if err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	res, err := h.orderService.GetOrder(ctx, id)
 	if err != nil {
 		switch {
 		case errors.Is(err, store.ErrorNotFound):
@@ -108,6 +152,7 @@ func (h *OrderHandler) get(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", helpers.ETag(res.Version))
 	response.OK(c, res)
 }
 
@@ -118,16 +163,26 @@ func (h *OrderHandler) get(c *gin.Context) {
 // @Accept  json
 // @Produce  json
 // @Param id path string true "Order ID"
+// @Param If-Match header string true "ETag of the order version being updated, or *"
 // @Param order body order.Request true "Order request"
 // @Success 200 {string} string "ok"
 // @Failure 400 {object} response.Object
 // @Failure 404 {object} response.Object
+// @Failure 409 {object} response.Object
+// @Failure 412 {object} response.Object
+// @Failure 422 {object} response.Object
+// @Failure 428 {object} response.Object
 // @Failure 500 {object} response.Object
 // @Router /orders/{id} [put]
 func (h *OrderHandler) update(c *gin.Context) {
 	id := c.Param("id")
 	req := order.Request{}
 
+	version, ok := ifMatch(c)
+	if !ok {
+		return
+	}
+
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.BadRequest(c, err, req)
 		return
@@ -138,10 +193,17 @@ func (h *OrderHandler) update(c *gin.Context) {
 		return
 	}
 
-	if err := h.orderService.UpdateOrder(c, id, req); err != nil {
+	if err := h.orderService.UpdateOrder(c, id, version, req); err != nil {
 		switch {
 		case errors.Is(err, store.ErrorNotFound):
 			response.NotFound(c, err)
+		case errors.Is(err, store.ErrorVersionMismatch):
+			response.PreconditionFailed(c, err)
+		case errors.Is(err, order.ErrorUnknownProduct), errors.Is(err, order.ErrorUnknownVariant),
+			errors.Is(err, order.ErrorVariantRequired), errors.Is(err, order.ErrorTotalPriceMismatch):
+			response.UnprocessableEntity(c, err, req)
+		case errors.Is(err, order.ErrorInsufficientStock), errors.Is(err, order.ErrorItemsLocked):
+			response.Conflict(c, err)
 		default:
 			response.InternalServerError(c, err)
 		}
@@ -153,22 +215,65 @@ func (h *OrderHandler) update(c *gin.Context) {
 
 // delete godoc
 // @Summary Delete an order
-// @Description Delete order by ID
+// @Description Soft delete order by ID and release its stock; it can be restored later
 // @Tags orders
 // @Accept  json
 // @Produce  json
 // @Param id path string true "Order ID"
+// @Param If-Match header string true "ETag of the order version being deleted, or *"
 // @Success 200 {string} string "Order deleted"
 // @Failure 404 {object} response.Object
+// @Failure 412 {object} response.Object
+// @Failure 428 {object} response.Object
 // @Failure 500 {object} response.Object
 // @Router /orders/{id} [delete]
 func (h *OrderHandler) delete(c *gin.Context) {
 	id := c.Param("id")
 
-	if err := h.orderService.DeleteOrder(c, id); err != nil {
+	version, ok := ifMatch(c)
+	if !ok {
+		return
+	}
+
+	if err := h.orderService.DeleteOrder(c, id, version); err != nil {
 		switch {
 		case errors.Is(err, store.ErrorNotFound):
 			response.NotFound(c, err)
+		case errors.Is(err, store.ErrorVersionMismatch):
+			response.PreconditionFailed(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, id)
+}
+
+// restore godoc
+// @Summary Restore an order
+// @Description Restore a deleted order by ID; an order that is not paid yet reserves its stock again
+// @Tags orders
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Order ID"
+// @Success 200 {string} string "Order restored"
+// @Failure 404 {object} response.Object
+// @Failure 409 {object} response.Object
+// @Failure 422 {object} response.Object
+// @Failure 500 {object} response.Object
+// @Router /orders/{id}/restore [post]
+func (h *OrderHandler) restore(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.orderService.RestoreOrder(c, id); err != nil {
+		switch {
+		case errors.Is(err, store.ErrorNotFound):
+			response.NotFound(c, err)
+		case errors.Is(err, order.ErrorUnknownProduct):
+			response.UnprocessableEntity(c, err, nil)
+		case errors.Is(err, order.ErrorInsufficientStock):
+			response.Conflict(c, err)
 		default:
 			response.InternalServerError(c, err)
 		}
@@ -186,6 +291,10 @@ func (h *OrderHandler) delete(c *gin.Context) {
 // @Produce  json
 // @Param userId query string false "User ID"
 // @Param status query string false "Status"
+// @Param limit query int false "Page size"
+// @Param sort query string false "Sort field, prefixed with - for descending" Enums(id, -id, created_at, -created_at, total_price, -total_price, status, -status)
+// @Param cursor query string false "Cursor of the next page"
+// @Param include_deleted query bool false "Also find deleted orders"
 // @Success 200 {array} order.Response
 // @Failure 400 {object} response.Object
 // @Failure 500 {object} response.Object
@@ -201,11 +310,184 @@ func (h *OrderHandler) search(c *gin.Context) {
 		return
 	}
 
-	res, err := h.orderService.SearchOrder(c, req)
+	page, err := pagination.Parse(c.Query("limit"), c.Query("sort"), c.Query("cursor"), order.SortFields...)
+	if err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	ctx, err := deletedContext(c)
+	if err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	res, next, err := h.orderService.SearchOrder(ctx, req, page)
 	if err != nil {
 		response.InternalServerError(c, err)
 		return
 	}
 
+	response.OKPage(c, res, next)
+}
+
+// transition godoc
+// @Summary Change order status
+// @Description Move an order to the next status of its lifecycle
+// @Tags orders
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Order ID"
+// @Param X-User-ID header string false "Actor performing the transition"
+// @Param transition body order.TransitionRequest true "Transition request"
+// @Success 200 {object} order.Response
+// @Failure 400 {object} response.Object
+// @Failure 404 {object} response.Object
+// @Failure 409 {object} response.Object
+// @Failure 500 {object} response.Object
+// @Router /orders/{id}/status [post]
+func (h *OrderHandler) transition(c *gin.Context) {
+	id := c.Param("id")
+	req := order.TransitionRequest{}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err, req)
+		return
+	}
+
+	if actor := c.GetHeader("X-User-ID"); actor != "" {
+		req.Actor = actor
+	}
+
+	if err := req.Validate(); err != nil {
+		response.BadRequest(c, err, req)
+		return
+	}
+
+	res, err := h.orderService.TransitionOrder(c, id, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrorNotFound):
+			response.NotFound(c, err)
+		case errors.Is(err, order.ErrorInvalidTransition):
+			response.Conflict(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, res)
+}
+
+// cancel godoc
+// @Summary Cancel an order
+// @Description Cancel an order, release its stock and void or refund its payments
+// @Tags orders
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Order ID"
+// @Param X-User-ID header string false "Actor cancelling the order"
+// @Param cancel body order.CancelRequest true "Cancel request"
+// @Success 200 {object} order.CancelResponse
+// @Failure 400 {object} response.Object
+// @Failure 404 {object} response.Object
+// @Failure 409 {object} response.Object
+// @Failure 500 {object} response.Object
+// @Router /orders/{id}/cancel [post]
+func (h *OrderHandler) cancel(c *gin.Context) {
+	id := c.Param("id")
+	req := order.CancelRequest{}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err, req)
+		return
+	}
+
+	if actor := c.GetHeader("X-User-ID"); actor != "" {
+		req.Actor = actor
+	}
+
+	if err := req.Validate(); err != nil {
+		response.BadRequest(c, err, req)
+		return
+	}
+
+	res, err := h.orderService.CancelOrder(c, id, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrorNotFound):
+			response.NotFound(c, err)
+		case errors.Is(err, order.ErrorNotCancellable):
+			response.Conflict(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, res)
+}
+
+// history godoc
+// @Summary Order status history
+// @Description Get the status transitions of an order
+// @Tags orders
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Order ID"
+// @Success 200 {array} order.HistoryResponse
+// @Failure 404 {object} response.Object
+// @Failure 500 {object} response.Object
+// @Router /orders/{id}/history [get]
+func (h *OrderHandler) history(c *gin.Context) {
+	id := c.Param("id")
+
+	res, err := h.orderService.ListOrderHistory(c, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrorNotFound):
+			response.NotFound(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
 	response.OK(c, res)
 }
+
+// deletedContext returns the context of a read, marked to include soft deleted
+// orders when the include_deleted query parameter is true.
+func deletedContext(c *gin.Context) (ctx context.Context, err error) {
+	ctx = c
+
+	if value := c.Query("include_deleted"); value != "" {
+		include, parseErr := strconv.ParseBool(value)
+		if parseErr != nil {
+			return nil, errors.New("include_deleted: invalid value")
+		}
+		if include {
+			ctx = store.WithDeleted(c)
+		}
+	}
+
+	return
+}
+
+// ifMatch returns the version a write expects from the If-Match header. When
+// the header is missing or invalid it writes the error response and ok is
+// false.
+func ifMatch(c *gin.Context) (version *int, ok bool) {
+	version, err := helpers.IfMatch(c.GetHeader("If-Match"))
+	switch {
+	case errors.Is(err, helpers.ErrorIfMatchRequired):
+		response.PreconditionRequired(c, err)
+	case err != nil:
+		response.PreconditionFailed(c, err)
+	default:
+		ok = true
+	}
+
+	return
+}
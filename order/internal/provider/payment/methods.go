@@ -0,0 +1,26 @@
+package payment
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+type Compensation struct {
+	PaymentID string `json:"payment_id"`
+	Action    string `json:"action"`
+	Result    string `json:"result"`
+	Status    string `json:"status"`
+	Error     string `json:"error"`
+}
+
+func (c *Client) CancelOrderPayments(ctx context.Context, orderID string) (dst []Compensation, err error) {
+	path, err := url.JoinPath(c.url, "payments", "orders", orderID, "cancel")
+	if err != nil {
+		return
+	}
+
+	err = c.request(ctx, http.MethodPost, path, nil, &dst)
+
+	return
+}
@@ -0,0 +1,81 @@
+package product
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+type Product struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	Price    float64   `json:"price"`
+	Quantity int       `json:"quantity"`
+	Variants []Variant `json:"variants"`
+}
+
+// Variant is a sellable version of a product. Price already falls back to the
+// product price when the variant does not override it.
+type Variant struct {
+	ID    string  `json:"id"`
+	SKU   string  `json:"sku"`
+	Price float64 `json:"price"`
+}
+
+type ReservationItem struct {
+	ProductID string  `json:"product_id"`
+	VariantID *string `json:"variant_id,omitempty"`
+	Quantity  int     `json:"quantity"`
+}
+
+type reservationRequest struct {
+	OrderID string            `json:"order_id"`
+	Items   []ReservationItem `json:"items"`
+}
+
+func (c *Client) GetProduct(ctx context.Context, id string) (dst Product, err error) {
+	path, err := url.JoinPath(c.url, "products", id)
+	if err != nil {
+		return
+	}
+
+	err = c.request(ctx, http.MethodGet, path, nil, &dst)
+
+	return
+}
+
+func (c *Client) ReserveStock(ctx context.Context, orderID string, items []ReservationItem) (err error) {
+	path, err := url.JoinPath(c.url, "products", "reservations")
+	if err != nil {
+		return
+	}
+
+	data, err := json.Marshal(reservationRequest{OrderID: orderID, Items: items})
+	if err != nil {
+		return
+	}
+
+	return c.request(ctx, http.MethodPost, path, bytes.NewBuffer(data), nil)
+}
+
+func (c *Client) CommitStock(ctx context.Context, orderID string) (err error) {
+	path, err := url.JoinPath(c.url, "products", "reservations", orderID, "commit")
+	if err != nil {
+		return
+	}
+
+	return c.request(ctx, http.MethodPost, path, nil, nil)
+}
+
+func (c *Client) ReleaseStock(ctx context.Context, orderID string) (dst []ReservationItem, err error) {
+	path, err := url.JoinPath(c.url, "products", "reservations", orderID, "release")
+	if err != nil {
+		return
+	}
+
+	err = c.request(ctx, http.MethodPost, path, nil, &dst)
+
+	return
+}
@@ -6,40 +6,34 @@ import (
 	"errors"
 	"fmt"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/yrss1/my-shop/order/internal/domain/order"
+	"github.com/yrss1/my-shop/order/pkg/pagination"
 	"github.com/yrss1/my-shop/order/pkg/store"
 	"strings"
 )
 
 type OrderRepository struct {
 	db *sqlx.DB
+	tx store.UnitOfWork
 }
 
-func NewOrderRepository(db *sqlx.DB) *OrderRepository {
-	return &OrderRepository{db: db}
+func NewOrderRepository(db *sqlx.DB, tx store.UnitOfWork) *OrderRepository {
+	return &OrderRepository{db: db, tx: tx}
 }
 
-func (r *OrderRepository) List(ctx context.Context) (dest []order.Entity, err error) {
+type orderRow struct {
+	order.Entity
+	SortKey string `db:"sort_key"`
+}
+
+func (r *OrderRepository) List(ctx context.Context, page pagination.Request) (dest []order.Entity, next string, err error) {
 	query := `
-		SELECT id, user_id, total_price, status 
+		SELECT id, user_id, total_price, status, version, deleted_at, ` + page.SortKey() + `
 		FROM orders
-		ORDER BY id`
-
-	var orders []order.Entity
-	err = r.db.SelectContext(ctx, &orders, query)
-	if err != nil {
-		return nil, err
-	}
-
-	for i := range orders {
-		products, err := r.getProductsByOrderID(ctx, orders[i].ID)
-		if err != nil {
-			return nil, err
-		}
-		orders[i].Products = products
-	}
+		WHERE ` + store.DeletedFilter(ctx, "orders")
 
-	return orders, nil
+	return r.selectPage(ctx, page, query, nil)
 }
 
 func (r *OrderRepository) Add(ctx context.Context, data order.Entity) (id string, err error) {
@@ -48,33 +42,27 @@ func (r *OrderRepository) Add(ctx context.Context, data order.Entity) (id string
 		VALUES ($1, $2, $3) 
 		RETURNING id`
 
-	if err = r.db.QueryRowContext(ctx, query, data.UserID, data.TotalPrice, data.Status).Scan(&id); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			err = store.ErrorNotFound
-		}
-	}
-
-	productQuery := `
-		INSERT INTO order_product (order_id, product_id) 
-		VALUES ($1, $2)`
-
-	for _, productID := range data.Products {
-		if _, err = r.db.ExecContext(ctx, productQuery, id, productID); err != nil {
+	err = r.tx.Do(ctx, func(ctx context.Context) (err error) {
+		if err = store.Conn(ctx, r.db).QueryRowContext(ctx, query, data.UserID, data.TotalPrice, data.Status).Scan(&id); err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
 				err = store.ErrorNotFound
 			}
+			return
 		}
-	}
+
+		return r.insertOrderItems(ctx, id, data.Items)
+	})
+
 	return
 }
 
 func (r *OrderRepository) Get(ctx context.Context, id string) (dest order.Entity, err error) {
 	query := `
-		SELECT id, user_id, total_price, status 
+		SELECT id, user_id, total_price, status, version, deleted_at
 		FROM orders
-		WHERE id=$1`
+		WHERE id=$1 AND ` + store.DeletedFilter(ctx, "orders")
 
-	if err = r.db.GetContext(ctx, &dest, query, id); err != nil {
+	if err = store.Conn(ctx, r.db).GetContext(ctx, &dest, query, id); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			err = store.ErrorNotFound
 			return
@@ -82,50 +70,89 @@ func (r *OrderRepository) Get(ctx context.Context, id string) (dest order.Entity
 		return
 	}
 
-	products, err := r.getProductsByOrderID(ctx, dest.ID)
-	if err != nil {
+	orders := []order.Entity{dest}
+	if err = r.attachItems(ctx, orders); err != nil {
 		return
 	}
-	dest.Products = products
+	dest = orders[0]
 
 	return
 }
 
+// Update changes the set fields of the order and replaces its items when data
+// has any. With data.Version set it only updates the order at that version and
+// fails with store.ErrorVersionMismatch otherwise.
 func (r *OrderRepository) Update(ctx context.Context, id string, data order.Entity) (err error) {
 	sets, args := r.prepareArgs(data)
 
-	if len(args) > 0 {
+	err = r.tx.Do(ctx, func(ctx context.Context) (err error) {
+		// the order row is touched even when only the items change, so that
+		// the items of a missing or deleted order are not replaced
 		args = append(args, id)
-		sets = append(sets, "updated_at=CURRENT_TIMESTAMP")
+		sets = append(sets, "updated_at=CURRENT_TIMESTAMP", "version=version+1")
+		conds := fmt.Sprintf("id=$%d AND deleted_at IS NULL", len(args))
 
-		query := fmt.Sprintf("UPDATE orders SET %s WHERE id=$%d RETURNING id", strings.Join(sets, ", "), len(args))
+		if data.Version != nil {
+			args = append(args, *data.Version)
+			conds += fmt.Sprintf(" AND version=$%d", len(args))
+		}
+
+		query := fmt.Sprintf("UPDATE orders SET %s WHERE %s RETURNING id", strings.Join(sets, ", "), conds)
 
-		if err = r.db.QueryRowContext(ctx, query, args...).Scan(&id); err != nil {
+		conn := store.Conn(ctx, r.db)
+		if err = conn.QueryRowContext(ctx, query, args...).Scan(&id); err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
-				err = store.ErrorNotFound
-				return
+				err = r.versionError(ctx, conn, id)
 			}
+			return
 		}
-	}
 
-	if data.Products != nil {
-		if err = r.updateOrderProducts(ctx, id, data.Products); err != nil {
-			return
+		if data.Items != nil {
+			if err = r.updateOrderItems(ctx, id, data.Items); err != nil {
+				return
+			}
 		}
-	}
+
+		return
+	})
 
 	return
 }
 
-func (r *OrderRepository) Delete(ctx context.Context, id string) (err error) {
+// Delete soft deletes the order, keeping its items, history and payments.
+// With a version it only deletes the order at that version.
+func (r *OrderRepository) Delete(ctx context.Context, id string, version *int) (err error) {
 	query := `
-		DELETE FROM orders
-		WHERE id=$1
+		UPDATE orders
+		SET deleted_at=CURRENT_TIMESTAMP, version=version+1
+		WHERE id=$1 AND deleted_at IS NULL AND ($2::integer IS NULL OR version=$2)
 		RETURNING id`
 
-	args := []any{id}
+	args := []any{id, version}
+
+	err = r.tx.Do(ctx, func(ctx context.Context) (err error) {
+		conn := store.Conn(ctx, r.db)
+		if err = conn.QueryRowContext(ctx, query, args...).Scan(&id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				err = r.versionError(ctx, conn, id)
+			}
+		}
+		return
+	})
+
+	return
+}
 
-	if err = r.db.QueryRowContext(ctx, query, args...).Scan(&id); err != nil {
+// Restore undoes Delete. Restoring an order that is not deleted gives
+// store.ErrorNotFound.
+func (r *OrderRepository) Restore(ctx context.Context, id string) (err error) {
+	query := `
+		UPDATE orders
+		SET deleted_at=NULL, version=version+1
+		WHERE id=$1 AND deleted_at IS NOT NULL
+		RETURNING id`
+
+	if err = store.Conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(&id); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			err = store.ErrorNotFound
 		}
@@ -134,29 +161,98 @@ func (r *OrderRepository) Delete(ctx context.Context, id string) (err error) {
 	return
 }
 
-func (r *OrderRepository) Search(ctx context.Context, data order.Entity) (dest []order.Entity, err error) {
-	query := "SELECT id, user_id, total_price, status FROM orders WHERE 1=1"
+func (r *OrderRepository) Search(ctx context.Context, data order.Entity, page pagination.Request) (dest []order.Entity, next string, err error) {
+	query := "SELECT id, user_id, total_price, status, version, deleted_at, " + page.SortKey() + " FROM orders WHERE " + store.DeletedFilter(ctx, "orders")
 
 	sets, args := r.prepareArgs(data)
 	if len(sets) > 0 {
 		query += " AND " + strings.Join(sets, " AND ")
 	}
-	err = r.db.SelectContext(ctx, &dest, query, args...)
-	if err != nil {
+
+	return r.selectPage(ctx, page, query, args)
+}
+
+func (r *OrderRepository) selectPage(ctx context.Context, page pagination.Request, query string, args []any) (dest []order.Entity, next string, err error) {
+	query, args = page.Keyset(query, args)
+
+	var rows []orderRow
+	if err = store.Conn(ctx, r.db).SelectContext(ctx, &rows, query, args...); err != nil {
 		return
 	}
 
-	for i := range dest {
-		products, err := r.getProductsByOrderID(ctx, dest[i].ID)
-		if err != nil {
-			return nil, err
+	rows, next = pagination.Next(page, rows, func(row orderRow) (string, string) {
+		return row.SortKey, row.ID
+	})
+
+	dest = make([]order.Entity, 0, len(rows))
+	for _, row := range rows {
+		dest = append(dest, row.Entity)
+	}
+
+	err = r.attachItems(ctx, dest)
+
+	return
+}
+
+// UpdateStatus moves the order from one status to another. The WHERE clause
+// on the current status makes concurrent transitions of one order exclusive.
+func (r *OrderRepository) UpdateStatus(ctx context.Context, id, from, to string) (err error) {
+	query := `
+		UPDATE orders
+		SET status=$1, updated_at=CURRENT_TIMESTAMP, version=version+1
+		WHERE id=$2 AND status=$3 AND deleted_at IS NULL
+		RETURNING id`
+
+	if err = store.Conn(ctx, r.db).QueryRowContext(ctx, query, to, id, from).Scan(&id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			if _, err = r.Get(ctx, id); err == nil {
+				err = fmt.Errorf("%w: order is no longer %s", order.ErrorInvalidTransition, from)
+			}
 		}
-		dest[i].Products = products
 	}
 
 	return
 }
 
+func (r *OrderRepository) AddHistory(ctx context.Context, data order.History) (err error) {
+	query := `
+		INSERT INTO order_status_history (order_id, from_status, to_status, actor, reason)
+		VALUES ($1, $2, $3, $4, $5)`
+
+	args := []any{data.OrderID, data.FromStatus, data.ToStatus, data.Actor, data.Reason}
+
+	_, err = store.Conn(ctx, r.db).ExecContext(ctx, query, args...)
+
+	return
+}
+
+func (r *OrderRepository) ListHistory(ctx context.Context, id string) (dest []order.History, err error) {
+	query := `
+		SELECT order_id, from_status, to_status, actor, reason, created_at
+		FROM order_status_history
+		WHERE order_id=$1
+		ORDER BY created_at, id`
+
+	err = store.Conn(ctx, r.db).SelectContext(ctx, &dest, query, id)
+
+	return
+}
+
+// versionError tells why a versioned write of the order matched no row: the
+// order is missing or deleted, or it is at another version.
+func (r *OrderRepository) versionError(ctx context.Context, conn store.Querier, id string) (err error) {
+	var exists bool
+	query := "SELECT EXISTS(SELECT 1 FROM orders WHERE id=$1 AND deleted_at IS NULL)"
+	if err = conn.GetContext(ctx, &exists, query, id); err != nil {
+		return
+	}
+
+	if exists {
+		return store.ErrorVersionMismatch
+	}
+	return store.ErrorNotFound
+}
+
 func (r *OrderRepository) prepareArgs(data order.Entity) (sets []string, args []any) {
 	if data.UserID != nil {
 		args = append(args, data.UserID)
@@ -176,30 +272,58 @@ func (r *OrderRepository) prepareArgs(data order.Entity) (sets []string, args []
 	return
 }
 
-func (r *OrderRepository) getProductsByOrderID(ctx context.Context, orderID string) (products []string, err error) {
+// attachItems loads the line items of all orders with a single query, so
+// the number of queries does not grow with the number of orders.
+func (r *OrderRepository) attachItems(ctx context.Context, orders []order.Entity) (err error) {
+	if len(orders) == 0 {
+		return
+	}
+
+	ids := make([]string, 0, len(orders))
+	for _, object := range orders {
+		ids = append(ids, object.ID)
+	}
+
 	query := `
-		SELECT p.id
-		FROM products p
-		JOIN order_product op ON p.id = op.product_id
-		WHERE op.order_id = $1`
+		SELECT order_id, product_id, variant_id, sku, product_name, quantity, unit_price
+		FROM order_items
+		WHERE order_id = ANY($1::bigint[])
+		ORDER BY order_id, id`
 
-	err = r.db.SelectContext(ctx, &products, query, orderID)
-	if err != nil {
+	var items []order.Item
+	if err = store.Conn(ctx, r.db).SelectContext(ctx, &items, query, pq.Array(ids)); err != nil {
 		return
 	}
 
+	byOrder := make(map[string][]order.Item, len(orders))
+	for _, item := range items {
+		byOrder[item.OrderID] = append(byOrder[item.OrderID], item)
+	}
+
+	for i := range orders {
+		orders[i].Items = byOrder[orders[i].ID]
+	}
+
 	return
 }
 
-func (r *OrderRepository) updateOrderProducts(ctx context.Context, orderID string, products []string) (err error) {
-	deleteQuery := "DELETE FROM order_product WHERE order_id=$1"
-	if _, err = r.db.ExecContext(ctx, deleteQuery, orderID); err != nil {
+func (r *OrderRepository) updateOrderItems(ctx context.Context, orderID string, items []order.Item) (err error) {
+	deleteQuery := "DELETE FROM order_items WHERE order_id=$1"
+	if _, err = store.Conn(ctx, r.db).ExecContext(ctx, deleteQuery, orderID); err != nil {
 		return
 	}
 
-	productQuery := "INSERT INTO order_product (order_id, product_id) VALUES ($1, $2)"
-	for _, productID := range products {
-		if _, err = r.db.ExecContext(ctx, productQuery, orderID, productID); err != nil {
+	return r.insertOrderItems(ctx, orderID, items)
+}
+
+func (r *OrderRepository) insertOrderItems(ctx context.Context, orderID string, items []order.Item) (err error) {
+	itemQuery := `
+		INSERT INTO order_items (order_id, product_id, variant_id, sku, product_name, quantity, unit_price) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	for _, item := range items {
+		args := []any{orderID, item.ProductID, item.VariantID, item.SKU, item.ProductName, item.Quantity, item.UnitPrice}
+		if _, err = store.Conn(ctx, r.db).ExecContext(ctx, itemQuery, args...); err != nil {
 			return
 		}
 	}
@@ -0,0 +1,162 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/yrss1/my-shop/order/internal/domain/order"
+	"github.com/yrss1/my-shop/order/pkg/store"
+	"os"
+	"sync/atomic"
+	"testing"
+)
+
+const benchItemsPerOrder = 5
+
+// benchPages are the numbers of orders a page is loaded with.
+var benchPages = []int{10, 100, 1000}
+
+var errRollback = errors.New("rollback")
+
+// queryConn is what database/sql uses of a pq connection.
+type queryConn interface {
+	driver.Conn
+	driver.ConnBeginTx
+	driver.QueryerContext
+	driver.ExecerContext
+}
+
+// countingConn counts the statements sent over a pq connection.
+type countingConn struct {
+	queryConn
+	queries *atomic.Int64
+}
+
+func (c countingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.queries.Add(1)
+	return c.queryConn.QueryContext(ctx, query, args)
+}
+
+func (c countingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.queries.Add(1)
+	return c.queryConn.ExecContext(ctx, query, args)
+}
+
+type countingConnector struct {
+	driver.Connector
+	queries *atomic.Int64
+}
+
+func (c countingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return countingConn{queryConn: conn.(queryConn), queries: c.queries}, nil
+}
+
+// BenchmarkAttachItems compares loading the line items of pages of orders one
+// order at a time with the single ANY($1) query of attachItems, and reports
+// the queries each load takes. Batched loading fails the benchmark when it
+// takes more than one query, however many orders the page has. It needs a
+// migrated database in POSTGRES_DSN; the orders it creates are rolled back.
+func BenchmarkAttachItems(b *testing.B) {
+	dsn := os.Getenv("POSTGRES_DSN")
+	if dsn == "" {
+		b.Skip("POSTGRES_DSN is not set")
+	}
+
+	connector, err := pq.NewConnector(dsn)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	var queries atomic.Int64
+	db := sqlx.NewDb(sql.OpenDB(countingConnector{Connector: connector, queries: &queries}), "postgres")
+	defer db.Close()
+
+	tx := store.NewTxManager(db)
+	r := NewOrderRepository(db, tx)
+
+	// reports the queries per load since the count was reset
+	report := func(b *testing.B) float64 {
+		perOp := float64(queries.Load()) / float64(b.N)
+		b.ReportMetric(perOp, "queries/op")
+		return perOp
+	}
+
+	err = tx.Do(context.Background(), func(ctx context.Context) (err error) {
+		all, err := seedOrders(ctx, r, benchPages[len(benchPages)-1])
+		if err != nil {
+			return
+		}
+
+		query := `
+			SELECT order_id, product_id, variant_id, sku, product_name, quantity, unit_price
+			FROM order_items
+			WHERE order_id = $1
+			ORDER BY id`
+
+		for _, n := range benchPages {
+			orders := all[:n]
+
+			b.Run(fmt.Sprintf("per order/%d", n), func(b *testing.B) {
+				queries.Store(0)
+				for i := 0; i < b.N; i++ {
+					for j := range orders {
+						if err := store.Conn(ctx, r.db).SelectContext(ctx, &orders[j].Items, query, orders[j].ID); err != nil {
+							b.Fatal(err)
+						}
+					}
+				}
+				report(b)
+			})
+
+			b.Run(fmt.Sprintf("batched/%d", n), func(b *testing.B) {
+				queries.Store(0)
+				for i := 0; i < b.N; i++ {
+					if err := r.attachItems(ctx, orders); err != nil {
+						b.Fatal(err)
+					}
+				}
+				if perOp := report(b); perOp != 1 {
+					b.Errorf("%v queries for %d orders, want 1", perOp, n)
+				}
+			})
+		}
+
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		b.Fatal(err)
+	}
+}
+
+func seedOrders(ctx context.Context, r *OrderRepository, count int) (orders []order.Entity, err error) {
+	query := `
+		INSERT INTO orders (total_price, status)
+		SELECT 0, 'new' FROM generate_series(1, $1)
+		RETURNING id`
+
+	if err = store.Conn(ctx, r.db).SelectContext(ctx, &orders, query, count); err != nil {
+		return
+	}
+
+	ids := make([]string, 0, len(orders))
+	for _, object := range orders {
+		ids = append(ids, object.ID)
+	}
+
+	query = `
+		INSERT INTO order_items (order_id, product_id, product_name, quantity, unit_price)
+		SELECT id, gen_random_uuid(), 'product', 1, 1
+		FROM unnest($1::bigint[]) AS id, generate_series(1, $2)`
+
+	_, err = store.Conn(ctx, r.db).ExecContext(ctx, query, pq.Array(ids), benchItemsPerOrder)
+
+	return
+}
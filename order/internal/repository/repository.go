@@ -11,7 +11,8 @@ type Configuration func(r *Repository) error
 type Repository struct {
 	postgres store.SQLX
 
-	Order order.Repository
+	UnitOfWork store.UnitOfWork
+	Order      order.Repository
 }
 
 func New(configs ...Configuration) (s *Repository, err error) {
@@ -36,7 +37,8 @@ func WithPostgresStore(dbName string) Configuration {
 		//	return
 		//}
 
-		r.Order = postgres.NewOrderRepository(r.postgres.Client)
+		r.UnitOfWork = store.NewTxManager(r.postgres.Client)
+		r.Order = postgres.NewOrderRepository(r.postgres.Client, r.UnitOfWork)
 
 		return
 	}
@@ -0,0 +1,110 @@
+package orderService
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/yrss1/my-shop/order/internal/domain/order"
+	"github.com/yrss1/my-shop/order/pkg/log"
+	"github.com/yrss1/my-shop/order/pkg/store"
+	"go.uber.org/zap"
+)
+
+// CancelOrder moves an order to cancelled and then runs the compensating
+// actions: the stock reservation is released and the payment service voids or
+// refunds the payments. The order stays cancelled when a compensation fails;
+// cancelling it again retries the compensations without touching the history.
+func (s *Service) CancelOrder(ctx context.Context, id string, req order.CancelRequest) (res order.CancelResponse, err error) {
+	logger := log.LoggerFromContext(ctx).Named("CancelOrder").With(zap.String("id", id))
+
+	var data order.Entity
+	err = s.unitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+		if data, err = s.orderRepository.Get(ctx, id); err != nil {
+			return
+		}
+
+		from := *data.Status
+		if from == order.StatusCancelled {
+			return
+		}
+		if !order.CanTransition(from, order.StatusCancelled) {
+			return fmt.Errorf("%w: status is %s", order.ErrorNotCancellable, from)
+		}
+
+		status := order.StatusCancelled
+		if err = s.orderRepository.UpdateStatus(ctx, id, from, status); err != nil {
+			return
+		}
+
+		history := order.History{
+			OrderID:    id,
+			FromStatus: &from,
+			ToStatus:   status,
+			Actor:      req.Actor,
+			Reason:     &req.Reason,
+		}
+		if err = s.orderRepository.AddHistory(ctx, history); err != nil {
+			return
+		}
+
+		data.Status = &status
+		return
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, order.ErrorInvalidTransition):
+			// the status changed under us
+			err = fmt.Errorf("%w: %v", order.ErrorNotCancellable, err)
+		case !errors.Is(err, store.ErrorNotFound) && !errors.Is(err, order.ErrorNotCancellable):
+			logger.Error("failed to cancel", zap.Error(err))
+		}
+		return
+	}
+
+	res.Order = order.ParseFromEntity(data)
+	res.Compensations = append(res.Compensations, s.compensateStock(ctx, id))
+	res.Compensations = append(res.Compensations, s.compensatePayments(ctx, id)...)
+
+	return
+}
+
+func (s *Service) compensateStock(ctx context.Context, id string) (res order.Compensation) {
+	logger := log.LoggerFromContext(ctx).Named("compensateStock").With(zap.String("id", id))
+
+	res = order.Compensation{Action: "release_stock", Target: id}
+
+	released, err := s.releaseStock(ctx, id)
+	switch {
+	case err != nil:
+		logger.Error("failed to release stock", zap.Error(err))
+		res.Result, res.Error = "failed", err.Error()
+	case len(released) == 0:
+		res.Result = "skipped"
+	default:
+		res.Result = "done"
+	}
+
+	return
+}
+
+func (s *Service) compensatePayments(ctx context.Context, id string) (res []order.Compensation) {
+	logger := log.LoggerFromContext(ctx).Named("compensatePayments").With(zap.String("id", id))
+
+	data, err := s.paymentClient.CancelOrderPayments(ctx, id)
+	if err != nil {
+		logger.Error("failed to cancel payments", zap.Error(err))
+		res = append(res, order.Compensation{Action: "cancel_payments", Target: id, Result: "failed", Error: err.Error()})
+		return
+	}
+
+	for _, object := range data {
+		res = append(res, order.Compensation{
+			Action: object.Action,
+			Target: object.PaymentID,
+			Result: object.Result,
+			Error:  object.Error,
+		})
+	}
+
+	return
+}
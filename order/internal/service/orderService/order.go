@@ -3,16 +3,18 @@ package orderService
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/yrss1/my-shop/order/internal/domain/order"
 	"github.com/yrss1/my-shop/order/pkg/log"
+	"github.com/yrss1/my-shop/order/pkg/pagination"
 	"github.com/yrss1/my-shop/order/pkg/store"
 	"go.uber.org/zap"
 )
 
-func (s *Service) ListOrders(ctx context.Context) (res []order.Response, err error) {
+func (s *Service) ListOrders(ctx context.Context, page pagination.Request) (res []order.Response, next string, err error) {
 	logger := log.LoggerFromContext(ctx).Named("ListOrders")
 
-	data, err := s.orderRepository.List(ctx)
+	data, next, err := s.orderRepository.List(ctx, page)
 	if err != nil {
 		logger.Error("failed to select", zap.Error(err))
 		return
@@ -26,16 +28,53 @@ func (s *Service) ListOrders(ctx context.Context) (res []order.Response, err err
 func (s *Service) CreateOrder(ctx context.Context, req order.Request) (res order.Response, err error) {
 	logger := log.LoggerFromContext(ctx).Named("CreateOrder")
 
+	items, err := s.priceItems(ctx, req.Items)
+	if err != nil {
+		logger.Error("failed to price items", zap.Error(err))
+		return
+	}
+	totalPrice := order.TotalPrice(items)
+	if err = checkTotalPrice(totalPrice, req.TotalPrice); err != nil {
+		return
+	}
+
+	status := order.StatusNew
 	data := order.Entity{
 		UserID:     req.UserID,
-		Products:   req.Products,
-		TotalPrice: req.TotalPrice,
-		Status:     req.Status,
+		Items:      items,
+		TotalPrice: &totalPrice,
+		Status:     &status,
 	}
 
-	data.ID, err = s.orderRepository.Add(ctx, data)
+	reserved := false
+	err = s.unitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+		if data.ID, err = s.orderRepository.Add(ctx, data); err != nil {
+			return
+		}
+		history := order.History{
+			OrderID:  data.ID,
+			ToStatus: status,
+			Actor:    *data.UserID,
+		}
+		if err = s.orderRepository.AddHistory(ctx, history); err != nil {
+			return
+		}
+		if err = s.reserveStock(ctx, data.ID, data.Items); err != nil {
+			return
+		}
+		reserved = true
+		return
+	})
 	if err != nil {
-		logger.Error("failed to create", zap.Error(err))
+		if reserved {
+			// the order insert was rolled back after stock had been reserved
+			if _, releaseErr := s.releaseStock(ctx, data.ID); releaseErr != nil {
+				logger.Error("failed to release stock", zap.Error(releaseErr))
+			}
+		}
+		if !errors.Is(err, order.ErrorInsufficientStock) && !errors.Is(err, order.ErrorUnknownProduct) {
+			logger.Error("failed to create", zap.Error(err))
+		}
 		return
 	}
 
@@ -58,18 +97,57 @@ func (s *Service) GetOrder(ctx context.Context, id string) (res order.Response,
 	return
 }
 
-func (s *Service) UpdateOrder(ctx context.Context, id string, req order.Request) (err error) {
+// UpdateOrder updates the order at version, or at any version when version is
+// nil.
+func (s *Service) UpdateOrder(ctx context.Context, id string, version *int, req order.Request) (err error) {
 	logger := log.LoggerFromContext(ctx).Named("UpdateOrder").With(zap.String("id", id))
 
 	data := order.Entity{
-		UserID:     req.UserID,
-		Products:   req.Products,
-		TotalPrice: req.TotalPrice,
-		Status:     req.Status,
+		UserID:  req.UserID,
+		Version: version,
+	}
+
+	if req.Items != nil || req.TotalPrice != nil {
+		var current order.Entity
+		if current, err = s.orderRepository.Get(ctx, id); err != nil {
+			if !errors.Is(err, store.ErrorNotFound) {
+				logger.Error("failed to get by id", zap.Error(err))
+			}
+			return
+		}
+
+		items := current.Items
+		if req.Items != nil {
+			// the stock of a paid order is committed, its items are final
+			if !order.HoldsReservation(*current.Status) {
+				return fmt.Errorf("%w: status is %s", order.ErrorItemsLocked, *current.Status)
+			}
+
+			if items, err = s.priceItems(ctx, req.Items); err != nil {
+				logger.Error("failed to price items", zap.Error(err))
+				return
+			}
+			data.Items = items
+		}
+
+		totalPrice := order.TotalPrice(items)
+		if err = checkTotalPrice(totalPrice, req.TotalPrice); err != nil {
+			return
+		}
+		data.TotalPrice = &totalPrice
 	}
 
-	err = s.orderRepository.Update(ctx, id, data)
-	if err != nil && !errors.Is(err, store.ErrorNotFound) {
+	err = s.unitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+		if err = s.orderRepository.Update(ctx, id, data); err != nil {
+			return
+		}
+		if data.Items != nil {
+			return s.replaceStock(ctx, id, data.Items)
+		}
+		return
+	})
+	if err != nil && !errors.Is(err, store.ErrorNotFound) && !errors.Is(err, store.ErrorVersionMismatch) &&
+		!errors.Is(err, order.ErrorInsufficientStock) {
 		logger.Error("failed to update by id", zap.Error(err))
 		return
 	}
@@ -77,11 +155,17 @@ func (s *Service) UpdateOrder(ctx context.Context, id string, req order.Request)
 	return
 }
 
-func (s *Service) DeleteOrder(ctx context.Context, id string) (err error) {
+func (s *Service) DeleteOrder(ctx context.Context, id string, version *int) (err error) {
 	logger := log.LoggerFromContext(ctx).Named("DeleteUser").With(zap.String("id", id))
 
-	err = s.orderRepository.Delete(ctx, id)
-	if err != nil && !errors.Is(err, store.ErrorNotFound) {
+	err = s.unitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+		if err = s.orderRepository.Delete(ctx, id, version); err != nil {
+			return
+		}
+		_, err = s.releaseStock(ctx, id)
+		return
+	})
+	if err != nil && !errors.Is(err, store.ErrorNotFound) && !errors.Is(err, store.ErrorVersionMismatch) {
 		logger.Error("failed to delete by id", zap.Error(err))
 		return
 	}
@@ -89,7 +173,38 @@ func (s *Service) DeleteOrder(ctx context.Context, id string) (err error) {
 	return
 }
 
-func (s *Service) SearchOrder(ctx context.Context, req order.Request) (res []order.Response, err error) {
+// RestoreOrder undoes DeleteOrder. Deleting released the reservation of an
+// order that was not paid yet, so such an order reserves its stock again and
+// is only restored when the stock is still available. The stock of a paid
+// order stays committed through the delete.
+func (s *Service) RestoreOrder(ctx context.Context, id string) (err error) {
+	logger := log.LoggerFromContext(ctx).Named("RestoreOrder").With(zap.String("id", id))
+
+	err = s.unitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+		if err = s.orderRepository.Restore(ctx, id); err != nil {
+			return
+		}
+
+		data, err := s.orderRepository.Get(ctx, id)
+		if err != nil {
+			return
+		}
+
+		if !order.HoldsReservation(*data.Status) {
+			return
+		}
+		return s.replaceStock(ctx, id, data.Items)
+	})
+	if err != nil && !errors.Is(err, store.ErrorNotFound) && !errors.Is(err, order.ErrorInsufficientStock) &&
+		!errors.Is(err, order.ErrorUnknownProduct) {
+		logger.Error("failed to restore by id", zap.Error(err))
+		return
+	}
+
+	return
+}
+
+func (s *Service) SearchOrder(ctx context.Context, req order.Request, page pagination.Request) (res []order.Response, next string, err error) {
 	logger := log.LoggerFromContext(ctx).Named("SearchOrder")
 
 	if req.UserID != nil {
@@ -104,7 +219,7 @@ func (s *Service) SearchOrder(ctx context.Context, req order.Request) (res []ord
 		Status: req.Status,
 	}
 
-	data, err := s.orderRepository.Search(ctx, searchData)
+	data, next, err := s.orderRepository.Search(ctx, searchData, page)
 	if err != nil {
 		logger.Error("failed to search orders", zap.Error(err))
 		return
@@ -0,0 +1,218 @@
+package orderService
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/yrss1/my-shop/order/internal/domain/order"
+	"github.com/yrss1/my-shop/order/internal/provider/product"
+	"github.com/yrss1/my-shop/order/pkg/log"
+	"github.com/yrss1/my-shop/order/pkg/store"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// memoryRepository keeps orders in memory. Methods the tests don't need panic
+// through the nil Repository it embeds.
+type memoryRepository struct {
+	order.Repository
+	orders map[string]order.Entity
+}
+
+func (r *memoryRepository) Get(ctx context.Context, id string) (dest order.Entity, err error) {
+	dest, ok := r.orders[id]
+	if !ok || dest.DeletedAt != nil {
+		err = store.ErrorNotFound
+	}
+	return
+}
+
+// Restore only checks that the order exists, the tests never delete one.
+func (r *memoryRepository) Restore(ctx context.Context, id string) (err error) {
+	if _, ok := r.orders[id]; !ok {
+		err = store.ErrorNotFound
+	}
+	return
+}
+
+func (r *memoryRepository) Update(ctx context.Context, id string, data order.Entity) (err error) {
+	current, ok := r.orders[id]
+	if !ok {
+		return store.ErrorNotFound
+	}
+	if data.Items != nil {
+		current.Items = data.Items
+	}
+	if data.TotalPrice != nil {
+		current.TotalPrice = data.TotalPrice
+	}
+	r.orders[id] = current
+	return
+}
+
+// directUnitOfWork runs fn without a transaction.
+type directUnitOfWork struct{}
+
+func (directUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// catalogue fakes the product service: a product "p1" at 10.00 and the status
+// of the stock reservation of every order, which it refuses to reserve twice
+// like the product service does.
+type catalogue struct {
+	mu           sync.Mutex
+	reservations map[string]string
+}
+
+func (c *catalogue) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case r.Method == http.MethodGet && len(parts) == 2 && parts[1] == "p1":
+		reply(w, http.StatusOK, product.Product{ID: "p1", Name: "product", Price: 10})
+	case r.Method == http.MethodPost && len(parts) == 2 && parts[1] == "reservations":
+		var req struct {
+			OrderID string `json:"order_id"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		if status := c.reservations[req.OrderID]; status == "reserved" || status == "committed" {
+			reply(w, http.StatusConflict, nil)
+			return
+		}
+		c.reservations[req.OrderID] = "reserved"
+		reply(w, http.StatusOK, nil)
+	case r.Method == http.MethodPost && len(parts) == 4 && parts[3] == "release":
+		if c.reservations[parts[2]] != "reserved" {
+			reply(w, http.StatusNotFound, nil)
+			return
+		}
+		c.reservations[parts[2]] = "released"
+		reply(w, http.StatusOK, []product.ReservationItem{{ProductID: "p1", Quantity: 1}})
+	default:
+		reply(w, http.StatusNotFound, nil)
+	}
+}
+
+func reply(w http.ResponseWriter, status int, data any) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{"data": data, "success": status == http.StatusOK})
+}
+
+// newTestService returns a service over an order "o1" in status with one line
+// of "p1", whose stock reservation is in reservation.
+func newTestService(t *testing.T, status, reservation string) (*Service, *memoryRepository, *catalogue) {
+	t.Helper()
+
+	c := &catalogue{reservations: map[string]string{"o1": reservation}}
+	server := httptest.NewServer(c)
+	t.Cleanup(server.Close)
+
+	productClient, err := product.New(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	totalPrice := 10.0
+	repository := &memoryRepository{orders: map[string]order.Entity{
+		"o1": {
+			ID:         "o1",
+			Items:      []order.Item{{OrderID: "o1", ProductID: "p1", Quantity: 1, UnitPrice: 10}},
+			TotalPrice: &totalPrice,
+			Status:     &status,
+		},
+	}}
+
+	s, err := New(
+		WithOrderRepository(repository),
+		WithUnitOfWork(directUnitOfWork{}),
+		WithProductClient(productClient))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s, repository, c
+}
+
+func TestUpdateOrderItems(t *testing.T) {
+	tests := []struct {
+		name            string
+		status          string
+		reservation     string
+		wantErr         error
+		wantReservation string
+	}{
+		{"new", order.StatusNew, "reserved", nil, "reserved"},
+		{"awaiting payment", order.StatusAwaitingPayment, "reserved", nil, "reserved"},
+		{"paid", order.StatusPaid, "committed", order.ErrorItemsLocked, "committed"},
+		{"processing", order.StatusProcessing, "committed", order.ErrorItemsLocked, "committed"},
+		{"shipped", order.StatusShipped, "committed", order.ErrorItemsLocked, "committed"},
+		{"cancelled", order.StatusCancelled, "released", order.ErrorItemsLocked, "released"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repository, c := newTestService(t, tt.status, tt.reservation)
+			ctx := log.ContextWithLogger(context.Background(), zap.NewNop())
+
+			req := order.Request{Items: []order.ItemRequest{{ProductID: "p1", Quantity: 2}}}
+			err := s.UpdateOrder(ctx, "o1", nil, req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateOrder() error = %v, want %v", err, tt.wantErr)
+			}
+
+			wantQuantity := 2
+			if tt.wantErr != nil {
+				wantQuantity = 1
+			}
+			if got := repository.orders["o1"].Items[0].Quantity; got != wantQuantity {
+				t.Errorf("quantity = %d, want %d", got, wantQuantity)
+			}
+			if got := c.reservations["o1"]; got != tt.wantReservation {
+				t.Errorf("reservation = %s, want %s", got, tt.wantReservation)
+			}
+		})
+	}
+}
+
+// TestRestoreOrder restores orders whose reservation is as DeleteOrder leaves
+// it: released unless the order was paid and committed its stock.
+func TestRestoreOrder(t *testing.T) {
+	tests := []struct {
+		name            string
+		status          string
+		reservation     string
+		wantReservation string
+	}{
+		{"new", order.StatusNew, "released", "reserved"},
+		{"awaiting payment", order.StatusAwaitingPayment, "released", "reserved"},
+		{"paid", order.StatusPaid, "committed", "committed"},
+		{"processing", order.StatusProcessing, "committed", "committed"},
+		{"shipped", order.StatusShipped, "committed", "committed"},
+		{"delivered", order.StatusDelivered, "committed", "committed"},
+		{"cancelled", order.StatusCancelled, "released", "released"},
+		{"refunded", order.StatusRefunded, "released", "released"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _, c := newTestService(t, tt.status, tt.reservation)
+			ctx := log.ContextWithLogger(context.Background(), zap.NewNop())
+
+			if err := s.RestoreOrder(ctx, "o1"); err != nil {
+				t.Fatalf("RestoreOrder() error = %v", err)
+			}
+
+			if got := c.reservations["o1"]; got != tt.wantReservation {
+				t.Errorf("reservation = %s, want %s", got, tt.wantReservation)
+			}
+		})
+	}
+}
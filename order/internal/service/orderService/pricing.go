@@ -0,0 +1,65 @@
+package orderService
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/yrss1/my-shop/order/internal/domain/order"
+	"github.com/yrss1/my-shop/order/internal/provider/product"
+)
+
+// priceItems resolves every line against the catalogue and snapshots the
+// current product name and price, or the variant SKU and price, into the
+// returned order items.
+func (s *Service) priceItems(ctx context.Context, req []order.ItemRequest) (items []order.Item, err error) {
+	items = make([]order.Item, 0, len(req))
+	for _, line := range req {
+		data, err := s.productClient.GetProduct(ctx, line.ProductID)
+		if err != nil {
+			if errors.Is(err, product.ErrorNotFound) {
+				err = fmt.Errorf("%w: %s", order.ErrorUnknownProduct, line.ProductID)
+			}
+			return nil, err
+		}
+
+		item := order.Item{
+			ProductID:   data.ID,
+			ProductName: data.Name,
+			Quantity:    line.Quantity,
+			UnitPrice:   data.Price,
+		}
+
+		switch {
+		case line.VariantID != nil:
+			variant, ok := findVariant(data.Variants, *line.VariantID)
+			if !ok {
+				return nil, fmt.Errorf("%w: %s of product %s", order.ErrorUnknownVariant, *line.VariantID, line.ProductID)
+			}
+			item.VariantID = &variant.ID
+			item.SKU = &variant.SKU
+			item.UnitPrice = variant.Price
+		case len(data.Variants) > 0:
+			return nil, fmt.Errorf("%w: %s", order.ErrorVariantRequired, line.ProductID)
+		}
+
+		items = append(items, item)
+	}
+
+	return
+}
+
+func findVariant(variants []product.Variant, id string) (product.Variant, bool) {
+	for _, variant := range variants {
+		if variant.ID == id {
+			return variant, true
+		}
+	}
+	return product.Variant{}, false
+}
+
+func checkTotalPrice(expected float64, actual *float64) error {
+	if actual != nil && order.Cents(*actual) != order.Cents(expected) {
+		return fmt.Errorf("%w: expected %.2f, got %.2f", order.ErrorTotalPriceMismatch, expected, *actual)
+	}
+	return nil
+}
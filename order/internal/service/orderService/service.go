@@ -1,11 +1,19 @@
 package orderService
 
-import "github.com/yrss1/my-shop/order/internal/domain/order"
+import (
+	"github.com/yrss1/my-shop/order/internal/domain/order"
+	"github.com/yrss1/my-shop/order/internal/provider/payment"
+	"github.com/yrss1/my-shop/order/internal/provider/product"
+	"github.com/yrss1/my-shop/order/pkg/store"
+)
 
 type Configuration func(s *Service) error
 
 type Service struct {
+	unitOfWork      store.UnitOfWork
 	orderRepository order.Repository
+	productClient   *product.Client
+	paymentClient   *payment.Client
 }
 
 func New(configs ...Configuration) (s *Service, err error) {
@@ -26,3 +34,24 @@ func WithOrderRepository(orderRepository order.Repository) Configuration {
 		return nil
 	}
 }
+
+func WithUnitOfWork(unitOfWork store.UnitOfWork) Configuration {
+	return func(s *Service) error {
+		s.unitOfWork = unitOfWork
+		return nil
+	}
+}
+
+func WithProductClient(productClient *product.Client) Configuration {
+	return func(s *Service) error {
+		s.productClient = productClient
+		return nil
+	}
+}
+
+func WithPaymentClient(paymentClient *payment.Client) Configuration {
+	return func(s *Service) error {
+		s.paymentClient = paymentClient
+		return nil
+	}
+}
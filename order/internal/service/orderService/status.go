@@ -0,0 +1,76 @@
+package orderService
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/yrss1/my-shop/order/internal/domain/order"
+	"github.com/yrss1/my-shop/order/pkg/log"
+	"github.com/yrss1/my-shop/order/pkg/store"
+	"go.uber.org/zap"
+)
+
+func (s *Service) TransitionOrder(ctx context.Context, id string, req order.TransitionRequest) (res order.Response, err error) {
+	logger := log.LoggerFromContext(ctx).Named("TransitionOrder").With(zap.String("id", id), zap.String("status", req.Status))
+
+	var data order.Entity
+	err = s.unitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+		if data, err = s.orderRepository.Get(ctx, id); err != nil {
+			return
+		}
+
+		from := *data.Status
+		if !order.CanTransition(from, req.Status) {
+			return fmt.Errorf("%w: %s -> %s", order.ErrorInvalidTransition, from, req.Status)
+		}
+
+		if err = s.orderRepository.UpdateStatus(ctx, id, from, req.Status); err != nil {
+			return
+		}
+
+		history := order.History{
+			OrderID:    id,
+			FromStatus: &from,
+			ToStatus:   req.Status,
+			Actor:      req.Actor,
+			Reason:     req.Reason,
+		}
+		if err = s.orderRepository.AddHistory(ctx, history); err != nil {
+			return
+		}
+
+		data.Status = &req.Status
+		return
+	})
+	if err != nil {
+		if !errors.Is(err, store.ErrorNotFound) && !errors.Is(err, order.ErrorInvalidTransition) {
+			logger.Error("failed to transition", zap.Error(err))
+		}
+		return
+	}
+
+	res = order.ParseFromEntity(data)
+
+	return
+}
+
+func (s *Service) ListOrderHistory(ctx context.Context, id string) (res []order.HistoryResponse, err error) {
+	logger := log.LoggerFromContext(ctx).Named("ListOrderHistory").With(zap.String("id", id))
+
+	if _, err = s.orderRepository.Get(ctx, id); err != nil {
+		if !errors.Is(err, store.ErrorNotFound) {
+			logger.Error("failed to get by id", zap.Error(err))
+		}
+		return
+	}
+
+	data, err := s.orderRepository.ListHistory(ctx, id)
+	if err != nil {
+		logger.Error("failed to select", zap.Error(err))
+		return
+	}
+
+	res = order.ParseFromHistory(data)
+
+	return
+}
@@ -0,0 +1,61 @@
+package orderService
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/yrss1/my-shop/order/internal/domain/order"
+	"github.com/yrss1/my-shop/order/internal/provider/product"
+)
+
+func (s *Service) reserveStock(ctx context.Context, orderID string, items []order.Item) (err error) {
+	reservation := make([]product.ReservationItem, 0, len(items))
+	for _, item := range items {
+		reservation = append(reservation, product.ReservationItem{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Quantity:  item.Quantity,
+		})
+	}
+
+	err = s.productClient.ReserveStock(ctx, orderID, reservation)
+	switch {
+	case errors.Is(err, product.ErrorConflict):
+		err = fmt.Errorf("%w: %v", order.ErrorInsufficientStock, err)
+	case errors.Is(err, product.ErrorNotFound):
+		err = fmt.Errorf("%w: %v", order.ErrorUnknownProduct, err)
+	}
+
+	return
+}
+
+// releaseStock returns the reserved stock of an order. An order without an
+// active reservation is not an error.
+func (s *Service) releaseStock(ctx context.Context, orderID string) (released []product.ReservationItem, err error) {
+	released, err = s.productClient.ReleaseStock(ctx, orderID)
+	if errors.Is(err, product.ErrorNotFound) {
+		err = nil
+	}
+
+	return
+}
+
+// replaceStock swaps the reservation of an order for the new items. When the
+// new reservation fails the previous one is restored.
+func (s *Service) replaceStock(ctx context.Context, orderID string, items []order.Item) (err error) {
+	released, err := s.releaseStock(ctx, orderID)
+	if err != nil {
+		return
+	}
+
+	if err = s.reserveStock(ctx, orderID, items); err != nil {
+		if len(released) > 0 {
+			if restoreErr := s.productClient.ReserveStock(ctx, orderID, released); restoreErr != nil {
+				err = fmt.Errorf("%w (restore reservation: %v)", err, restoreErr)
+			}
+		}
+		return
+	}
+
+	return
+}
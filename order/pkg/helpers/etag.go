@@ -0,0 +1,46 @@
+package helpers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrorIfMatchRequired = errors.New("If-Match header is required")
+	ErrorIfMatchInvalid  = errors.New("If-Match header must be a single entity tag or *")
+)
+
+// ETag formats a row version as a strong entity tag.
+func ETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// IfMatch parses an If-Match header into the row version a write expects. A
+// * matches any version and gives nil. Weak tags are invalid, as If-Match
+// compares strongly.
+func IfMatch(header string) (version *int, err error) {
+	header = strings.TrimSpace(header)
+	switch header {
+	case "":
+		return nil, ErrorIfMatchRequired
+	case "*":
+		return nil, nil
+	}
+
+	if header[0] != '"' {
+		return nil, ErrorIfMatchInvalid
+	}
+
+	tag, err := strconv.Unquote(header)
+	if err != nil {
+		return nil, ErrorIfMatchInvalid
+	}
+
+	value, err := strconv.Atoi(tag)
+	if err != nil {
+		return nil, ErrorIfMatchInvalid
+	}
+
+	return &value, nil
+}
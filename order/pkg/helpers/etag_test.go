@@ -0,0 +1,62 @@
+package helpers
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    *int
+		wantErr error
+	}{
+		{"version", `"3"`, intPtr(3), nil},
+		{"surrounding spaces", `  "12" `, intPtr(12), nil},
+		{"zero", `"0"`, intPtr(0), nil},
+		{"any version", "*", nil, nil},
+		{"missing", "", nil, ErrorIfMatchRequired},
+		{"blank", "   ", nil, ErrorIfMatchRequired},
+		{"unquoted", "3", nil, ErrorIfMatchInvalid},
+		{"weak", `W/"3"`, nil, ErrorIfMatchInvalid},
+		{"single quoted", `'3'`, nil, ErrorIfMatchInvalid},
+		{"backquoted", "`3`", nil, ErrorIfMatchInvalid},
+		{"list", `"3", "4"`, nil, ErrorIfMatchInvalid},
+		{"not a number", `"abc"`, nil, ErrorIfMatchInvalid},
+		{"unterminated", `"3`, nil, ErrorIfMatchInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := IfMatch(tt.header)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("IfMatch(%q) error = %v, want %v", tt.header, err, tt.wantErr)
+			}
+
+			switch {
+			case got == nil && tt.want == nil:
+			case got == nil || tt.want == nil || *got != *tt.want:
+				t.Errorf("IfMatch(%q) = %v, want %v", tt.header, deref(got), deref(tt.want))
+			}
+		})
+	}
+}
+
+func TestETagRoundTrip(t *testing.T) {
+	for _, version := range []int{0, 1, 42, 1 << 30} {
+		got, err := IfMatch(ETag(version))
+		if err != nil || got == nil || *got != version {
+			t.Errorf("IfMatch(ETag(%d)) = %v, %v", version, deref(got), err)
+		}
+	}
+}
+
+func intPtr(v int) *int { return &v }
+
+func deref(v *int) any {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
@@ -6,4 +6,8 @@ import (
 
 var (
 	ErrorNotFound = errors.New("error not found")
+
+	// ErrorVersionMismatch is returned by writes that expect a row version the
+	// row no longer has.
+	ErrorVersionMismatch = errors.New("version does not match")
 )
@@ -0,0 +1,7 @@
+DO $$
+    BEGIN
+        -- COLUMNS --
+        ALTER TABLE payments ADD COLUMN IF NOT EXISTS transaction_id VARCHAR(100);
+
+        COMMIT;
+    END $$;
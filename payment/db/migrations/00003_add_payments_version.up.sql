@@ -0,0 +1,8 @@
+DO $$
+    BEGIN
+        -- COLUMNS --
+        -- bumped by every edit, compared against If-Match on updates
+        ALTER TABLE payments ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+        COMMIT;
+    END $$;
@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE payments DROP COLUMN IF EXISTS version;
+END;
@@ -17,7 +17,7 @@ const docTemplate = `{
     "paths": {
         "/payments": {
             "get": {
-                "description": "Get all payments",
+                "description": "Get a page of payments",
                 "consumes": [
                     "application/json"
                 ],
@@ -28,6 +28,34 @@ const docTemplate = `{
                     "payments"
                 ],
                 "summary": "List payments",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "-id",
+                            "created_at",
+                            "-created_at",
+                            "status",
+                            "-status"
+                        ],
+                        "type": "string",
+                        "description": "Sort field, prefixed with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor of the next page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -38,6 +66,12 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Object"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -91,6 +125,59 @@ const docTemplate = `{
                 }
             }
         },
+        "/payments/orders/{orderId}/cancel": {
+            "post": {
+                "description": "Void pending and refund successful payments of a cancelled order",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payments"
+                ],
+                "summary": "Cancel order payments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID",
+                        "name": "orderId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/payment.CompensationResponse"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Object"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/response.Object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Object"
+                        }
+                    }
+                }
+            }
+        },
         "/payments/search": {
             "get": {
                 "description": "Search payments by user ID or order ID",
@@ -122,6 +209,32 @@ const docTemplate = `{
                         "description": "Status",
                         "name": "status",
                         "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "-id",
+                            "created_at",
+                            "-created_at",
+                            "status",
+                            "-status"
+                        ],
+                        "type": "string",
+                        "description": "Sort field, prefixed with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor of the next page",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -176,6 +289,12 @@ const docTemplate = `{
                         "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/payment.Response"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the payment, for If-Match"
+                            }
                         }
                     },
                     "404": {
@@ -212,6 +331,13 @@ const docTemplate = `{
                         "in": "path",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "description": "ETag of the payment version being updated, or *",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
                     {
                         "description": "Payment request",
                         "name": "payment",
@@ -241,6 +367,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/response.Object"
                         }
                     },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/response.Object"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/response.Object"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -268,6 +406,13 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the payment version being deleted, or *",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -283,6 +428,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/response.Object"
                         }
                     },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/response.Object"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/response.Object"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -294,6 +451,26 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "payment.CompensationResponse": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "payment_id": {
+                    "type": "string"
+                },
+                "result": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
         "payment.Request": {
             "type": "object",
             "properties": {
@@ -329,8 +506,14 @@ const docTemplate = `{
                 "status": {
                     "type": "string"
                 },
+                "transaction_id": {
+                    "type": "string"
+                },
                 "user_id": {
                     "type": "string"
+                },
+                "version": {
+                    "type": "integer"
                 }
             }
         },
@@ -341,6 +524,9 @@ const docTemplate = `{
                 "message": {
                     "type": "string"
                 },
+                "next_cursor": {
+                    "type": "string"
+                },
                 "success": {
                     "type": "boolean"
                 }
@@ -7,6 +7,8 @@ import (
 	"github.com/yrss1/my-shop/payment/internal/config"
 	"github.com/yrss1/my-shop/payment/internal/handler"
 	"github.com/yrss1/my-shop/payment/internal/provider/epay"
+	"github.com/yrss1/my-shop/payment/internal/provider/order"
+	"github.com/yrss1/my-shop/payment/internal/provider/product"
 	"github.com/yrss1/my-shop/payment/internal/repository"
 	"github.com/yrss1/my-shop/payment/internal/service/epayment"
 	"github.com/yrss1/my-shop/payment/pkg/log"
@@ -45,9 +47,23 @@ func Run() {
 		return
 	}
 
+	productClient, err := product.New(configs.API.Product)
+	if err != nil {
+		logger.Error("ERR_INIT_CLIENTS", zap.Error(err))
+		return
+	}
+
+	orderClient, err := order.New(configs.API.Order)
+	if err != nil {
+		logger.Error("ERR_INIT_CLIENTS", zap.Error(err))
+		return
+	}
+
 	epayService, err := epayment.New(
 		epayment.WithPaymentRepository(repositories.Payment),
 		epayment.WithEpayClient(EpayClient),
+		epayment.WithProductClient(productClient),
+		epayment.WithOrderClient(orderClient),
 	)
 	if err != nil {
 		logger.Error("ERR_INIT_EPAY_SERVICE", zap.Error(err))
@@ -21,6 +21,7 @@ type (
 		APP      AppConfig
 		POSTGRES StoreConfig
 		EPAY     CredentialsConfig
+		API      APIConfig
 	}
 
 	AppConfig struct {
@@ -41,6 +42,11 @@ type (
 		OAuthURL       string
 		PaymentPageURL string
 	}
+
+	APIConfig struct {
+		Product string
+		Order   string
+	}
 )
 
 func New() (cfg Configs, err error) {
@@ -69,5 +75,9 @@ func New() (cfg Configs, err error) {
 		return
 	}
 
+	if err = envconfig.Process("API", &cfg.API); err != nil {
+		return
+	}
+
 	return
 }
@@ -4,12 +4,17 @@ import (
 	"errors"
 )
 
+// SortFields are the fields payment lists can be sorted by.
+var SortFields = []string{"id", "created_at", "status"}
+
 type Request struct {
 	ID      string  `json:"id"`
 	UserID  *string `json:"user_id"`
 	OrderID *string `json:"order_id"`
 	Amount  *string `json:"amount"`
 	Status  *string `json:"status"`
+
+	TransactionID *string `json:"-"`
 }
 
 func (s *Request) Validate() error {
@@ -60,6 +65,9 @@ type Response struct {
 	OrderID string `json:"order_id"`
 	Amount  string `json:"amount"`
 	Status  string `json:"status"`
+	Version int    `json:"version"`
+
+	TransactionID string `json:"transaction_id,omitempty"`
 }
 
 func ParseFromEntity(data Entity) (res Response) {
@@ -70,6 +78,12 @@ func ParseFromEntity(data Entity) (res Response) {
 		Amount:  *data.Amount,
 		Status:  *data.Status,
 	}
+	if data.Version != nil {
+		res.Version = *data.Version
+	}
+	if data.TransactionID != nil {
+		res.TransactionID = *data.TransactionID
+	}
 	return
 }
 
@@ -80,3 +94,13 @@ func ParseFromEntities(data []Entity) (res []Response) {
 	}
 	return
 }
+
+// CompensationResponse reports what happened to one payment when its order
+// was cancelled.
+type CompensationResponse struct {
+	PaymentID string `json:"payment_id"`
+	Action    string `json:"action"`
+	Result    string `json:"result"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+}
@@ -6,4 +6,7 @@ type Entity struct {
 	OrderID *string `db:"order_id"`
 	Amount  *string `db:"amount"`
 	Status  *string `db:"status"`
+	Version *int    `db:"version"`
+
+	TransactionID *string `db:"transaction_id"`
 }
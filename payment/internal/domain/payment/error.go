@@ -0,0 +1,12 @@
+package payment
+
+import (
+	"errors"
+)
+
+var (
+	ErrorMissingTransaction = errors.New("transaction_id: payment was not registered with the provider")
+	ErrorOrderNotFound      = errors.New("order not found")
+	ErrorOrderNotCancelled  = errors.New("order is not cancelled")
+	ErrorRefundPending      = errors.New("refund was started but not recorded, check it with the provider")
+)
@@ -7,6 +7,7 @@ import (
 	"github.com/yrss1/my-shop/payment/internal/provider/epay"
 	"github.com/yrss1/my-shop/payment/internal/service/epayment"
 	"github.com/yrss1/my-shop/payment/pkg/helpers"
+	"github.com/yrss1/my-shop/payment/pkg/pagination"
 	"github.com/yrss1/my-shop/payment/pkg/server/response"
 	"github.com/yrss1/my-shop/payment/pkg/store"
 )
@@ -29,6 +30,8 @@ func (h *PaymentHandler) Routes(r *gin.RouterGroup) {
 		api.DELETE("/:id", h.delete)
 		api.GET("/search", h.search)
 
+		api.POST("/orders/:orderId/cancel", h.cancelOrder)
+
 		//api.POST("/token", h.getToken)
 		//api.GET("/status/:id", h.getStatus)
 		//api.GET("/pay", h.pay)
@@ -38,21 +41,31 @@ func (h *PaymentHandler) Routes(r *gin.RouterGroup) {
 
 // list godoc
 // @Summary List payments
-// @Description Get all payments
+// @Description Get a page of payments
 // @Tags payments
 // @Accept  json
 // @Produce  json
+// @Param limit query int false "Page size"
+// @Param sort query string false "Sort field, prefixed with - for descending" Enums(id, -id, created_at, -created_at, status, -status)
+// @Param cursor query string false "Cursor of the next page"
 // @Success 200 {array} payment.Response
+// @Failure 400 {object} response.Object
 // @Failure 500 {object} response.Object
 // @Router /payments [get]
 func (h *PaymentHandler) list(c *gin.Context) {
-	res, err := h.epayService.ListPayments(c)
+	page, err := pagination.Parse(c.Query("limit"), c.Query("sort"), c.Query("cursor"), payment.SortFields...)
+	if err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	res, next, err := h.epayService.ListPayments(c, page)
 	if err != nil {
 		response.InternalServerError(c, err)
 		return
 	}
 
-	response.OK(c, res)
+	response.OKPage(c, res, next)
 }
 
 // add godoc
@@ -96,6 +109,7 @@ func (h *PaymentHandler) add(c *gin.Context) {
 		response.InternalServerError(c, err)
 		return
 	}
+	req.TransactionID = helpers.GetStringPtr(payRes.ID)
 	switch payRes.Status {
 	case "NEW", "AUTH", "EXPIRED":
 		req.Status = helpers.GetStringPtr("pending")
@@ -121,6 +135,7 @@ func (h *PaymentHandler) add(c *gin.Context) {
 // @Produce  json
 // @Param id path string true "Payment ID"
 // @Success 200 {object} payment.Response
+// @Header 200 {string} ETag "Version of the payment, for If-Match"
 // @Failure 404 {object} response.Object
 // @Failure 500 {object} response.Object
 // @Router /payments/{id} [get]
@@ -138,6 +153,7 @@ func (h *PaymentHandler) get(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", helpers.ETag(res.Version))
 	response.OK(c, res)
 }
 
@@ -148,16 +164,24 @@ func (h *PaymentHandler) get(c *gin.Context) {
 // @Accept  json
 // @Produce  json
 // @Param id path string true "Payment ID"
+// @Param If-Match header string true "ETag of the payment version being updated, or *"
 // @Param payment body payment.Request true "Payment request"
 // @Success 200 {string} string "ok"
 // @Failure 400 {object} response.Object
 // @Failure 404 {object} response.Object
+// @Failure 412 {object} response.Object
+// @Failure 428 {object} response.Object
 // @Failure 500 {object} response.Object
 // @Router /payments/{id} [put]
 func (h *PaymentHandler) update(c *gin.Context) {
 	id := c.Param("id")
 	req := payment.Request{}
 
+	version, ok := ifMatch(c)
+	if !ok {
+		return
+	}
+
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.BadRequest(c, err, req)
 		return
@@ -168,10 +192,12 @@ func (h *PaymentHandler) update(c *gin.Context) {
 		return
 	}
 
-	if err := h.epayService.UpdatePayment(c, id, req); err != nil {
+	if err := h.epayService.UpdatePayment(c, id, version, req); err != nil {
 		switch {
 		case errors.Is(err, store.ErrorNotFound):
 			response.NotFound(c, err)
+		case errors.Is(err, store.ErrorVersionMismatch):
+			response.PreconditionFailed(c, err)
 		default:
 			response.InternalServerError(c, err)
 		}
@@ -188,17 +214,27 @@ func (h *PaymentHandler) update(c *gin.Context) {
 // @Accept  json
 // @Produce  json
 // @Param id path string true "Payment ID"
+// @Param If-Match header string true "ETag of the payment version being deleted, or *"
 // @Success 200 {string} string "Payment deleted"
 // @Failure 404 {object} response.Object
+// @Failure 412 {object} response.Object
+// @Failure 428 {object} response.Object
 // @Failure 500 {object} response.Object
 // @Router /payments/{id} [delete]
 func (h *PaymentHandler) delete(c *gin.Context) {
 	id := c.Param("id")
 
-	if err := h.epayService.DeletePayment(c, id); err != nil {
+	version, ok := ifMatch(c)
+	if !ok {
+		return
+	}
+
+	if err := h.epayService.DeletePayment(c, id, version); err != nil {
 		switch {
 		case errors.Is(err, store.ErrorNotFound):
 			response.NotFound(c, err)
+		case errors.Is(err, store.ErrorVersionMismatch):
+			response.PreconditionFailed(c, err)
 		default:
 			response.InternalServerError(c, err)
 		}
@@ -217,6 +253,9 @@ func (h *PaymentHandler) delete(c *gin.Context) {
 // @Param userId query string false "User ID"
 // @Param orderId query string false "Order ID"
 // @Param status query string false "Status"
+// @Param limit query int false "Page size"
+// @Param sort query string false "Sort field, prefixed with - for descending" Enums(id, -id, created_at, -created_at, status, -status)
+// @Param cursor query string false "Cursor of the next page"
 // @Success 200 {array} payment.Response
 // @Failure 400 {object} response.Object
 // @Failure 500 {object} response.Object
@@ -233,12 +272,49 @@ func (h *PaymentHandler) search(c *gin.Context) {
 		return
 	}
 
-	res, err := h.epayService.SearchPayment(c, req)
+	page, err := pagination.Parse(c.Query("limit"), c.Query("sort"), c.Query("cursor"), payment.SortFields...)
+	if err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	res, next, err := h.epayService.SearchPayment(c, req, page)
 	if err != nil {
 		response.InternalServerError(c, err)
 		return
 	}
 
+	response.OKPage(c, res, next)
+}
+
+// cancelOrder godoc
+// @Summary Cancel order payments
+// @Description Void pending and refund successful payments of a cancelled order
+// @Tags payments
+// @Accept  json
+// @Produce  json
+// @Param orderId path string true "Order ID"
+// @Success 200 {array} payment.CompensationResponse
+// @Failure 404 {object} response.Object
+// @Failure 409 {object} response.Object
+// @Failure 500 {object} response.Object
+// @Router /payments/orders/{orderId}/cancel [post]
+func (h *PaymentHandler) cancelOrder(c *gin.Context) {
+	orderID := c.Param("orderId")
+
+	res, err := h.epayService.CancelOrderPayments(c, orderID)
+	if err != nil {
+		switch {
+		case errors.Is(err, payment.ErrorOrderNotFound):
+			response.NotFound(c, err)
+		case errors.Is(err, payment.ErrorOrderNotCancelled):
+			response.Conflict(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
 	response.OK(c, res)
 }
 
@@ -304,3 +380,20 @@ func (h *PaymentHandler) search(c *gin.Context) {
 //	//	response.BadRequest(c, nil, "Invalid token")
 //	//}
 //}
+
+// ifMatch returns the version a write expects from the If-Match header. When
+// the header is missing or invalid it writes the error response and ok is
+// false.
+func ifMatch(c *gin.Context) (version *int, ok bool) {
+	version, err := helpers.IfMatch(c.GetHeader("If-Match"))
+	switch {
+	case errors.Is(err, helpers.ErrorIfMatchRequired):
+		response.PreconditionRequired(c, err)
+	case err != nil:
+		response.PreconditionFailed(c, err)
+	default:
+		ok = true
+	}
+
+	return
+}
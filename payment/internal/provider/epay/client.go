@@ -1,10 +1,10 @@
 package epay
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"time"
@@ -45,7 +45,7 @@ func New(credentials Credentials) (client *Client, err error) {
 	return
 }
 
-func (c *Client) request(ctx context.Context, method, url string, body *bytes.Buffer, headers map[string]string, dst interface{}) error {
+func (c *Client) request(ctx context.Context, method, url string, body io.Reader, headers map[string]string, dst interface{}) error {
 	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		return err
@@ -66,5 +66,9 @@ func (c *Client) request(ctx context.Context, method, url string, body *bytes.Bu
 		return fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(respBody))
 	}
 
+	if dst == nil {
+		return nil
+	}
+
 	return json.NewDecoder(resp.Body).Decode(dst)
 }
@@ -0,0 +1,42 @@
+package epay
+
+import (
+	"context"
+	"net/url"
+)
+
+// Cancel voids an authorised payment before it has been charged.
+func (c *Client) Cancel(ctx context.Context, token string, transactionID string) (err error) {
+	path, err := url.Parse(c.credentials.URL)
+	if err != nil {
+		return
+	}
+
+	path = path.JoinPath("/operation/", transactionID, "/cancel")
+
+	headers := map[string]string{
+		"Authorization": "Bearer " + token,
+	}
+
+	return c.request(ctx, "POST", path.String(), nil, headers, nil)
+}
+
+// Refund returns a charged payment to the card. An empty amount refunds the
+// whole payment.
+func (c *Client) Refund(ctx context.Context, token string, transactionID string, amount string) (err error) {
+	path, err := url.Parse(c.credentials.URL)
+	if err != nil {
+		return
+	}
+
+	path = path.JoinPath("/operation/", transactionID, "/refund")
+	if amount != "" {
+		path.RawQuery = url.Values{"amount": {amount}}.Encode()
+	}
+
+	headers := map[string]string{
+		"Authorization": "Bearer " + token,
+	}
+
+	return c.request(ctx, "POST", path.String(), nil, headers, nil)
+}
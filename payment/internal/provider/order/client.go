@@ -0,0 +1,75 @@
+package order
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+var (
+	ErrorNotFound = errors.New("order not found")
+	ErrorConflict = errors.New("order conflict")
+)
+
+type Client struct {
+	httpClient *http.Client
+	url        string
+}
+
+type envelope struct {
+	Data    json.RawMessage `json:"data"`
+	Message string          `json:"message"`
+	Success bool            `json:"success"`
+}
+
+func New(url string) (client *Client, err error) {
+	if url == "" {
+		err = errors.New("order: undefined service url")
+		return
+	}
+
+	client = &Client{
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		url:        url,
+	}
+
+	return
+}
+
+func (c *Client) request(ctx context.Context, method, url string, body io.Reader, dst any) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	res := envelope{}
+	if err = json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return fmt.Errorf("unexpected response, status code: %d: %w", resp.StatusCode, err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: %s", ErrorNotFound, res.Message)
+	case resp.StatusCode == http.StatusConflict:
+		return fmt.Errorf("%w: %s", ErrorConflict, res.Message)
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("unexpected status code: %d, message: %s", resp.StatusCode, res.Message)
+	}
+
+	if dst == nil {
+		return nil
+	}
+
+	return json.Unmarshal(res.Data, dst)
+}
@@ -0,0 +1,23 @@
+package order
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+type Order struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+func (c *Client) GetOrder(ctx context.Context, id string) (dst Order, err error) {
+	path, err := url.JoinPath(c.url, "orders", id)
+	if err != nil {
+		return
+	}
+
+	err = c.request(ctx, http.MethodGet, path, nil, &dst)
+
+	return
+}
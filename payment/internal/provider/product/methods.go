@@ -0,0 +1,25 @@
+package product
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+func (c *Client) CommitStock(ctx context.Context, orderID string) (err error) {
+	path, err := url.JoinPath(c.url, "products", "reservations", orderID, "commit")
+	if err != nil {
+		return
+	}
+
+	return c.request(ctx, http.MethodPost, path, nil, nil)
+}
+
+func (c *Client) ReleaseStock(ctx context.Context, orderID string) (err error) {
+	path, err := url.JoinPath(c.url, "products", "reservations", orderID, "release")
+	if err != nil {
+		return
+	}
+
+	return c.request(ctx, http.MethodPost, path, nil, nil)
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"github.com/jmoiron/sqlx"
 	"github.com/yrss1/my-shop/payment/internal/domain/payment"
+	"github.com/yrss1/my-shop/payment/pkg/pagination"
 	"github.com/yrss1/my-shop/payment/pkg/store"
 	"strings"
 )
@@ -19,24 +20,27 @@ func NewPaymentRepository(db *sqlx.DB) *PaymentRepository {
 	return &PaymentRepository{db: db}
 }
 
-func (r *PaymentRepository) List(ctx context.Context) (dest []payment.Entity, err error) {
+type paymentRow struct {
+	payment.Entity
+	SortKey string `db:"sort_key"`
+}
+
+func (r *PaymentRepository) List(ctx context.Context, page pagination.Request) (dest []payment.Entity, next string, err error) {
 	query := `
-			SELECT id, user_id, order_id, amount, status
+			SELECT id, user_id, order_id, amount, status, transaction_id, version, ` + page.SortKey() + `
 			FROM payments
-			ORDER BY id`
-
-	err = r.db.SelectContext(ctx, &dest, query)
+			WHERE 1=1`
 
-	return
+	return r.selectPage(ctx, page, query, nil)
 }
 
 func (r *PaymentRepository) Add(ctx context.Context, data payment.Entity) (id string, err error) {
 	query := `
-		INSERT INTO payments (user_id, order_id, amount, status) 
-		VALUES ($1, $2, $3, $4) 
+		INSERT INTO payments (user_id, order_id, amount, status, transaction_id) 
+		VALUES ($1, $2, $3, $4, $5) 
 		RETURNING id`
 
-	args := []any{data.UserID, data.OrderID, data.Amount, data.Status}
+	args := []any{data.UserID, data.OrderID, data.Amount, data.Status, data.TransactionID}
 
 	if err = r.db.QueryRowContext(ctx, query, args...).Scan(&id); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -49,7 +53,7 @@ func (r *PaymentRepository) Add(ctx context.Context, data payment.Entity) (id st
 
 func (r *PaymentRepository) Get(ctx context.Context, id string) (dest payment.Entity, err error) {
 	query := `
-		SELECT id, user_id, order_id, amount, status 
+		SELECT id, user_id, order_id, amount, status, transaction_id, version
 		FROM payments 
 		WHERE id=$1`
 
@@ -64,18 +68,32 @@ func (r *PaymentRepository) Get(ctx context.Context, id string) (dest payment.En
 	return
 }
 
+// Update changes the set fields of the payment. With data.Version set it only
+// updates the payment at that version and fails with
+// store.ErrorVersionMismatch otherwise.
 func (r *PaymentRepository) Update(ctx context.Context, id string, data payment.Entity) (err error) {
 	sets, args := r.prepareArgs(data)
 
+	// an empty update changes nothing, but a stale version still fails
+	if len(args) == 0 && data.Version != nil {
+		return r.checkVersion(ctx, id, *data.Version)
+	}
+
 	if len(args) > 0 {
 		args = append(args, id)
-		sets = append(sets, "updated_at=CURRENT_TIMESTAMP")
+		sets = append(sets, "updated_at=CURRENT_TIMESTAMP", "version=version+1")
+		conds := fmt.Sprintf("id=$%d", len(args))
+
+		if data.Version != nil {
+			args = append(args, *data.Version)
+			conds += fmt.Sprintf(" AND version=$%d", len(args))
+		}
 
-		query := fmt.Sprintf("UPDATE payments SET %s WHERE id=$%d RETURNING id", strings.Join(sets, ", "), len(args))
+		query := fmt.Sprintf("UPDATE payments SET %s WHERE %s RETURNING id", strings.Join(sets, ", "), conds)
 
 		if err = r.db.QueryRowContext(ctx, query, args...).Scan(&id); err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
-				err = store.ErrorNotFound
+				err = r.versionError(ctx, id)
 			}
 		}
 	}
@@ -83,6 +101,37 @@ func (r *PaymentRepository) Update(ctx context.Context, id string, data payment.
 	return
 }
 
+// versionError tells why a versioned write of the payment matched no row: the
+// payment is missing, or it is at another version.
+func (r *PaymentRepository) versionError(ctx context.Context, id string) (err error) {
+	var exists bool
+	if err = r.db.GetContext(ctx, &exists, "SELECT EXISTS(SELECT 1 FROM payments WHERE id=$1)", id); err != nil {
+		return
+	}
+
+	if exists {
+		return store.ErrorVersionMismatch
+	}
+	return store.ErrorNotFound
+}
+
+// checkVersion fails like a versioned write of the payment would, for writes
+// that have nothing to change.
+func (r *PaymentRepository) checkVersion(ctx context.Context, id string, version int) (err error) {
+	var current int
+	if err = r.db.GetContext(ctx, &current, "SELECT version FROM payments WHERE id=$1", id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = store.ErrorNotFound
+		}
+		return
+	}
+
+	if current != version {
+		return store.ErrorVersionMismatch
+	}
+	return
+}
+
 func (r *PaymentRepository) prepareArgs(data payment.Entity) (sets []string, args []any) {
 	if data.UserID != nil {
 		args = append(args, data.UserID)
@@ -104,35 +153,60 @@ func (r *PaymentRepository) prepareArgs(data payment.Entity) (sets []string, arg
 		sets = append(sets, fmt.Sprintf("status=$%d", len(args)))
 	}
 
+	if data.TransactionID != nil {
+		args = append(args, data.TransactionID)
+		sets = append(sets, fmt.Sprintf("transaction_id=$%d", len(args)))
+	}
+
 	return
 }
 
-func (r *PaymentRepository) Delete(ctx context.Context, id string) (err error) {
+// Delete removes the payment. With a version it only removes the payment at
+// that version.
+func (r *PaymentRepository) Delete(ctx context.Context, id string, version *int) (err error) {
 	query := `
 		DELETE FROM payments
-		WHERE id=$1
+		WHERE id=$1 AND ($2::integer IS NULL OR version=$2)
 		RETURNING id`
 
-	args := []any{id}
+	args := []any{id, version}
 
 	if err = r.db.QueryRowContext(ctx, query, args...).Scan(&id); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			err = store.ErrorNotFound
+			err = r.versionError(ctx, id)
 		}
 	}
 
 	return
 }
 
-func (r *PaymentRepository) Search(ctx context.Context, data payment.Entity) (dest []payment.Entity, err error) {
-	query := "SELECT id, user_id, order_id, amount, status FROM payments WHERE 1=1"
+func (r *PaymentRepository) Search(ctx context.Context, data payment.Entity, page pagination.Request) (dest []payment.Entity, next string, err error) {
+	query := "SELECT id, user_id, order_id, amount, status, transaction_id, version, " + page.SortKey() + " FROM payments WHERE 1=1"
 
 	sets, args := r.prepareArgs(data)
 	if len(sets) > 0 {
 		query += " AND " + strings.Join(sets, " AND ")
 	}
 
-	err = r.db.SelectContext(ctx, &dest, query, args...)
+	return r.selectPage(ctx, page, query, args)
+}
+
+func (r *PaymentRepository) selectPage(ctx context.Context, page pagination.Request, query string, args []any) (dest []payment.Entity, next string, err error) {
+	query, args = page.Keyset(query, args)
+
+	var rows []paymentRow
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return
+	}
+
+	rows, next = pagination.Next(page, rows, func(row paymentRow) (string, string) {
+		return row.SortKey, row.ID
+	})
+
+	dest = make([]payment.Entity, 0, len(rows))
+	for _, row := range rows {
+		dest = append(dest, row.Entity)
+	}
 
 	return
 }
@@ -0,0 +1,140 @@
+package epayment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/yrss1/my-shop/payment/internal/domain/payment"
+	"github.com/yrss1/my-shop/payment/internal/provider/order"
+	"github.com/yrss1/my-shop/payment/pkg/log"
+	"github.com/yrss1/my-shop/payment/pkg/pagination"
+	"go.uber.org/zap"
+)
+
+// CancelOrderPayments compensates every payment of a cancelled order: pending
+// payments are voided, successful ones are refunded and the rest are left as
+// they are. The order has to be cancelled or refunded in the order service. A
+// provider or storage failure is reported for that payment only, so the caller
+// can retry the cancellation later.
+func (s *Service) CancelOrderPayments(ctx context.Context, orderID string) (res []payment.CompensationResponse, err error) {
+	logger := log.LoggerFromContext(ctx).Named("CancelOrderPayments").With(zap.String("order_id", orderID))
+
+	found, err := s.orderClient.GetOrder(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, order.ErrorNotFound) {
+			err = payment.ErrorOrderNotFound
+			return
+		}
+		logger.Error("failed to get order", zap.Error(err))
+		return
+	}
+	if found.Status != "cancelled" && found.Status != "refunded" {
+		err = fmt.Errorf("%w: status is %s", payment.ErrorOrderNotCancelled, found.Status)
+		return
+	}
+
+	data, _, err := s.paymentRepository.Search(ctx, payment.Entity{OrderID: &orderID}, pagination.Request{})
+	if err != nil {
+		logger.Error("failed to search payments", zap.Error(err))
+		return
+	}
+
+	res = make([]payment.CompensationResponse, 0, len(data))
+	for _, object := range data {
+		item := payment.CompensationResponse{
+			PaymentID: object.ID,
+			Status:    *object.Status,
+		}
+
+		var status string
+		switch *object.Status {
+		case "pending":
+			item.Action, status = "void", "cancelled"
+		case "successful":
+			item.Action, status = "refund", "refunded"
+		case "refunding":
+			// an earlier refund may have gone through, refunding again could
+			// pay the money back twice
+			item.Action, item.Result, item.Error = "refund", "failed", payment.ErrorRefundPending.Error()
+			res = append(res, item)
+			continue
+		default:
+			item.Action, item.Result = "none", "skipped"
+			res = append(res, item)
+			continue
+		}
+
+		if item.Action == "refund" {
+			if err := s.startRefund(ctx, object); err != nil {
+				logger.Error("failed to start refund", zap.String("id", object.ID), zap.Error(err))
+				item.Result, item.Error = "failed", err.Error()
+				res = append(res, item)
+				continue
+			}
+		}
+
+		if compensateErr := s.compensate(ctx, object, item.Action); compensateErr != nil {
+			logger.Error("failed to "+item.Action+" payment", zap.String("id", object.ID), zap.Error(compensateErr))
+			item.Result, item.Error = "failed", compensateErr.Error()
+			if item.Action == "refund" {
+				s.abortRefund(ctx, object.ID)
+			}
+			res = append(res, item)
+			continue
+		}
+
+		if updateErr := s.paymentRepository.Update(ctx, object.ID, payment.Entity{Status: &status}); updateErr != nil {
+			logger.Error("failed to update by id", zap.String("id", object.ID), zap.Error(updateErr))
+			item.Result, item.Error = "failed", updateErr.Error()
+			res = append(res, item)
+			continue
+		}
+
+		item.Result, item.Status = "done", status
+		res = append(res, item)
+	}
+
+	return
+}
+
+// startRefund marks a successful payment as refunding before the provider is
+// asked to refund it. Only one cancellation gets to do so for the version of
+// the payment it read, and a payment left refunding is not refunded again.
+func (s *Service) startRefund(ctx context.Context, data payment.Entity) (err error) {
+	status := "refunding"
+	return s.paymentRepository.Update(ctx, data.ID, payment.Entity{Status: &status, Version: data.Version})
+}
+
+// abortRefund puts a payment whose refund the provider refused back to
+// successful, so that the cancellation can be retried. Storing it is best
+// effort; a payment left refunding is reported for a manual check.
+func (s *Service) abortRefund(ctx context.Context, id string) {
+	status := "successful"
+	if err := s.paymentRepository.Update(ctx, id, payment.Entity{Status: &status}); err != nil {
+		log.LoggerFromContext(ctx).Named("CancelOrderPayments").Error("failed to abort refund", zap.String("id", id), zap.Error(err))
+	}
+}
+
+func (s *Service) compensate(ctx context.Context, data payment.Entity, action string) (err error) {
+	if data.TransactionID == nil {
+		// a pending payment the provider never saw has nothing to void
+		if action == "void" {
+			return
+		}
+		return payment.ErrorMissingTransaction
+	}
+
+	token, err := s.GetToken(ctx, nil)
+	if err != nil {
+		return
+	}
+
+	switch action {
+	case "void":
+		err = s.epayClient.Cancel(ctx, token, *data.TransactionID)
+	case "refund":
+		err = s.epayClient.Refund(ctx, token, *data.TransactionID, "")
+	}
+
+	return
+}
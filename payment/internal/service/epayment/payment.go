@@ -5,14 +5,15 @@ import (
 	"errors"
 	"github.com/yrss1/my-shop/payment/internal/domain/payment"
 	"github.com/yrss1/my-shop/payment/pkg/log"
+	"github.com/yrss1/my-shop/payment/pkg/pagination"
 	"github.com/yrss1/my-shop/payment/pkg/store"
 	"go.uber.org/zap"
 )
 
-func (s *Service) ListPayments(ctx context.Context) (res []payment.Response, err error) {
+func (s *Service) ListPayments(ctx context.Context, page pagination.Request) (res []payment.Response, next string, err error) {
 	logger := log.LoggerFromContext(ctx).Named("ListPayments")
 
-	data, err := s.paymentRepository.List(ctx)
+	data, next, err := s.paymentRepository.List(ctx, page)
 	if err != nil {
 		logger.Error("failed to select", zap.Error(err))
 		return
@@ -39,6 +40,10 @@ func (s *Service) CreatePayment(ctx context.Context, req payment.Request) (res p
 		return
 	}
 
+	if data.Status != nil {
+		s.settleStock(ctx, *data.OrderID, *data.Status)
+	}
+
 	res = payment.ParseFromEntity(data)
 
 	return
@@ -58,7 +63,9 @@ func (s *Service) GetPayment(ctx context.Context, id string) (res payment.Respon
 	return
 }
 
-func (s *Service) UpdatePayment(ctx context.Context, id string, req payment.Request) (err error) {
+// UpdatePayment updates the payment at version, or at any version when version
+// is nil.
+func (s *Service) UpdatePayment(ctx context.Context, id string, version *int, req payment.Request) (err error) {
 	logger := log.LoggerFromContext(ctx).Named("UpdatePayment").With(zap.String("id", id))
 
 	data := payment.Entity{
@@ -66,22 +73,34 @@ func (s *Service) UpdatePayment(ctx context.Context, id string, req payment.Requ
 		OrderID: req.OrderID,
 		Amount:  req.Amount,
 		Status:  req.Status,
+		Version: version,
 	}
 
 	err = s.paymentRepository.Update(ctx, id, data)
-	if err != nil && !errors.Is(err, store.ErrorNotFound) {
-		logger.Error("failed to update by id", zap.Error(err))
+	if err != nil {
+		if !errors.Is(err, store.ErrorNotFound) && !errors.Is(err, store.ErrorVersionMismatch) {
+			logger.Error("failed to update by id", zap.Error(err))
+		}
 		return
 	}
 
+	if data.Status != nil {
+		current, err := s.paymentRepository.Get(ctx, id)
+		if err != nil {
+			logger.Error("failed to get by id", zap.Error(err))
+			return err
+		}
+		s.settleStock(ctx, *current.OrderID, *data.Status)
+	}
+
 	return
 }
 
-func (s *Service) DeletePayment(ctx context.Context, id string) (err error) {
+func (s *Service) DeletePayment(ctx context.Context, id string, version *int) (err error) {
 	logger := log.LoggerFromContext(ctx).Named("DeletePayment").With(zap.String("id", id))
 
-	err = s.paymentRepository.Delete(ctx, id)
-	if err != nil && !errors.Is(err, store.ErrorNotFound) {
+	err = s.paymentRepository.Delete(ctx, id, version)
+	if err != nil && !errors.Is(err, store.ErrorNotFound) && !errors.Is(err, store.ErrorVersionMismatch) {
 		logger.Error("failed to delete by id", zap.Error(err))
 		return
 	}
@@ -89,7 +108,7 @@ func (s *Service) DeletePayment(ctx context.Context, id string) (err error) {
 	return
 }
 
-func (s *Service) SearchPayment(ctx context.Context, req payment.Request) (res []payment.Response, err error) {
+func (s *Service) SearchPayment(ctx context.Context, req payment.Request, page pagination.Request) (res []payment.Response, next string, err error) {
 	logger := log.LoggerFromContext(ctx).Named("SearchUser")
 
 	if req.UserID != nil {
@@ -107,7 +126,7 @@ func (s *Service) SearchPayment(ctx context.Context, req payment.Request) (res [
 		OrderID: req.OrderID,
 		Status:  req.Status,
 	}
-	data, err := s.paymentRepository.Search(ctx, searchData)
+	data, next, err := s.paymentRepository.Search(ctx, searchData, page)
 	if err != nil {
 		logger.Error("failed to search payments", zap.Error(err))
 		return
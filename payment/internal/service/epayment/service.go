@@ -3,6 +3,8 @@ package epayment
 import (
 	"github.com/yrss1/my-shop/payment/internal/domain/payment"
 	"github.com/yrss1/my-shop/payment/internal/provider/epay"
+	"github.com/yrss1/my-shop/payment/internal/provider/order"
+	"github.com/yrss1/my-shop/payment/internal/provider/product"
 )
 
 type Configuration func(s *Service) error
@@ -10,6 +12,8 @@ type Configuration func(s *Service) error
 type Service struct {
 	paymentRepository payment.Repository
 	epayClient        *epay.Client
+	productClient     *product.Client
+	orderClient       *order.Client
 }
 
 func New(configs ...Configuration) (s *Service, err error) {
@@ -37,3 +41,17 @@ func WithEpayClient(epayClient *epay.Client) Configuration {
 		return nil
 	}
 }
+
+func WithProductClient(productClient *product.Client) Configuration {
+	return func(s *Service) error {
+		s.productClient = productClient
+		return nil
+	}
+}
+
+func WithOrderClient(orderClient *order.Client) Configuration {
+	return func(s *Service) error {
+		s.orderClient = orderClient
+		return nil
+	}
+}
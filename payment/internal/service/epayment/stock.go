@@ -0,0 +1,29 @@
+package epayment
+
+import (
+	"context"
+	"errors"
+	"github.com/yrss1/my-shop/payment/internal/provider/product"
+	"github.com/yrss1/my-shop/payment/pkg/log"
+	"go.uber.org/zap"
+)
+
+// settleStock commits the order reservation once the payment succeeded and
+// releases it when the payment failed. Pending payments keep it reserved.
+func (s *Service) settleStock(ctx context.Context, orderID, status string) {
+	logger := log.LoggerFromContext(ctx).Named("settleStock").With(zap.String("order_id", orderID), zap.String("status", status))
+
+	var err error
+	switch status {
+	case "successful":
+		err = s.productClient.CommitStock(ctx, orderID)
+	case "unsuccessful":
+		err = s.productClient.ReleaseStock(ctx, orderID)
+	default:
+		return
+	}
+
+	if err != nil && !errors.Is(err, product.ErrorNotFound) {
+		logger.Error("failed to settle stock", zap.Error(err))
+	}
+}
@@ -13,6 +13,7 @@ func New() *gin.Engine {
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "PUT", "POST", "DELETE"},
 		AllowHeaders:     []string{"*"},
+		ExposeHeaders:    []string{"ETag"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
@@ -0,0 +1,19 @@
+DO $$
+    BEGIN
+        -- COLUMNS --
+        ALTER TABLE products ADD COLUMN IF NOT EXISTS reserved INTEGER NOT NULL DEFAULT 0;
+        ALTER TABLE products ADD CONSTRAINT products_reserved_check CHECK (reserved >= 0 AND reserved <= quantity);
+
+        -- TABLES --
+        CREATE TABLE IF NOT EXISTS stock_reservations (
+                                                          created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                                          updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                                          order_id VARCHAR(64) NOT NULL,
+                                                          product_id UUID NOT NULL REFERENCES products(id),
+                                                          quantity INTEGER NOT NULL CHECK (quantity > 0),
+                                                          status VARCHAR(20) NOT NULL,
+                                                          PRIMARY KEY (order_id, product_id)
+        );
+
+        COMMIT;
+    END $$;
@@ -0,0 +1,5 @@
+BEGIN;
+DROP TABLE IF EXISTS stock_reservations CASCADE;
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_reserved_check;
+ALTER TABLE products DROP COLUMN IF EXISTS reserved;
+END;
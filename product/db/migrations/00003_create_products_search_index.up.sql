@@ -0,0 +1,20 @@
+DO $$
+    BEGIN
+        -- EXTENSIONS --
+        CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+        -- COLUMNS --
+        ALTER TABLE products ADD COLUMN IF NOT EXISTS search_vector tsvector
+            GENERATED ALWAYS AS (
+                setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+                setweight(to_tsvector('english', coalesce(description, '')), 'B')
+            ) STORED;
+
+        -- INDEXES --
+        CREATE INDEX IF NOT EXISTS products_search_vector_idx ON products USING GIN (search_vector);
+        CREATE INDEX IF NOT EXISTS products_name_trgm_idx ON products USING GIN (name gin_trgm_ops);
+        CREATE INDEX IF NOT EXISTS products_category_idx ON products (category);
+        CREATE INDEX IF NOT EXISTS products_price_idx ON products (price);
+
+        COMMIT;
+    END $$;
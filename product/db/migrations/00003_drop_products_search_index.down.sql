@@ -0,0 +1,7 @@
+BEGIN;
+DROP INDEX IF EXISTS products_price_idx;
+DROP INDEX IF EXISTS products_category_idx;
+DROP INDEX IF EXISTS products_name_trgm_idx;
+DROP INDEX IF EXISTS products_search_vector_idx;
+ALTER TABLE products DROP COLUMN IF EXISTS search_vector;
+END;
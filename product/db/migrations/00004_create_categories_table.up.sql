@@ -0,0 +1,46 @@
+DO $$
+    BEGIN
+        -- TABLES --
+        CREATE TABLE IF NOT EXISTS categories (
+                                                  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                                  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                                  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+                                                  parent_id UUID REFERENCES categories(id) ON DELETE RESTRICT,
+                                                  name VARCHAR(100) NOT NULL,
+                                                  slug VARCHAR(120) UNIQUE NOT NULL,
+                                                  CHECK (parent_id <> id)
+        );
+
+        CREATE INDEX IF NOT EXISTS categories_parent_id_idx ON categories (parent_id);
+
+        -- DATA --
+        -- every distinct free-text category becomes a root category
+        INSERT INTO categories (name, slug)
+        SELECT min(trim(category)), slug
+        FROM (
+                 SELECT category,
+                        coalesce(nullif(trim(both '-' from regexp_replace(lower(trim(category)), '[^[:alnum:]]+', '-', 'g')), ''),
+                                 'category-' || left(md5(trim(category)), 8)) AS slug
+                 FROM products
+                 WHERE nullif(trim(category), '') IS NOT NULL
+             ) AS source
+        GROUP BY slug
+        ON CONFLICT (slug) DO NOTHING;
+
+        -- COLUMNS --
+        ALTER TABLE products ADD COLUMN IF NOT EXISTS category_id UUID REFERENCES categories(id) ON DELETE RESTRICT;
+
+        UPDATE products p
+        SET category_id = c.id
+        FROM categories c
+        WHERE c.slug = coalesce(nullif(trim(both '-' from regexp_replace(lower(trim(p.category)), '[^[:alnum:]]+', '-', 'g')), ''),
+                                'category-' || left(md5(trim(p.category)), 8));
+
+        DROP INDEX IF EXISTS products_category_idx;
+        ALTER TABLE products DROP COLUMN IF EXISTS category;
+
+        -- INDEXES --
+        CREATE INDEX IF NOT EXISTS products_category_id_idx ON products (category_id);
+
+        COMMIT;
+    END $$;
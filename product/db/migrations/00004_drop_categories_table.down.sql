@@ -0,0 +1,8 @@
+BEGIN;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS category VARCHAR(100);
+UPDATE products p SET category = c.name FROM categories c WHERE c.id = p.category_id;
+CREATE INDEX IF NOT EXISTS products_category_idx ON products (category);
+DROP INDEX IF EXISTS products_category_id_idx;
+ALTER TABLE products DROP COLUMN IF EXISTS category_id;
+DROP TABLE IF EXISTS categories;
+END;
@@ -0,0 +1,29 @@
+DO $$
+    BEGIN
+        -- TABLES --
+        CREATE TABLE IF NOT EXISTS product_variants (
+                                                        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                                        updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                                        id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+                                                        product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+                                                        sku VARCHAR(64) UNIQUE NOT NULL,
+                                                        attributes JSONB NOT NULL DEFAULT '{}',
+                                                        price DECIMAL(10, 2) CHECK (price >= 0),
+                                                        quantity INTEGER NOT NULL DEFAULT 0,
+                                                        reserved INTEGER NOT NULL DEFAULT 0,
+                                                        CHECK (reserved >= 0 AND reserved <= quantity)
+        );
+
+        CREATE INDEX IF NOT EXISTS product_variants_product_id_idx ON product_variants (product_id);
+
+        -- COLUMNS --
+        -- a reservation line holds stock of a product or of one of its variants
+        ALTER TABLE stock_reservations ADD COLUMN IF NOT EXISTS variant_id UUID REFERENCES product_variants(id) ON DELETE RESTRICT;
+        ALTER TABLE stock_reservations DROP CONSTRAINT IF EXISTS stock_reservations_pkey;
+
+        -- INDEXES --
+        CREATE UNIQUE INDEX IF NOT EXISTS stock_reservations_line_idx
+            ON stock_reservations (order_id, product_id, COALESCE(variant_id, '00000000-0000-0000-0000-000000000000'));
+
+        COMMIT;
+    END $$;
@@ -0,0 +1,7 @@
+BEGIN;
+DELETE FROM stock_reservations WHERE variant_id IS NOT NULL;
+DROP INDEX IF EXISTS stock_reservations_line_idx;
+ALTER TABLE stock_reservations DROP COLUMN IF EXISTS variant_id;
+ALTER TABLE stock_reservations ADD PRIMARY KEY (order_id, product_id);
+DROP TABLE IF EXISTS product_variants;
+END;
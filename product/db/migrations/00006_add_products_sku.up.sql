@@ -0,0 +1,8 @@
+DO $$
+    BEGIN
+        -- COLUMNS --
+        -- imports upsert products by sku, or by name when a row has none
+        ALTER TABLE products ADD COLUMN IF NOT EXISTS sku VARCHAR(64) UNIQUE;
+
+        COMMIT;
+    END $$;
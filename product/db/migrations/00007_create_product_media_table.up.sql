@@ -0,0 +1,20 @@
+DO $$
+    BEGIN
+        -- TABLES --
+        CREATE TABLE IF NOT EXISTS product_media (
+                                                     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                                     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+                                                     product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+                                                     position INTEGER NOT NULL CHECK (position >= 0),
+                                                     content_type VARCHAR(50) NOT NULL,
+                                                     size BIGINT NOT NULL,
+                                                     width INTEGER NOT NULL,
+                                                     height INTEGER NOT NULL,
+                                                     key VARCHAR(255) NOT NULL,
+                                                     thumbnail_key VARCHAR(255) NOT NULL,
+                                                     -- deferred so that reordering can swap positions
+                                                     CONSTRAINT product_media_position_key UNIQUE (product_id, position) DEFERRABLE INITIALLY DEFERRED
+        );
+
+        COMMIT;
+    END $$;
@@ -0,0 +1,39 @@
+DO $$
+    BEGIN
+        -- TABLES --
+        CREATE TABLE IF NOT EXISTS product_price_schedules (
+                                                               created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                                               updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                                               id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+                                                               product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+                                                               price DECIMAL(10, 2) NOT NULL CHECK (price >= 0),
+                                                               starts_at TIMESTAMPTZ NOT NULL,
+                                                               ends_at TIMESTAMPTZ CHECK (ends_at > starts_at),
+                                                               -- the price a sale replaced, restored when it ends
+                                                               previous_price DECIMAL(10, 2),
+                                                               status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        );
+
+        CREATE TABLE IF NOT EXISTS product_price_history (
+                                                             created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                                             id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+                                                             product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+                                                             old_price DECIMAL(10, 2),
+                                                             price DECIMAL(10, 2) NOT NULL,
+                                                             source VARCHAR(20) NOT NULL,
+                                                             schedule_id UUID REFERENCES product_price_schedules(id) ON DELETE SET NULL
+        );
+
+        -- INDEXES --
+        CREATE INDEX IF NOT EXISTS product_price_schedules_due_idx ON product_price_schedules (status, starts_at);
+        CREATE INDEX IF NOT EXISTS product_price_schedules_product_id_idx ON product_price_schedules (product_id);
+        CREATE INDEX IF NOT EXISTS product_price_history_product_id_idx ON product_price_history (product_id, created_at);
+
+        -- DATA --
+        -- the current prices start the history of existing products
+        INSERT INTO product_price_history (product_id, price, source, created_at)
+        SELECT id, price, 'manual', created_at
+        FROM products;
+
+        COMMIT;
+    END $$;
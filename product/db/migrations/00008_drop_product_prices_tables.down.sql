@@ -0,0 +1,4 @@
+BEGIN;
+DROP TABLE IF EXISTS product_price_history;
+DROP TABLE IF EXISTS product_price_schedules;
+END;
@@ -0,0 +1,8 @@
+DO $$
+    BEGIN
+        -- COLUMNS --
+        -- deleted products are kept for their orders and can be restored
+        ALTER TABLE products ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+        COMMIT;
+    END $$;
@@ -0,0 +1,14 @@
+DO $$
+    BEGIN
+        -- CONSTRAINTS --
+        -- names and SKUs only have to be unique among products that are not
+        -- deleted, so that a deleted product does not block a new one
+        ALTER TABLE products DROP CONSTRAINT IF EXISTS products_name_key;
+        ALTER TABLE products DROP CONSTRAINT IF EXISTS products_sku_key;
+
+        -- INDEXES --
+        CREATE UNIQUE INDEX IF NOT EXISTS products_name_live_idx ON products (name) WHERE deleted_at IS NULL;
+        CREATE UNIQUE INDEX IF NOT EXISTS products_sku_live_idx ON products (sku) WHERE deleted_at IS NULL;
+
+        COMMIT;
+    END $$;
@@ -0,0 +1,6 @@
+BEGIN;
+DROP INDEX IF EXISTS products_sku_live_idx;
+DROP INDEX IF EXISTS products_name_live_idx;
+ALTER TABLE products ADD CONSTRAINT products_sku_key UNIQUE (sku);
+ALTER TABLE products ADD CONSTRAINT products_name_key UNIQUE (name);
+END;
@@ -8,6 +8,7 @@ import (
 	"github.com/yrss1/my-shop/product/internal/handler"
 	"github.com/yrss1/my-shop/product/internal/repository"
 	"github.com/yrss1/my-shop/product/internal/service/productService"
+	"github.com/yrss1/my-shop/product/internal/storage/local"
 	"github.com/yrss1/my-shop/product/pkg/log"
 	"github.com/yrss1/my-shop/product/pkg/server"
 	"go.uber.org/zap"
@@ -32,14 +33,31 @@ func Run() {
 		return
 	}
 
+	mediaStorage, err := local.New(configs.MEDIA.Dir, configs.MEDIA.URL)
+	if err != nil {
+		logger.Error("ERR_INIT_MEDIA_STORAGE", zap.Error(err))
+		return
+	}
+
 	productService, err := productService.New(
+		productService.WithUnitOfWork(repositories.UnitOfWork),
 		productService.WithProductRepository(repositories.Product),
+		productService.WithCategoryRepository(repositories.Category),
+		productService.WithVariantRepository(repositories.Variant),
+		productService.WithReservationRepository(repositories.Reservation),
+		productService.WithMediaRepository(repositories.Media),
+		productService.WithPriceRepository(repositories.Price),
+		productService.WithMediaStorage(mediaStorage, configs.MEDIA.MaxSize, configs.MEDIA.ThumbnailSize),
 	)
 	if err != nil {
 		logger.Error("ERR_INIT_PRODUCT_SERVICE", zap.Error(err))
 		return
 	}
 
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	defer stopScheduler()
+	go productService.RunPriceScheduler(schedulerCtx, configs.APP.SchedulerInterval)
+
 	//conn, err := grpc.NewClient("localhost:9004", grpc.WithTransportCredentials(insecure.NewCredentials()))
 	//if err != nil {
 	//	panic(err)
@@ -86,6 +104,7 @@ func Run() {
 	}
 
 	fmt.Println("running cleanup tasks...")
+	stopScheduler()
 
 	fmt.Println("server was successful shutdown.")
 }
@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"path"
 	"path/filepath"
 	"time"
 
@@ -14,6 +15,12 @@ const (
 	defaultAppPort    = "8080"
 	defaultAppPath    = "/"
 	defaultAppTimeout = 60 * time.Second
+
+	defaultSchedulerInterval = time.Minute
+
+	defaultMediaDir           = "media"
+	defaultMediaMaxSize       = 10 << 20
+	defaultMediaThumbnailSize = 320
 )
 
 type (
@@ -21,6 +28,7 @@ type (
 		APP      AppConfig
 		POSTGRES StoreConfig
 		EPAY     CredentialsConfig
+		MEDIA    MediaConfig
 	}
 
 	AppConfig struct {
@@ -29,11 +37,23 @@ type (
 		Path     string
 		UserPort string
 		Timeout  time.Duration
+
+		// SchedulerInterval is how often scheduled prices are applied.
+		SchedulerInterval time.Duration
 	}
 
 	StoreConfig struct {
 		DSN string
 	}
+
+	// MediaConfig sets where product images are kept. URL is the public base
+	// URL of Dir and defaults to the media path of the service.
+	MediaConfig struct {
+		Dir           string
+		URL           string
+		MaxSize       int64
+		ThumbnailSize int
+	}
 	CredentialsConfig struct {
 		URL      string
 		Login    string
@@ -56,6 +76,8 @@ func New() (cfg Configs, err error) {
 		Port:    defaultAppPort,
 		Path:    defaultAppPath,
 		Timeout: defaultAppTimeout,
+
+		SchedulerInterval: defaultSchedulerInterval,
 	}
 
 	if err = envconfig.Process("APP", &cfg.APP); err != nil {
@@ -70,5 +92,19 @@ func New() (cfg Configs, err error) {
 		return
 	}
 
+	cfg.MEDIA = MediaConfig{
+		Dir:           defaultMediaDir,
+		MaxSize:       defaultMediaMaxSize,
+		ThumbnailSize: defaultMediaThumbnailSize,
+	}
+
+	if err = envconfig.Process("MEDIA", &cfg.MEDIA); err != nil {
+		return
+	}
+
+	if cfg.MEDIA.URL == "" {
+		cfg.MEDIA.URL = path.Join(cfg.APP.Path, "media")
+	}
+
 	return
 }
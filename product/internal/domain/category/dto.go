@@ -0,0 +1,93 @@
+package category
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// SortFields are the fields category lists can be sorted by.
+var SortFields = []string{"name", "id", "slug", "created_at"}
+
+type Request struct {
+	ID       string  `json:"id"`
+	Name     *string `json:"name"`
+	Slug     *string `json:"slug"`
+	ParentID *string `json:"parent_id"`
+}
+
+func (s *Request) Validate() error {
+	if s.Name == nil || strings.TrimSpace(*s.Name) == "" {
+		return errors.New("name: cannot be blank")
+	}
+
+	if s.Slug == nil {
+		slug := Slugify(*s.Name)
+		s.Slug = &slug
+	}
+
+	if s.ParentID != nil && *s.ParentID == "" {
+		s.ParentID = nil
+	}
+
+	return s.validateSlug()
+}
+
+func (s *Request) IsEmpty() error {
+	if s.Name == nil && s.Slug == nil && s.ParentID == nil {
+		return errors.New("data cannot be blank")
+	}
+
+	if s.Name != nil && strings.TrimSpace(*s.Name) == "" {
+		return errors.New("name: cannot be blank")
+	}
+
+	if s.Slug != nil {
+		return s.validateSlug()
+	}
+
+	return nil
+}
+
+func (s *Request) validateSlug() error {
+	if *s.Slug == "" || *s.Slug != Slugify(*s.Slug) {
+		return errors.New("slug: must be lowercase words separated by dashes")
+	}
+
+	return nil
+}
+
+// Slugify lowercases name and joins its words with dashes.
+func Slugify(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, "-")
+}
+
+type Response struct {
+	ID       string `json:"id"`
+	ParentID string `json:"parent_id,omitempty"`
+	Name     string `json:"name"`
+	Slug     string `json:"slug"`
+}
+
+func ParseFromEntity(data Entity) (res Response) {
+	res = Response{
+		ID:   data.ID,
+		Name: *data.Name,
+		Slug: *data.Slug,
+	}
+	if data.ParentID != nil {
+		res.ParentID = *data.ParentID
+	}
+	return
+}
+
+func ParseFromEntities(data []Entity) (res []Response) {
+	res = make([]Response, 0)
+	for _, object := range data {
+		res = append(res, ParseFromEntity(object))
+	}
+	return
+}
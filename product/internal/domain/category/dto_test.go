@@ -0,0 +1,30 @@
+package category
+
+import "testing"
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"single word", "Electronics", "electronics"},
+		{"words", "Home Appliances", "home-appliances"},
+		{"punctuation", "Phones & Tablets!", "phones-tablets"},
+		{"surrounding separators", "  --Audio--  ", "audio"},
+		{"repeated separators", "TV___and///Video", "tv-and-video"},
+		{"digits", "4K TVs", "4k-tvs"},
+		{"unicode letters", "Küche Geräte", "küche-geräte"},
+		{"already a slug", "home-appliances", "home-appliances"},
+		{"no words", "&&&", ""},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Slugify(tt.in); got != tt.want {
+				t.Errorf("Slugify(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,8 @@
+package category
+
+type Entity struct {
+	ID       string  `db:"id"`
+	ParentID *string `db:"parent_id"`
+	Name     *string `db:"name"`
+	Slug     *string `db:"slug"`
+}
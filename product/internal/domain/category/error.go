@@ -0,0 +1,12 @@
+package category
+
+import (
+	"errors"
+)
+
+var (
+	ErrorDuplicateSlug = errors.New("slug: already taken")
+	ErrorUnknownParent = errors.New("parent_id: category not found")
+	ErrorCycle         = errors.New("parent_id: category cannot be moved under itself")
+	ErrorInUse         = errors.New("category still has subcategories or products")
+)
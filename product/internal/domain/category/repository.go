@@ -0,0 +1,15 @@
+package category
+
+import (
+	"context"
+	"github.com/yrss1/my-shop/product/pkg/pagination"
+)
+
+type Repository interface {
+	List(ctx context.Context, page pagination.Request) (dest []Entity, next string, err error)
+	Add(ctx context.Context, data Entity) (id string, err error)
+	Get(ctx context.Context, id string) (dest Entity, err error)
+	Update(ctx context.Context, id string, data Entity) (err error)
+	Delete(ctx context.Context, id string) (err error)
+	IsDescendant(ctx context.Context, id, ancestorID string) (ok bool, err error)
+}
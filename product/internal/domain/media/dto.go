@@ -0,0 +1,60 @@
+package media
+
+import (
+	"errors"
+)
+
+// OrderRequest lists the image ids of a product in their new order.
+type OrderRequest struct {
+	IDs []string `json:"ids"`
+}
+
+func (s *OrderRequest) Validate() error {
+	if len(s.IDs) == 0 {
+		return errors.New("ids: cannot be empty")
+	}
+
+	seen := make(map[string]bool, len(s.IDs))
+	for _, id := range s.IDs {
+		if seen[id] {
+			return errors.New("ids: duplicate id " + id)
+		}
+		seen[id] = true
+	}
+
+	return nil
+}
+
+type Response struct {
+	ID           string `json:"id"`
+	Position     int    `json:"position"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	ContentType  string `json:"content_type"`
+	Size         int64  `json:"size"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+}
+
+// ParseFromEntity resolves the storage keys to URLs with url.
+func ParseFromEntity(data Entity, url func(key string) string) (res Response) {
+	res = Response{
+		ID:           data.ID,
+		Position:     data.Position,
+		URL:          url(data.Key),
+		ThumbnailURL: url(data.ThumbnailKey),
+		ContentType:  data.ContentType,
+		Size:         data.Size,
+		Width:        data.Width,
+		Height:       data.Height,
+	}
+	return
+}
+
+func ParseFromEntities(data []Entity, url func(key string) string) (res []Response) {
+	res = make([]Response, 0)
+	for _, object := range data {
+		res = append(res, ParseFromEntity(object, url))
+	}
+	return
+}
@@ -0,0 +1,16 @@
+package media
+
+// Entity is an image attached to a product. Key and ThumbnailKey locate the
+// original and its thumbnail in the blob storage; Position orders the images
+// of a product starting at 0.
+type Entity struct {
+	ID           string `db:"id"`
+	ProductID    string `db:"product_id"`
+	Position     int    `db:"position"`
+	ContentType  string `db:"content_type"`
+	Size         int64  `db:"size"`
+	Width        int    `db:"width"`
+	Height       int    `db:"height"`
+	Key          string `db:"key"`
+	ThumbnailKey string `db:"thumbnail_key"`
+}
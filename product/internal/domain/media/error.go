@@ -0,0 +1,12 @@
+package media
+
+import (
+	"errors"
+)
+
+var (
+	ErrorUnsupportedType = errors.New("file: content type must be image/jpeg, image/png or image/gif")
+	ErrorTooLarge        = errors.New("file: too large")
+	ErrorInvalidImage    = errors.New("file: cannot decode image")
+	ErrorInvalidOrder    = errors.New("ids: must list every image of the product exactly once")
+)
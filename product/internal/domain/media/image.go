@@ -0,0 +1,66 @@
+package media
+
+import (
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// Extensions are the accepted image content types and the file extension
+// their files are stored with. Thumbnails are always JPEG.
+var Extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// Thumbnail scales img down so that its longer side is at most size pixels.
+// Every target pixel is the average of the source pixels it covers, and
+// transparent areas are flattened onto white. Smaller images keep their size.
+func Thumbnail(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	sw, sh := bounds.Dx(), bounds.Dy()
+
+	dw, dh := sw, sh
+	if sw > size || sh > size {
+		if sw >= sh {
+			dw, dh = size, max(1, sh*size/sw)
+		} else {
+			dw, dh = max(1, sw*size/sh), size
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for dy := 0; dy < dh; dy++ {
+		y0 := bounds.Min.Y + dy*sh/dh
+		y1 := max(y0+1, bounds.Min.Y+(dy+1)*sh/dh)
+
+		for dx := 0; dx < dw; dx++ {
+			x0 := bounds.Min.X + dx*sw/dw
+			x1 := max(x0+1, bounds.Min.X+(dx+1)*sw/dw)
+
+			var r, g, b, n uint64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					cr, cg, cb, ca := img.At(x, y).RGBA()
+					// the values are alpha premultiplied, add the white behind them
+					r += uint64(cr + 0xffff - ca)
+					g += uint64(cg + 0xffff - ca)
+					b += uint64(cb + 0xffff - ca)
+					n++
+				}
+			}
+
+			dst.SetRGBA(dx, dy, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(b / n >> 8),
+				A: 0xff,
+			})
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,85 @@
+package media
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestThumbnail(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   image.Rectangle
+		size  int
+		wantW int
+		wantH int
+	}{
+		{"landscape", image.Rect(0, 0, 400, 200), 100, 100, 50},
+		{"portrait", image.Rect(0, 0, 300, 600), 100, 50, 100},
+		{"square", image.Rect(0, 0, 250, 250), 100, 100, 100},
+		{"smaller keeps its size", image.Rect(0, 0, 80, 40), 100, 80, 40},
+		{"exact size", image.Rect(0, 0, 100, 60), 100, 100, 60},
+		{"thin keeps a pixel", image.Rect(0, 0, 1000, 2), 100, 100, 1},
+		{"offset bounds", image.Rect(50, 50, 250, 150), 100, 100, 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := image.NewRGBA(tt.src)
+			got := Thumbnail(src, tt.size).Bounds()
+
+			if got.Min != (image.Point{}) || got.Dx() != tt.wantW || got.Dy() != tt.wantH {
+				t.Errorf("Thumbnail() bounds = %v, want %dx%d at the origin", got, tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestThumbnailColors(t *testing.T) {
+	tests := []struct {
+		name string
+		fill color.Color
+		want color.RGBA
+	}{
+		{"opaque", color.RGBA{R: 200, G: 100, B: 50, A: 0xff}, color.RGBA{R: 200, G: 100, B: 50, A: 0xff}},
+		{"transparent becomes white", color.RGBA{}, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}},
+		{"half transparent black becomes grey", color.RGBA{A: 0x80}, color.RGBA{R: 0x7f, G: 0x7f, B: 0x7f, A: 0xff}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := image.NewRGBA(image.Rect(0, 0, 40, 20))
+			for y := 0; y < 20; y++ {
+				for x := 0; x < 40; x++ {
+					src.Set(x, y, tt.fill)
+				}
+			}
+
+			dst := Thumbnail(src, 10).(*image.RGBA)
+			if got := dst.RGBAAt(3, 2); got != tt.want {
+				t.Errorf("Thumbnail() pixel = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestThumbnailAverages(t *testing.T) {
+	// a black and white checkerboard averages to grey
+	src := image.NewGray(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			if (x+y)%2 == 0 {
+				src.SetGray(x, y, color.Gray{Y: 0xff})
+			}
+		}
+	}
+
+	dst := Thumbnail(src, 2).(*image.RGBA)
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 2; x++ {
+			if got := dst.RGBAAt(x, y); got != (color.RGBA{R: 0x7f, G: 0x7f, B: 0x7f, A: 0xff}) {
+				t.Errorf("Thumbnail() pixel (%d, %d) = %v, want grey", x, y, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,10 @@
+package media
+
+import "context"
+
+type Repository interface {
+	List(ctx context.Context, productIDs ...string) (dest []Entity, err error)
+	Add(ctx context.Context, data Entity) (dest Entity, err error)
+	Delete(ctx context.Context, productID, id string) (dest Entity, err error)
+	Reorder(ctx context.Context, productID string, ids []string) (err error)
+}
@@ -0,0 +1,13 @@
+package media
+
+import (
+	"context"
+	"io"
+)
+
+// Storage keeps the image files. Keys are slash separated paths.
+type Storage interface {
+	Put(ctx context.Context, key, contentType string, r io.Reader) (err error)
+	Delete(ctx context.Context, key string) (err error)
+	URL(key string) string
+}
@@ -0,0 +1,95 @@
+package price
+
+import (
+	"errors"
+	"time"
+)
+
+// SortFields are the fields the price history can be sorted by.
+var SortFields = []string{"created_at", "id"}
+
+// DefaultSort lists the latest price changes first.
+const DefaultSort = "-created_at"
+
+type ScheduleRequest struct {
+	Price    *float64   `json:"price"`
+	StartsAt *time.Time `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at"`
+}
+
+func (s *ScheduleRequest) Validate(now time.Time) error {
+	if s.Price == nil {
+		return errors.New("price: cannot be blank")
+	}
+
+	if *s.Price < 0 {
+		return errors.New("price: cannot be negative")
+	}
+
+	if s.StartsAt == nil {
+		return errors.New("starts_at: cannot be blank")
+	}
+
+	if s.StartsAt.Before(now) {
+		return errors.New("starts_at: must be in the future")
+	}
+
+	if s.EndsAt != nil && !s.EndsAt.After(*s.StartsAt) {
+		return errors.New("ends_at: must be after starts_at")
+	}
+
+	return nil
+}
+
+type HistoryResponse struct {
+	OldPrice   *float64  `json:"old_price,omitempty"`
+	Price      float64   `json:"price"`
+	Source     string    `json:"source"`
+	ScheduleID *string   `json:"schedule_id,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func ParseFromHistory(data []History) (res []HistoryResponse) {
+	res = make([]HistoryResponse, 0)
+	for _, object := range data {
+		res = append(res, HistoryResponse{
+			OldPrice:   object.OldPrice,
+			Price:      object.Price,
+			Source:     object.Source,
+			ScheduleID: object.ScheduleID,
+			CreatedAt:  object.CreatedAt,
+		})
+	}
+	return
+}
+
+type ScheduleResponse struct {
+	ID            string     `json:"id"`
+	ProductID     string     `json:"product_id"`
+	Price         float64    `json:"price"`
+	StartsAt      time.Time  `json:"starts_at"`
+	EndsAt        *time.Time `json:"ends_at,omitempty"`
+	PreviousPrice *float64   `json:"previous_price,omitempty"`
+	Status        string     `json:"status"`
+}
+
+func ParseFromSchedule(data Schedule) (res ScheduleResponse) {
+	res = ScheduleResponse{
+		ID:            data.ID,
+		ProductID:     data.ProductID,
+		Price:         data.Price,
+		StartsAt:      data.StartsAt,
+		EndsAt:        data.EndsAt,
+		PreviousPrice: data.PreviousPrice,
+		Status:        data.Status,
+	}
+	return
+}
+
+func ParseFromSchedules(data []Schedule) (res []ScheduleResponse) {
+	res = make([]ScheduleResponse, 0)
+	for _, object := range data {
+		res = append(res, ParseFromSchedule(object))
+	}
+	return
+}
@@ -0,0 +1,44 @@
+package price
+
+import "time"
+
+// Sources of a price change.
+const (
+	SourceManual         = "manual"
+	SourceSchedule       = "schedule"
+	SourceScheduleEnd    = "schedule_end"
+	SourceScheduleCancel = "schedule_cancel"
+)
+
+// Statuses of a scheduled price. A schedule is pending until it starts, active
+// while its price applies and then finished; schedules without an end finish
+// as soon as they start.
+const (
+	StatusPending   = "pending"
+	StatusActive    = "active"
+	StatusFinished  = "finished"
+	StatusCancelled = "cancelled"
+)
+
+// History is one recorded change of a product price.
+type History struct {
+	ID         string    `db:"id"`
+	ProductID  string    `db:"product_id"`
+	OldPrice   *float64  `db:"old_price"`
+	Price      float64   `db:"price"`
+	Source     string    `db:"source"`
+	ScheduleID *string   `db:"schedule_id"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+// Schedule sets the price of a product from StartsAt on. With EndsAt the
+// price is a sale: PreviousPrice is restored when it ends.
+type Schedule struct {
+	ID            string     `db:"id"`
+	ProductID     string     `db:"product_id"`
+	Price         float64    `db:"price"`
+	StartsAt      time.Time  `db:"starts_at"`
+	EndsAt        *time.Time `db:"ends_at"`
+	PreviousPrice *float64   `db:"previous_price"`
+	Status        string     `db:"status"`
+}
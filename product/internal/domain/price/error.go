@@ -0,0 +1,10 @@
+package price
+
+import (
+	"errors"
+)
+
+var (
+	ErrorOverlap        = errors.New("schedule overlaps another pending or active schedule of the product")
+	ErrorNotCancellable = errors.New("schedule has already finished")
+)
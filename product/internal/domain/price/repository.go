@@ -0,0 +1,16 @@
+package price
+
+import (
+	"context"
+	"github.com/yrss1/my-shop/product/pkg/pagination"
+	"time"
+)
+
+type Repository interface {
+	ListHistory(ctx context.Context, productID string, page pagination.Request) (dest []History, next string, err error)
+	ListSchedules(ctx context.Context, productID string) (dest []Schedule, err error)
+	AddSchedule(ctx context.Context, data Schedule) (dest Schedule, err error)
+	CancelSchedule(ctx context.Context, productID, id string) (dest Schedule, err error)
+	StartDue(ctx context.Context, now time.Time) (dest []Schedule, err error)
+	EndDue(ctx context.Context, now time.Time) (dest []Schedule, err error)
+}
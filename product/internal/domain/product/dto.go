@@ -2,14 +2,21 @@ package product
 
 import (
 	"errors"
+	"github.com/yrss1/my-shop/product/internal/domain/media"
+	"github.com/yrss1/my-shop/product/internal/domain/variant"
+	"time"
 )
 
+// SortFields are the fields product lists can be sorted by.
+var SortFields = []string{"id", "created_at", "name", "price", "quantity"}
+
 type Request struct {
 	ID          string   `json:"id"`
+	SKU         *string  `json:"sku"`
 	Name        *string  `json:"name"`
 	Description *string  `json:"description"`
 	Price       *float64 `json:"price"`
-	Category    *string  `json:"category"`
+	CategoryID  *string  `json:"category_id"`
 	Quantity    *int     `json:"quantity"`
 }
 
@@ -26,25 +33,95 @@ func (s *Request) Validate() error {
 		return errors.New("quantity: cannot be blank")
 	}
 
+	if s.SKU != nil && *s.SKU == "" {
+		return errors.New("sku: cannot be blank")
+	}
+
 	return nil
 }
 
 func (s *Request) IsEmpty() error {
 	if s.Name == nil && s.Description == nil &&
-		s.Price == nil && s.Category == nil &&
-		s.Quantity == nil {
+		s.Price == nil && s.CategoryID == nil &&
+		s.Quantity == nil && s.SKU == nil {
 		return errors.New("data cannot be blank")
 	}
+
+	if s.SKU != nil && *s.SKU == "" {
+		return errors.New("sku: cannot be blank")
+	}
+
+	return nil
+}
+
+// SearchRequest holds the /products/search filters. Name matches part of
+// the product name, Query runs a full-text search over name and description.
+// Categories are ids or slugs and match their subcategories with Descendants.
+type SearchRequest struct {
+	Name        *string
+	Query       *string
+	Categories  []string
+	Descendants bool
+	MinPrice    *float64
+	MaxPrice    *float64
+	InStock     *bool
+}
+
+func (s *SearchRequest) Validate() error {
+	if s.Name == nil && s.Query == nil && len(s.Categories) == 0 &&
+		s.MinPrice == nil && s.MaxPrice == nil && s.InStock == nil {
+		return errors.New("invalid query: at least one filter is required")
+	}
+
+	if s.MinPrice != nil && *s.MinPrice < 0 {
+		return errors.New("min_price: cannot be negative")
+	}
+
+	if s.MaxPrice != nil && *s.MaxPrice < 0 {
+		return errors.New("max_price: cannot be negative")
+	}
+
+	if s.MinPrice != nil && s.MaxPrice != nil && *s.MinPrice > *s.MaxPrice {
+		return errors.New("min_price: cannot be greater than max_price")
+	}
+
 	return nil
 }
 
+// SortFields adds relevance to the product sort fields when the search has a
+// full-text query, and makes it the default.
+func (s *SearchRequest) SortFields() []string {
+	if s.Query == nil {
+		return SortFields
+	}
+	return append([]string{"relevance"}, SortFields...)
+}
+
+// DefaultSort orders full-text results by descending relevance.
+func (s *SearchRequest) DefaultSort() string {
+	if s.Query == nil {
+		return ""
+	}
+	return "-relevance"
+}
+
 type Response struct {
 	ID          string  `json:"id"`
+	SKU         string  `json:"sku,omitempty"`
 	Name        string  `json:"name"`
 	Description string  `json:"description"`
 	Price       float64 `json:"price"`
-	Category    string  `json:"category"`
+	CategoryID  string  `json:"category_id,omitempty"`
+	Category    string  `json:"category,omitempty"`
 	Quantity    int     `json:"quantity"`
+	Reserved    int     `json:"reserved"`
+	Available   int     `json:"available"`
+	Version     int     `json:"version"`
+
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+
+	Images   []media.Response   `json:"images"`
+	Variants []variant.Response `json:"variants,omitempty"`
 }
 
 func ParseFromEntity(data Entity) (res Response) {
@@ -53,9 +130,25 @@ func ParseFromEntity(data Entity) (res Response) {
 		Name:        *data.Name,
 		Description: *data.Description,
 		Price:       *data.Price,
-		Category:    *data.Category,
 		Quantity:    *data.Quantity,
 	}
+	if data.SKU != nil {
+		res.SKU = *data.SKU
+	}
+	if data.CategoryID != nil {
+		res.CategoryID = *data.CategoryID
+	}
+	if data.Category != nil {
+		res.Category = *data.Category
+	}
+	if data.Reserved != nil {
+		res.Reserved = *data.Reserved
+	}
+	res.Available = res.Quantity - res.Reserved
+	if data.Version != nil {
+		res.Version = *data.Version
+	}
+	res.DeletedAt = data.DeletedAt
 	return
 }
 
@@ -1,10 +1,30 @@
 package product
 
+import "time"
+
 type Entity struct {
 	ID          string   `db:"id"`
+	SKU         *string  `db:"sku"`
 	Name        *string  `db:"name"`
 	Description *string  `db:"description"`
 	Price       *float64 `db:"price"`
+	CategoryID  *string  `db:"category_id"`
 	Category    *string  `db:"category"`
 	Quantity    *int     `db:"quantity"`
+	Reserved    *int     `db:"reserved"`
+	Version     *int     `db:"version"`
+
+	DeletedAt *time.Time `db:"deleted_at"`
+}
+
+// Filter narrows a product search. Nil and empty fields are not filtered on.
+// Categories are ids or slugs; with Descendants their subcategories match too.
+type Filter struct {
+	Name        *string
+	Query       *string
+	Categories  []string
+	Descendants bool
+	MinPrice    *float64
+	MaxPrice    *float64
+	InStock     *bool
 }
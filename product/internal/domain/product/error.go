@@ -0,0 +1,10 @@
+package product
+
+import (
+	"errors"
+)
+
+var (
+	ErrorUnknownCategory = errors.New("category_id: category not found")
+	ErrorDuplicate       = errors.New("name or sku is already in use")
+)
@@ -1,12 +1,18 @@
 package product
 
-import "context"
+import (
+	"context"
+	"github.com/yrss1/my-shop/product/pkg/pagination"
+)
 
 type Repository interface {
-	List(ctx context.Context) (dest []Entity, err error)
+	List(ctx context.Context, page pagination.Request) (dest []Entity, next string, err error)
 	Add(ctx context.Context, data Entity) (id string, err error)
 	Get(ctx context.Context, id string) (dest Entity, err error)
 	Update(ctx context.Context, id string, dest Entity) (err error)
-	Delete(ctx context.Context, id string) (err error)
-	Search(ctx context.Context, data Entity) (dest []Entity, err error)
+	Delete(ctx context.Context, id string, version *int) (err error)
+	Restore(ctx context.Context, id string) (err error)
+	Search(ctx context.Context, data Filter, page pagination.Request) (dest []Entity, next string, err error)
+	Upsert(ctx context.Context, data Entity) (id string, created bool, err error)
+	Export(ctx context.Context, fn func(data Entity) error) (err error)
 }
@@ -0,0 +1,267 @@
+package product
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+const (
+	ActionCreated = "created"
+	ActionUpdated = "updated"
+	ActionFailed  = "failed"
+)
+
+// Columns are the columns of product CSV files. Exports write all of them;
+// imports need a header with any subset and ignore id.
+var Columns = []string{"id", "sku", "name", "description", "price", "category_id", "quantity"}
+
+var ErrorUnknownFormat = errors.New("format: must be csv or ndjson")
+
+// ImportRow is one product of an import file. Line is its line number in the
+// file and Err is set when the line could not be decoded.
+type ImportRow struct {
+	Line    int
+	Request Request
+	Err     error
+}
+
+// Key identifies the product a row is upserted into: its SKU when the row has
+// one, its name otherwise.
+func (r ImportRow) Key() string {
+	switch {
+	case r.Request.SKU != nil:
+		return *r.Request.SKU
+	case r.Request.Name != nil:
+		return *r.Request.Name
+	}
+	return ""
+}
+
+// ImportResult is the outcome of one import row.
+type ImportResult struct {
+	Line   int    `json:"line"`
+	Key    string `json:"key"`
+	Action string `json:"action"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ImportResponse reports every row of an import. An import is all or
+// nothing: when a row fails, Committed is false and no row is written.
+type ImportResponse struct {
+	Committed bool           `json:"committed"`
+	Created   int            `json:"created"`
+	Updated   int            `json:"updated"`
+	Failed    int            `json:"failed"`
+	Rows      []ImportResult `json:"rows"`
+}
+
+// DecodeImport reads all rows of a CSV or NDJSON import file. Rows with bad
+// values are returned with Err set; a file that cannot be read at all, such as
+// a CSV file with an unknown column, is an error.
+func DecodeImport(format string, r io.Reader) (rows []ImportRow, err error) {
+	switch format {
+	case FormatCSV:
+		return decodeCSV(r)
+	case FormatNDJSON:
+		return decodeNDJSON(r)
+	}
+	return nil, ErrorUnknownFormat
+}
+
+func decodeCSV(r io.Reader) (rows []ImportRow, err error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			err = errors.New("csv: missing header")
+		}
+		return
+	}
+
+	known := make(map[string]bool, len(Columns))
+	for _, column := range Columns {
+		known[column] = true
+	}
+	for i, column := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(column))
+		if !known[header[i]] {
+			return nil, fmt.Errorf("csv: unknown column %q", column)
+		}
+	}
+
+	for {
+		record, readErr := reader.Read()
+		if errors.Is(readErr, io.EOF) {
+			break
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := ImportRow{Line: line}
+
+		switch {
+		case errors.Is(readErr, csv.ErrFieldCount):
+			row.Err = fmt.Errorf("expected %d fields, got %d", len(header), len(record))
+		case readErr != nil:
+			return nil, readErr
+		default:
+			row.Request, row.Err = parseRecord(header, record)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return
+}
+
+// parseRecord maps a CSV record to a request. Empty cells are left unset.
+func parseRecord(header, record []string) (req Request, err error) {
+	for i, column := range header {
+		value := strings.TrimSpace(record[i])
+		if value == "" {
+			continue
+		}
+
+		switch column {
+		case "sku":
+			req.SKU = &value
+		case "name":
+			req.Name = &value
+		case "description":
+			req.Description = &value
+		case "category_id":
+			req.CategoryID = &value
+		case "price":
+			price, parseErr := strconv.ParseFloat(value, 64)
+			if parseErr != nil {
+				return req, fmt.Errorf("price: invalid number %q", value)
+			}
+			req.Price = &price
+		case "quantity":
+			quantity, parseErr := strconv.Atoi(value)
+			if parseErr != nil {
+				return req, fmt.Errorf("quantity: invalid integer %q", value)
+			}
+			req.Quantity = &quantity
+		}
+	}
+
+	return
+}
+
+func decodeNDJSON(r io.Reader) (rows []ImportRow, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		row := ImportRow{Line: line}
+
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		row.Err = decoder.Decode(&row.Request)
+
+		rows = append(rows, row)
+	}
+
+	err = scanner.Err()
+
+	return
+}
+
+// Encoder writes products to an export file.
+type Encoder interface {
+	Encode(data Entity) error
+	Flush() error
+}
+
+// NewEncoder returns an encoder writing format to w. CSV files start with the
+// Columns header.
+func NewEncoder(format string, w io.Writer) (Encoder, error) {
+	switch format {
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(Columns); err != nil {
+			return nil, err
+		}
+		return &csvEncoder{writer: writer}, nil
+	case FormatNDJSON:
+		buffer := bufio.NewWriter(w)
+		return &ndjsonEncoder{buffer: buffer, encoder: json.NewEncoder(buffer)}, nil
+	}
+	return nil, ErrorUnknownFormat
+}
+
+type csvEncoder struct {
+	writer *csv.Writer
+}
+
+func (e *csvEncoder) Encode(data Entity) error {
+	record := []string{
+		data.ID,
+		stringValue(data.SKU),
+		stringValue(data.Name),
+		stringValue(data.Description),
+		"",
+		stringValue(data.CategoryID),
+		"",
+	}
+	if data.Price != nil {
+		record[4] = strconv.FormatFloat(*data.Price, 'f', -1, 64)
+	}
+	if data.Quantity != nil {
+		record[6] = strconv.Itoa(*data.Quantity)
+	}
+
+	return e.writer.Write(record)
+}
+
+func (e *csvEncoder) Flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+type ndjsonEncoder struct {
+	buffer  *bufio.Writer
+	encoder *json.Encoder
+}
+
+func (e *ndjsonEncoder) Encode(data Entity) error {
+	return e.encoder.Encode(Request{
+		ID:          data.ID,
+		SKU:         data.SKU,
+		Name:        data.Name,
+		Description: data.Description,
+		Price:       data.Price,
+		CategoryID:  data.CategoryID,
+		Quantity:    data.Quantity,
+	})
+}
+
+func (e *ndjsonEncoder) Flush() error {
+	return e.buffer.Flush()
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -0,0 +1,129 @@
+package product
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeImport(t *testing.T) {
+	str := func(v string) *string { return &v }
+	num := func(v float64) *float64 { return &v }
+	count := func(v int) *int { return &v }
+
+	tests := []struct {
+		name    string
+		format  string
+		input   string
+		want    []ImportRow
+		wantErr bool
+	}{
+		{
+			name:   "csv",
+			format: FormatCSV,
+			input:  "sku,name,price,quantity\nLP-1,Laptop,999.99,10\n,Mouse,19.5,\n",
+			want: []ImportRow{
+				{Line: 2, Request: Request{SKU: str("LP-1"), Name: str("Laptop"), Price: num(999.99), Quantity: count(10)}},
+				{Line: 3, Request: Request{Name: str("Mouse"), Price: num(19.5)}},
+			},
+		},
+		{
+			name:   "csv header is case and space insensitive",
+			format: FormatCSV,
+			input:  " Name , Category_ID,id\nLaptop, c1 ,ignored\n",
+			want: []ImportRow{
+				{Line: 2, Request: Request{Name: str("Laptop"), CategoryID: str("c1")}},
+			},
+		},
+		{
+			name:   "csv quoted fields",
+			format: FormatCSV,
+			input:  "name,description\n\"Desk, oak\",\"50\"\" wide\"\n",
+			want: []ImportRow{
+				{Line: 2, Request: Request{Name: str("Desk, oak"), Description: str("50\" wide")}},
+			},
+		},
+		{
+			name:   "csv bad values fail their row only",
+			format: FormatCSV,
+			input:  "name,price,quantity\nLaptop,cheap,1\nMouse,1,many\nCable,2\nDesk,3,4\n",
+			want: []ImportRow{
+				{Line: 2, Err: errors.New(`price: invalid number "cheap"`)},
+				{Line: 3, Err: errors.New(`quantity: invalid integer "many"`)},
+				{Line: 4, Err: errors.New("expected 3 fields, got 2")},
+				{Line: 5, Request: Request{Name: str("Desk"), Price: num(3), Quantity: count(4)}},
+			},
+		},
+		{
+			name:    "csv unknown column",
+			format:  FormatCSV,
+			input:   "name,colour\nLaptop,grey\n",
+			wantErr: true,
+		},
+		{
+			name:    "csv without header",
+			format:  FormatCSV,
+			input:   "",
+			wantErr: true,
+		},
+		{
+			name:   "ndjson",
+			format: FormatNDJSON,
+			input:  "{\"sku\":\"LP-1\",\"name\":\"Laptop\",\"price\":999.99,\"quantity\":10}\n\n  {\"name\":\"Mouse\"}  \n",
+			want: []ImportRow{
+				{Line: 1, Request: Request{SKU: str("LP-1"), Name: str("Laptop"), Price: num(999.99), Quantity: count(10)}},
+				{Line: 3, Request: Request{Name: str("Mouse")}},
+			},
+		},
+		{
+			name:   "ndjson bad lines fail their row only",
+			format: FormatNDJSON,
+			input:  "{\"name\":\"Laptop\",\"colour\":\"grey\"}\n{\"name\":\n{\"quantity\":\"ten\"}\n{\"name\":\"Mouse\"}\n",
+			want: []ImportRow{
+				{Line: 1, Err: errors.New("unknown field")},
+				{Line: 2, Err: errors.New("unexpected EOF")},
+				{Line: 3, Err: errors.New("cannot unmarshal")},
+				{Line: 4, Request: Request{Name: str("Mouse")}},
+			},
+		},
+		{
+			name:    "unknown format",
+			format:  "xml",
+			input:   "<products/>",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := DecodeImport(tt.format, strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeImport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(rows) != len(tt.want) {
+				t.Fatalf("DecodeImport() returned %d rows, want %d", len(rows), len(tt.want))
+			}
+
+			for i, want := range tt.want {
+				got := rows[i]
+				if got.Line != want.Line {
+					t.Errorf("row %d: line = %d, want %d", i, got.Line, want.Line)
+				}
+
+				if want.Err != nil {
+					if got.Err == nil || !strings.Contains(got.Err.Error(), want.Err.Error()) {
+						t.Errorf("row %d: error = %v, want %q", i, got.Err, want.Err)
+					}
+					continue
+				}
+				if got.Err != nil {
+					t.Errorf("row %d: unexpected error %v", i, got.Err)
+				}
+				if !reflect.DeepEqual(got.Request, want.Request) {
+					t.Errorf("row %d: request = %+v, want %+v", i, got.Request, want.Request)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package reservation
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Request struct {
+	OrderID string        `json:"order_id"`
+	Items   []ItemRequest `json:"items"`
+}
+
+// ItemRequest reserves stock of a product, or of one of its variants when
+// VariantID is set.
+type ItemRequest struct {
+	ProductID string  `json:"product_id"`
+	VariantID *string `json:"variant_id,omitempty"`
+	Quantity  int     `json:"quantity"`
+}
+
+func (s *Request) Validate() error {
+	if s.OrderID == "" {
+		return errors.New("order_id: cannot be blank")
+	}
+
+	if len(s.Items) == 0 {
+		return errors.New("items: cannot be empty")
+	}
+
+	seen := make(map[string]bool, len(s.Items))
+	for i, item := range s.Items {
+		if item.ProductID == "" {
+			return fmt.Errorf("items[%d].product_id: cannot be blank", i)
+		}
+		if item.Quantity <= 0 {
+			return fmt.Errorf("items[%d].quantity: must be positive", i)
+		}
+		if item.VariantID != nil && *item.VariantID == "" {
+			return fmt.Errorf("items[%d].variant_id: cannot be blank", i)
+		}
+
+		key := item.ProductID
+		if item.VariantID != nil {
+			key += "/" + *item.VariantID
+		}
+		if seen[key] {
+			return fmt.Errorf("items[%d]: duplicate item %s", i, key)
+		}
+		seen[key] = true
+	}
+
+	return nil
+}
+
+type Response struct {
+	OrderID   string  `json:"order_id"`
+	ProductID string  `json:"product_id"`
+	VariantID *string `json:"variant_id,omitempty"`
+	Quantity  int     `json:"quantity"`
+	Status    string  `json:"status"`
+}
+
+func ParseFromEntity(data Entity) (res Response) {
+	res = Response{
+		OrderID:   data.OrderID,
+		ProductID: data.ProductID,
+		VariantID: data.VariantID,
+		Quantity:  data.Quantity,
+		Status:    data.Status,
+	}
+	return
+}
+
+func ParseFromEntities(data []Entity) (res []Response) {
+	res = make([]Response, 0)
+	for _, object := range data {
+		res = append(res, ParseFromEntity(object))
+	}
+	return
+}
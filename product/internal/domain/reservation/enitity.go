@@ -0,0 +1,15 @@
+package reservation
+
+const (
+	StatusReserved  = "reserved"
+	StatusCommitted = "committed"
+	StatusReleased  = "released"
+)
+
+type Entity struct {
+	OrderID   string  `db:"order_id"`
+	ProductID string  `db:"product_id"`
+	VariantID *string `db:"variant_id"`
+	Quantity  int     `db:"quantity"`
+	Status    string  `db:"status"`
+}
@@ -0,0 +1,10 @@
+package reservation
+
+import (
+	"errors"
+)
+
+var (
+	ErrorInsufficientStock = errors.New("insufficient stock")
+	ErrorAlreadyReserved   = errors.New("stock is already reserved for this order")
+)
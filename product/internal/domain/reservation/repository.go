@@ -0,0 +1,9 @@
+package reservation
+
+import "context"
+
+type Repository interface {
+	Reserve(ctx context.Context, orderID string, data []Entity) (dest []Entity, err error)
+	Commit(ctx context.Context, orderID string) (dest []Entity, err error)
+	Release(ctx context.Context, orderID string) (dest []Entity, err error)
+}
@@ -0,0 +1,92 @@
+package variant
+
+import (
+	"errors"
+	"strings"
+)
+
+type Request struct {
+	ID         string     `json:"id"`
+	SKU        *string    `json:"sku"`
+	Attributes Attributes `json:"attributes"`
+	Price      *float64   `json:"price"`
+	Quantity   *int       `json:"quantity"`
+}
+
+func (s *Request) Validate() error {
+	if s.SKU == nil || strings.TrimSpace(*s.SKU) == "" {
+		return errors.New("sku: cannot be blank")
+	}
+
+	if s.Quantity == nil {
+		return errors.New("quantity: cannot be blank")
+	}
+
+	return s.validateValues()
+}
+
+func (s *Request) IsEmpty() error {
+	if s.SKU == nil && s.Attributes == nil && s.Price == nil && s.Quantity == nil {
+		return errors.New("data cannot be blank")
+	}
+
+	if s.SKU != nil && strings.TrimSpace(*s.SKU) == "" {
+		return errors.New("sku: cannot be blank")
+	}
+
+	return s.validateValues()
+}
+
+func (s *Request) validateValues() error {
+	if s.Price != nil && *s.Price < 0 {
+		return errors.New("price: cannot be negative")
+	}
+
+	if s.Quantity != nil && *s.Quantity < 0 {
+		return errors.New("quantity: cannot be negative")
+	}
+
+	return nil
+}
+
+type Response struct {
+	ID            string     `json:"id"`
+	ProductID     string     `json:"product_id"`
+	SKU           string     `json:"sku"`
+	Attributes    Attributes `json:"attributes"`
+	Price         float64    `json:"price"`
+	PriceOverride *float64   `json:"price_override,omitempty"`
+	Quantity      int        `json:"quantity"`
+	Reserved      int        `json:"reserved"`
+	Available     int        `json:"available"`
+}
+
+func ParseFromEntity(data Entity) (res Response) {
+	res = Response{
+		ID:            data.ID,
+		ProductID:     data.ProductID,
+		SKU:           *data.SKU,
+		Attributes:    data.Attributes,
+		PriceOverride: data.Price,
+		Quantity:      *data.Quantity,
+	}
+	if res.Attributes == nil {
+		res.Attributes = Attributes{}
+	}
+	if data.EffectivePrice != nil {
+		res.Price = *data.EffectivePrice
+	}
+	if data.Reserved != nil {
+		res.Reserved = *data.Reserved
+	}
+	res.Available = res.Quantity - res.Reserved
+	return
+}
+
+func ParseFromEntities(data []Entity) (res []Response) {
+	res = make([]Response, 0)
+	for _, object := range data {
+		res = append(res, ParseFromEntity(object))
+	}
+	return
+}
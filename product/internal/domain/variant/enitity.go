@@ -0,0 +1,45 @@
+package variant
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// Entity is a sellable version of a product, such as one size and colour.
+// Price overrides the product price when set; EffectivePrice is the price the
+// variant sells at.
+type Entity struct {
+	ID             string     `db:"id"`
+	ProductID      string     `db:"product_id"`
+	SKU            *string    `db:"sku"`
+	Attributes     Attributes `db:"attributes"`
+	Price          *float64   `db:"price"`
+	EffectivePrice *float64   `db:"effective_price"`
+	Quantity       *int       `db:"quantity"`
+	Reserved       *int       `db:"reserved"`
+}
+
+// Attributes are the options that tell variants apart, e.g. size and colour.
+// They are stored as a JSON object.
+type Attributes map[string]string
+
+func (a Attributes) Value() (driver.Value, error) {
+	if a == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(a)
+}
+
+func (a *Attributes) Scan(src any) error {
+	switch value := src.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(value, a)
+	case string:
+		return json.Unmarshal([]byte(value), a)
+	}
+	return errors.New("attributes: unsupported type")
+}
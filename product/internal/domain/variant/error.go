@@ -0,0 +1,10 @@
+package variant
+
+import (
+	"errors"
+)
+
+var (
+	ErrorDuplicateSKU = errors.New("sku is already in use")
+	ErrorInUse        = errors.New("variant has stock reservations")
+)
@@ -0,0 +1,11 @@
+package variant
+
+import "context"
+
+type Repository interface {
+	List(ctx context.Context, productID string) (dest []Entity, err error)
+	Add(ctx context.Context, data Entity) (id string, err error)
+	Get(ctx context.Context, productID, id string) (dest Entity, err error)
+	Update(ctx context.Context, productID, id string, data Entity) (err error)
+	Delete(ctx context.Context, productID, id string) (err error)
+}
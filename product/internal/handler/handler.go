@@ -41,6 +41,18 @@ func New(d Dependencies, configs ...Configuration) (h *Handler, err error) {
 func WithHTTPHandler() Configuration {
 	return func(h *Handler) (err error) {
 		h.HTTP = router.New()
+
+		productHandler := http.NewProductHandler(h.dependencies.ProductService)
+		categoryHandler := http.NewCategoryHandler(h.dependencies.ProductService)
+
+		// streaming routes are registered before the timeout middleware,
+		// which buffers the whole response
+		stream := h.HTTP.Group(h.dependencies.Configs.APP.Path)
+		{
+			productHandler.StreamRoutes(stream)
+			stream.Static("/media", h.dependencies.Configs.MEDIA.Dir)
+		}
+
 		h.HTTP.Use(timeout.New(
 			timeout.WithTimeout(h.dependencies.Configs.APP.Timeout),
 			timeout.WithHandler(func(ctx *gin.Context) {
@@ -54,11 +66,10 @@ func WithHTTPHandler() Configuration {
 		docs.SwaggerInfo.BasePath = h.dependencies.Configs.APP.Path
 		h.HTTP.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-		productHandler := http.NewProductHandler(h.dependencies.ProductService)
-
 		api := h.HTTP.Group(h.dependencies.Configs.APP.Path)
 		{
 			productHandler.Routes(api)
+			categoryHandler.Routes(api)
 		}
 		return
 	}
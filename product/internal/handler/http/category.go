@@ -0,0 +1,256 @@
+package http
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/yrss1/my-shop/product/internal/domain/category"
+	"github.com/yrss1/my-shop/product/internal/domain/product"
+	"github.com/yrss1/my-shop/product/internal/service/productService"
+	"github.com/yrss1/my-shop/product/pkg/pagination"
+	"github.com/yrss1/my-shop/product/pkg/server/response"
+	"github.com/yrss1/my-shop/product/pkg/store"
+	"strconv"
+)
+
+type CategoryHandler struct {
+	productService *productService.Service
+}
+
+func NewCategoryHandler(s *productService.Service) *CategoryHandler {
+	return &CategoryHandler{
+		productService: s,
+	}
+}
+
+func (h *CategoryHandler) Routes(r *gin.RouterGroup) {
+	api := r.Group("/categories")
+	{
+		api.GET("/", h.list)
+		api.POST("/", h.add)
+
+		api.GET("/:id", h.get)
+		api.PUT("/:id", h.update)
+		api.DELETE("/:id", h.delete)
+
+		api.GET("/:id/products", h.products)
+	}
+}
+
+// list godoc
+// @Summary List categories
+// @Description Get a page of categories; parent_id links each one to its parent
+// @Tags categories
+// @Accept  json
+// @Produce  json
+// @Param limit query int false "Page size"
+// @Param sort query string false "Sort field, prefixed with - for descending" Enums(name, -name, id, -id, slug, -slug, created_at, -created_at)
+// @Param cursor query string false "Cursor of the next page"
+// @Success 200 {array} category.Response
+// @Failure 400 {object} response.Object
+// @Failure 500 {object} response.Object
+// @Router /categories [get]
+func (h *CategoryHandler) list(c *gin.Context) {
+	page, err := pagination.Parse(c.Query("limit"), c.Query("sort"), c.Query("cursor"), category.SortFields...)
+	if err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	res, next, err := h.productService.ListCategories(c, page)
+	if err != nil {
+		response.InternalServerError(c, err)
+		return
+	}
+
+	response.OKPage(c, res, next)
+}
+
+// add godoc
+// @Summary Add a category
+// @Description Add a new category; the slug is derived from the name when omitted
+// @Tags categories
+// @Accept  json
+// @Produce  json
+// @Param category body category.Request true "Category request"
+// @Success 200 {object} category.Response
+// @Failure 400 {object} response.Object
+// @Failure 409 {object} response.Object
+// @Failure 500 {object} response.Object
+// @Router /categories [post]
+func (h *CategoryHandler) add(c *gin.Context) {
+	req := category.Request{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err, req)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.BadRequest(c, err, req)
+		return
+	}
+
+	res, err := h.productService.CreateCategory(c, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, category.ErrorUnknownParent):
+			response.BadRequest(c, err, req)
+		case errors.Is(err, category.ErrorDuplicateSlug):
+			response.Conflict(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, res)
+}
+
+// get godoc
+// @Summary Get a category
+// @Description Get category by ID
+// @Tags categories
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Category ID"
+// @Success 200 {object} category.Response
+// @Failure 404 {object} response.Object
+// @Failure 500 {object} response.Object
+// @Router /categories/{id} [get]
+func (h *CategoryHandler) get(c *gin.Context) {
+	id := c.Param("id")
+
+	res, err := h.productService.GetCategory(c, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrorNotFound):
+			response.NotFound(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, res)
+}
+
+// update godoc
+// @Summary Update a category
+// @Description Rename a category or move it; an empty parent_id makes it a root category
+// @Tags categories
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Category ID"
+// @Param category body category.Request true "Category request"
+// @Success 200 {string} string "ok"
+// @Failure 400 {object} response.Object
+// @Failure 404 {object} response.Object
+// @Failure 409 {object} response.Object
+// @Failure 500 {object} response.Object
+// @Router /categories/{id} [put]
+func (h *CategoryHandler) update(c *gin.Context) {
+	id := c.Param("id")
+	req := category.Request{}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err, req)
+		return
+	}
+
+	if err := req.IsEmpty(); err != nil {
+		response.BadRequest(c, err, req)
+		return
+	}
+
+	if err := h.productService.UpdateCategory(c, id, req); err != nil {
+		switch {
+		case errors.Is(err, store.ErrorNotFound):
+			response.NotFound(c, err)
+		case errors.Is(err, category.ErrorUnknownParent), errors.Is(err, category.ErrorCycle):
+			response.BadRequest(c, err, req)
+		case errors.Is(err, category.ErrorDuplicateSlug):
+			response.Conflict(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, "ok")
+}
+
+// delete godoc
+// @Summary Delete a category
+// @Description Delete a category that has no subcategories and no products
+// @Tags categories
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Category ID"
+// @Success 200 {string} string "Category deleted"
+// @Failure 404 {object} response.Object
+// @Failure 409 {object} response.Object
+// @Failure 500 {object} response.Object
+// @Router /categories/{id} [delete]
+func (h *CategoryHandler) delete(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.productService.DeleteCategory(c, id); err != nil {
+		switch {
+		case errors.Is(err, store.ErrorNotFound):
+			response.NotFound(c, err)
+		case errors.Is(err, category.ErrorInUse):
+			response.Conflict(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, id)
+}
+
+// products godoc
+// @Summary List products in a category
+// @Description Get a page of the products of a category, including its subcategories by default
+// @Tags categories
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Category ID"
+// @Param descendants query bool false "Include products of subcategories" default(true)
+// @Param limit query int false "Page size"
+// @Param sort query string false "Sort field, prefixed with - for descending" Enums(id, -id, created_at, -created_at, name, -name, price, -price, quantity, -quantity)
+// @Param cursor query string false "Cursor of the next page"
+// @Success 200 {array} product.Response
+// @Failure 400 {object} response.Object
+// @Failure 404 {object} response.Object
+// @Failure 500 {object} response.Object
+// @Router /categories/{id}/products [get]
+func (h *CategoryHandler) products(c *gin.Context) {
+	id := c.Param("id")
+
+	descendants := true
+	if value := c.Query("descendants"); value != "" {
+		var err error
+		if descendants, err = strconv.ParseBool(value); err != nil {
+			response.BadRequest(c, errors.New("descendants: invalid value"), nil)
+			return
+		}
+	}
+
+	page, err := pagination.Parse(c.Query("limit"), c.Query("sort"), c.Query("cursor"), product.SortFields...)
+	if err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	res, next, err := h.productService.ListCategoryProducts(c, id, descendants, page)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrorNotFound):
+			response.NotFound(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OKPage(c, res, next)
+}
@@ -0,0 +1,168 @@
+package http
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/yrss1/my-shop/product/internal/domain/media"
+	"github.com/yrss1/my-shop/product/pkg/server/response"
+	"github.com/yrss1/my-shop/product/pkg/store"
+	"io"
+)
+
+// listMedia godoc
+// @Summary List product images
+// @Description Get the images of a product in display order
+// @Tags media
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Product ID"
+// @Success 200 {array} media.Response
+// @Failure 404 {object} response.Object
+// @Failure 500 {object} response.Object
+// @Router /products/{id}/media [get]
+func (h *ProductHandler) listMedia(c *gin.Context) {
+	productID := c.Param("id")
+
+	res, err := h.productService.ListMedia(c, productID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrorNotFound):
+			response.NotFound(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, res)
+}
+
+// uploadMedia godoc
+// @Summary Upload a product image
+// @Description Upload a JPEG, PNG or GIF image; it is appended to the images of the product and gets a JPEG thumbnail
+// @Tags media
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param id path string true "Product ID"
+// @Param file formData file true "Image file"
+// @Success 200 {object} media.Response
+// @Failure 400 {object} response.Object
+// @Failure 404 {object} response.Object
+// @Failure 413 {object} response.Object
+// @Failure 500 {object} response.Object
+// @Router /products/{id}/media [post]
+func (h *ProductHandler) uploadMedia(c *gin.Context) {
+	productID := c.Param("id")
+
+	file, err := formFile(c, "file")
+	if err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	res, err := h.productService.UploadMedia(c, productID, file)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrorNotFound):
+			response.NotFound(c, err)
+		case errors.Is(err, media.ErrorTooLarge):
+			response.RequestEntityTooLarge(c, err)
+		case errors.Is(err, media.ErrorUnsupportedType), errors.Is(err, media.ErrorInvalidImage):
+			response.BadRequest(c, err, nil)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, res)
+}
+
+// reorderMedia godoc
+// @Summary Reorder product images
+// @Description Set the display order of all images of a product
+// @Tags media
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Product ID"
+// @Param order body media.OrderRequest true "Image ids in their new order"
+// @Success 200 {array} media.Response
+// @Failure 400 {object} response.Object
+// @Failure 404 {object} response.Object
+// @Failure 500 {object} response.Object
+// @Router /products/{id}/media/order [put]
+func (h *ProductHandler) reorderMedia(c *gin.Context) {
+	productID := c.Param("id")
+	req := media.OrderRequest{}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err, req)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.BadRequest(c, err, req)
+		return
+	}
+
+	res, err := h.productService.ReorderMedia(c, productID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrorNotFound):
+			response.NotFound(c, err)
+		case errors.Is(err, media.ErrorInvalidOrder):
+			response.BadRequest(c, err, req)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, res)
+}
+
+// deleteMedia godoc
+// @Summary Delete a product image
+// @Description Delete an image and its thumbnail
+// @Tags media
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Product ID"
+// @Param mediaId path string true "Image ID"
+// @Success 200 {string} string "Image deleted"
+// @Failure 404 {object} response.Object
+// @Failure 500 {object} response.Object
+// @Router /products/{id}/media/{mediaId} [delete]
+func (h *ProductHandler) deleteMedia(c *gin.Context) {
+	productID, id := c.Param("id"), c.Param("mediaId")
+
+	if err := h.productService.DeleteMedia(c, productID, id); err != nil {
+		switch {
+		case errors.Is(err, store.ErrorNotFound):
+			response.NotFound(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, id)
+}
+
+// formFile streams the named file of a multipart request without buffering
+// the upload to memory or disk first.
+func formFile(c *gin.Context, name string) (io.Reader, error) {
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		return nil, errors.New("file: expected a multipart/form-data request")
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			return nil, errors.New(name + ": cannot be blank")
+		}
+		if part.FormName() == name && part.FileName() != "" {
+			return part, nil
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package http
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/yrss1/my-shop/product/internal/domain/price"
+	"github.com/yrss1/my-shop/product/pkg/pagination"
+	"github.com/yrss1/my-shop/product/pkg/server/response"
+	"github.com/yrss1/my-shop/product/pkg/store"
+	"time"
+)
+
+// listPrices godoc
+// @Summary List product price history
+// @Description Get a page of the price changes of a product, latest first by default
+// @Tags prices
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Product ID"
+// @Param limit query int false "Page size"
+// @Param sort query string false "Sort field, prefixed with - for descending" Enums(created_at, -created_at, id, -id)
+// @Param cursor query string false "Cursor of the next page"
+// @Success 200 {array} price.HistoryResponse
+// @Failure 400 {object} response.Object
+// @Failure 404 {object} response.Object
+// @Failure 500 {object} response.Object
+// @Router /products/{id}/prices [get]
+func (h *ProductHandler) listPrices(c *gin.Context) {
+	productID := c.Param("id")
+
+	sort := c.Query("sort")
+	if sort == "" {
+		sort = price.DefaultSort
+	}
+
+	page, err := pagination.Parse(c.Query("limit"), sort, c.Query("cursor"), price.SortFields...)
+	if err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	res, next, err := h.productService.ListPriceHistory(c, productID, page)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrorNotFound):
+			response.NotFound(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OKPage(c, res, next)
+}
+
+// listPriceSchedules godoc
+// @Summary List scheduled prices
+// @Description Get the scheduled prices of a product ordered by start time
+// @Tags prices
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Product ID"
+// @Success 200 {array} price.ScheduleResponse
+// @Failure 404 {object} response.Object
+// @Failure 500 {object} response.Object
+// @Router /products/{id}/prices/schedules [get]
+func (h *ProductHandler) listPriceSchedules(c *gin.Context) {
+	productID := c.Param("id")
+
+	res, err := h.productService.ListPriceSchedules(c, productID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrorNotFound):
+			response.NotFound(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, res)
+}
+
+// schedulePrice godoc
+// @Summary Schedule a price
+// @Description Set the product price from starts_at on; with ends_at it is a sale and the price it replaced is restored when it ends
+// @Tags prices
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Product ID"
+// @Param schedule body price.ScheduleRequest true "Schedule request"
+// @Success 200 {object} price.ScheduleResponse
+// @Failure 400 {object} response.Object
+// @Failure 404 {object} response.Object
+// @Failure 409 {object} response.Object
+// @Failure 500 {object} response.Object
+// @Router /products/{id}/prices/schedules [post]
+func (h *ProductHandler) schedulePrice(c *gin.Context) {
+	productID := c.Param("id")
+	req := price.ScheduleRequest{}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err, req)
+		return
+	}
+	if err := req.Validate(time.Now()); err != nil {
+		response.BadRequest(c, err, req)
+		return
+	}
+
+	res, err := h.productService.SchedulePrice(c, productID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrorNotFound):
+			response.NotFound(c, err)
+		case errors.Is(err, price.ErrorOverlap):
+			response.Conflict(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, res)
+}
+
+// cancelPriceSchedule godoc
+// @Summary Cancel a scheduled price
+// @Description Cancel a pending schedule, or end an active sale at once and restore the price it replaced
+// @Tags prices
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Product ID"
+// @Param scheduleId path string true "Schedule ID"
+// @Success 200 {object} price.ScheduleResponse
+// @Failure 404 {object} response.Object
+// @Failure 409 {object} response.Object
+// @Failure 500 {object} response.Object
+// @Router /products/{id}/prices/schedules/{scheduleId} [delete]
+func (h *ProductHandler) cancelPriceSchedule(c *gin.Context) {
+	productID := c.Param("id")
+	id := c.Param("scheduleId")
+
+	res, err := h.productService.CancelPriceSchedule(c, productID, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrorNotFound):
+			response.NotFound(c, err)
+		case errors.Is(err, price.ErrorNotCancellable):
+			response.Conflict(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, res)
+}
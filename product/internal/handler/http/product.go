@@ -1,13 +1,17 @@
 package http
 
 import (
+	"context"
 	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/yrss1/my-shop/product/internal/domain/product"
 	"github.com/yrss1/my-shop/product/internal/service/productService"
 	"github.com/yrss1/my-shop/product/pkg/helpers"
+	"github.com/yrss1/my-shop/product/pkg/pagination"
 	"github.com/yrss1/my-shop/product/pkg/server/response"
 	"github.com/yrss1/my-shop/product/pkg/store"
+	"strconv"
+	"strings"
 )
 
 type ProductHandler struct {
@@ -29,29 +33,68 @@ func (h *ProductHandler) Routes(r *gin.RouterGroup) {
 		api.GET("/:id", h.get)
 		api.PUT("/:id", h.update)
 		api.DELETE("/:id", h.delete)
+		api.POST("/:id/restore", h.restore)
 
 		api.GET("/search", h.search)
 
+		api.POST("/import", h.importProducts)
+
+		api.GET("/:id/media", h.listMedia)
+		api.POST("/:id/media", h.uploadMedia)
+		api.PUT("/:id/media/order", h.reorderMedia)
+		api.DELETE("/:id/media/:mediaId", h.deleteMedia)
+
+		api.GET("/:id/prices", h.listPrices)
+		api.GET("/:id/prices/schedules", h.listPriceSchedules)
+		api.POST("/:id/prices/schedules", h.schedulePrice)
+		api.DELETE("/:id/prices/schedules/:scheduleId", h.cancelPriceSchedule)
+
+		api.GET("/:id/variants", h.listVariants)
+		api.POST("/:id/variants", h.addVariant)
+		api.GET("/:id/variants/:variantId", h.getVariant)
+		api.PUT("/:id/variants/:variantId", h.updateVariant)
+		api.DELETE("/:id/variants/:variantId", h.deleteVariant)
+
+		api.POST("/reservations", h.reserve)
+		api.POST("/reservations/:orderId/commit", h.commitReservation)
+		api.POST("/reservations/:orderId/release", h.releaseReservation)
 	}
 }
 
 // list godoc
 // @Summary List products
-// @Description Get all products
+// @Description Get a page of products
 // @Tags products
 // @Accept  json
 // @Produce  json
+// @Param limit query int false "Page size"
+// @Param sort query string false "Sort field, prefixed with - for descending" Enums(id, -id, created_at, -created_at, name, -name, price, -price, quantity, -quantity)
+// @Param cursor query string false "Cursor of the next page"
+// @Param include_deleted query bool false "Also list deleted products"
 // @Success 200 {array} product.Response
+// @Failure 400 {object} response.Object
 // @Failure 500 {object} response.Object
 // @Router /products [get]
 func (h *ProductHandler) list(c *gin.Context) {
-	res, err := h.productService.ListProducts(c)
+	page, err := pagination.Parse(c.Query("limit"), c.Query("sort"), c.Query("cursor"), product.SortFields...)
+	if err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	ctx, err := deletedContext(c)
+	if err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	res, next, err := h.productService.ListProducts(ctx, page)
 	if err != nil {
 		response.InternalServerError(c, err)
 		return
 	}
 
-	response.OK(c, res)
+	response.OKPage(c, res, next)
 }
 
 // add godoc
@@ -63,6 +106,7 @@ func (h *ProductHandler) list(c *gin.Context) {
 // @Param product body product.Request true "Product request"
 // @Success 200 {object} product.Response
 // @Failure 400 {object} response.Object
+// @Failure 409 {object} response.Object
 // @Failure 500 {object} response.Object
 // @Router /products [post]
 func (h *ProductHandler) add(c *gin.Context) {
@@ -78,7 +122,14 @@ func (h *ProductHandler) add(c *gin.Context) {
 
 	res, err := h.productService.CreateProduct(c, req)
 	if err != nil {
-		response.InternalServerError(c, err)
+		switch {
+		case errors.Is(err, product.ErrorUnknownCategory):
+			response.BadRequest(c, err, req)
+		case errors.Is(err, product.ErrorDuplicate):
+			response.Conflict(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
 		return
 	}
 
@@ -92,7 +143,10 @@ func (h *ProductHandler) add(c *gin.Context) {
 // @Accept  json
 // @Produce  json
 // @Param id path string true "Product ID"
+// @Param include_deleted query bool false "Also get a deleted product"
 // @Success 200 {object} product.Response
+// @Header 200 {string} ETag "Version of the product, for If-Match"
+// @Failure 400 {object} response.Object
 // @Failure 404 {object} response.Object
 // @Failure 500 {object} response.Object
 // @Router /products/{id} [get]
@@ -104,7 +158,13 @@ func (h *ProductHandler) get(c *gin.Context) {
 	//	return
 	//}
 	//fmt.Println(data)
-	res, err := h.productService.GetProduct(c, id)
+	ctx, err := deletedContext(c)
+	if err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	res, err := h.productService.GetProduct(ctx, id)
 	if err != nil {
 		switch {
 		case errors.Is(err, store.ErrorNotFound):
@@ -115,6 +175,7 @@ func (h *ProductHandler) get(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", helpers.ETag(res.Version))
 	response.OK(c, res)
 }
 
@@ -125,16 +186,25 @@ func (h *ProductHandler) get(c *gin.Context) {
 // @Accept  json
 // @Produce  json
 // @Param id path string true "Product ID"
+// @Param If-Match header string true "ETag of the product version being updated, or *"
 // @Param product body product.Request true "Product request"
 // @Success 200 {string} string "ok"
 // @Failure 400 {object} response.Object
 // @Failure 404 {object} response.Object
+// @Failure 409 {object} response.Object
+// @Failure 412 {object} response.Object
+// @Failure 428 {object} response.Object
 // @Failure 500 {object} response.Object
 // @Router /products/{id} [put]
 func (h *ProductHandler) update(c *gin.Context) {
 	id := c.Param("id")
 	req := product.Request{}
 
+	version, ok := ifMatch(c)
+	if !ok {
+		return
+	}
+
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.BadRequest(c, err, req)
 		return
@@ -145,10 +215,16 @@ func (h *ProductHandler) update(c *gin.Context) {
 		return
 	}
 
-	if err := h.productService.UpdateProduct(c, id, req); err != nil {
+	if err := h.productService.UpdateProduct(c, id, version, req); err != nil {
 		switch {
 		case errors.Is(err, store.ErrorNotFound):
 			response.NotFound(c, err)
+		case errors.Is(err, store.ErrorVersionMismatch):
+			response.PreconditionFailed(c, err)
+		case errors.Is(err, product.ErrorUnknownCategory):
+			response.BadRequest(c, err, req)
+		case errors.Is(err, product.ErrorDuplicate):
+			response.Conflict(c, err)
 		default:
 			response.InternalServerError(c, err)
 		}
@@ -160,22 +236,62 @@ func (h *ProductHandler) update(c *gin.Context) {
 
 // delete godoc
 // @Summary Delete a product
-// @Description Delete product by ID
+// @Description Soft delete product by ID; it can be restored later
 // @Tags products
 // @Accept  json
 // @Produce  json
 // @Param id path string true "Product ID"
+// @Param If-Match header string true "ETag of the product version being deleted, or *"
 // @Success 200 {string} string "Product deleted"
 // @Failure 404 {object} response.Object
+// @Failure 412 {object} response.Object
+// @Failure 428 {object} response.Object
 // @Failure 500 {object} response.Object
 // @Router /products/{id} [delete]
 func (h *ProductHandler) delete(c *gin.Context) {
 	id := c.Param("id")
 
-	if err := h.productService.DeleteProduct(c, id); err != nil {
+	version, ok := ifMatch(c)
+	if !ok {
+		return
+	}
+
+	if err := h.productService.DeleteProduct(c, id, version); err != nil {
+		switch {
+		case errors.Is(err, store.ErrorNotFound):
+			response.NotFound(c, err)
+		case errors.Is(err, store.ErrorVersionMismatch):
+			response.PreconditionFailed(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, id)
+}
+
+// restore godoc
+// @Summary Restore a product
+// @Description Restore a deleted product by ID
+// @Tags products
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Product ID"
+// @Success 200 {string} string "Product restored"
+// @Failure 404 {object} response.Object
+// @Failure 409 {object} response.Object
+// @Failure 500 {object} response.Object
+// @Router /products/{id}/restore [post]
+func (h *ProductHandler) restore(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.productService.RestoreProduct(c, id); err != nil {
 		switch {
 		case errors.Is(err, store.ErrorNotFound):
 			response.NotFound(c, err)
+		case errors.Is(err, product.ErrorDuplicate):
+			response.Conflict(c, err)
 		default:
 			response.InternalServerError(c, err)
 		}
@@ -187,32 +303,142 @@ func (h *ProductHandler) delete(c *gin.Context) {
 
 // search godoc
 // @Summary Search products
-// @Description Search products by name or email
+// @Description Search products by partial name, full-text query, categories, price range and availability
 // @Tags products
 // @Accept  json
 // @Produce  json
-// @Param name query string false "Name"
-// @Param category query string false "Category"
+// @Param name query string false "Part of the product name, case-insensitive"
+// @Param q query string false "Full-text query over name and description"
+// @Param category query []string false "Category ids or slugs, repeated or comma-separated" collectionFormat(multi)
+// @Param descendants query bool false "Match subcategories of the given categories" default(true)
+// @Param min_price query number false "Minimum price"
+// @Param max_price query number false "Maximum price"
+// @Param in_stock query bool false "Only products with available stock, or only sold-out ones"
+// @Param include_deleted query bool false "Also find deleted products"
+// @Param limit query int false "Page size"
+// @Param sort query string false "Sort field, prefixed with - for descending; relevance requires q" Enums(relevance, -relevance, id, -id, created_at, -created_at, name, -name, price, -price, quantity, -quantity)
+// @Param cursor query string false "Cursor of the next page"
 // @Success 200 {array} product.Response
 // @Failure 400 {object} response.Object
 // @Failure 500 {object} response.Object
 // @Router /products/search [get]
 func (h *ProductHandler) search(c *gin.Context) {
-	req := product.Request{
-		Name:     helpers.GetStringPtr(c.Query("name")),
-		Category: helpers.GetStringPtr(c.Query("category")),
+	req, err := parseSearchRequest(c)
+	if err != nil {
+		response.BadRequest(c, err, nil)
+		return
 	}
 
-	if err := req.IsEmpty(); err != nil {
-		response.BadRequest(c, errors.New("incorrect query"), nil)
+	if err = req.Validate(); err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	sort := c.Query("sort")
+	if sort == "" {
+		sort = req.DefaultSort()
+	}
+
+	page, err := pagination.Parse(c.Query("limit"), sort, c.Query("cursor"), req.SortFields()...)
+	if err != nil {
+		response.BadRequest(c, err, nil)
 		return
 	}
 
-	res, err := h.productService.SearchProduct(c, req)
+	ctx, err := deletedContext(c)
+	if err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	res, next, err := h.productService.SearchProduct(ctx, req, page)
 	if err != nil {
 		response.InternalServerError(c, err)
 		return
 	}
 
-	response.OK(c, res)
+	response.OKPage(c, res, next)
+}
+
+func parseSearchRequest(c *gin.Context) (req product.SearchRequest, err error) {
+	req = product.SearchRequest{
+		Name:  helpers.GetStringPtr(strings.TrimSpace(c.Query("name"))),
+		Query: helpers.GetStringPtr(strings.TrimSpace(c.Query("q"))),
+	}
+
+	for _, value := range c.QueryArray("category") {
+		for _, category := range strings.Split(value, ",") {
+			if category = strings.TrimSpace(category); category != "" {
+				req.Categories = append(req.Categories, category)
+			}
+		}
+	}
+
+	req.Descendants = true
+	if value := c.Query("descendants"); value != "" {
+		if req.Descendants, err = strconv.ParseBool(value); err != nil {
+			return req, errors.New("descendants: invalid value")
+		}
+	}
+
+	if value := c.Query("min_price"); value != "" {
+		price, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return req, errors.New("min_price: invalid value")
+		}
+		req.MinPrice = &price
+	}
+
+	if value := c.Query("max_price"); value != "" {
+		price, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return req, errors.New("max_price: invalid value")
+		}
+		req.MaxPrice = &price
+	}
+
+	if value := c.Query("in_stock"); value != "" {
+		inStock, err := strconv.ParseBool(value)
+		if err != nil {
+			return req, errors.New("in_stock: invalid value")
+		}
+		req.InStock = &inStock
+	}
+
+	return
+}
+
+// deletedContext returns the context of a read, marked to include soft deleted
+// products when the include_deleted query parameter is true.
+func deletedContext(c *gin.Context) (ctx context.Context, err error) {
+	ctx = c
+
+	if value := c.Query("include_deleted"); value != "" {
+		include, parseErr := strconv.ParseBool(value)
+		if parseErr != nil {
+			return nil, errors.New("include_deleted: invalid value")
+		}
+		if include {
+			ctx = store.WithDeleted(c)
+		}
+	}
+
+	return
+}
+
+// ifMatch returns the version a write expects from the If-Match header. When
+// the header is missing or invalid it writes the error response and ok is
+// false.
+func ifMatch(c *gin.Context) (version *int, ok bool) {
+	version, err := helpers.IfMatch(c.GetHeader("If-Match"))
+	switch {
+	case errors.Is(err, helpers.ErrorIfMatchRequired):
+		response.PreconditionRequired(c, err)
+	case err != nil:
+		response.PreconditionFailed(c, err)
+	default:
+		ok = true
+	}
+
+	return
 }
@@ -0,0 +1,105 @@
+package http
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/yrss1/my-shop/product/internal/domain/reservation"
+	"github.com/yrss1/my-shop/product/pkg/server/response"
+	"github.com/yrss1/my-shop/product/pkg/store"
+)
+
+// reserve godoc
+// @Summary Reserve stock
+// @Description Reserve stock for every item of an order
+// @Tags reservations
+// @Accept  json
+// @Produce  json
+// @Param reservation body reservation.Request true "Reservation request"
+// @Success 200 {array} reservation.Response
+// @Failure 400 {object} response.Object
+// @Failure 404 {object} response.Object
+// @Failure 409 {object} response.Object
+// @Failure 500 {object} response.Object
+// @Router /products/reservations [post]
+func (h *ProductHandler) reserve(c *gin.Context) {
+	req := reservation.Request{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err, req)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.BadRequest(c, err, req)
+		return
+	}
+
+	res, err := h.productService.ReserveStock(c, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrorNotFound):
+			response.NotFound(c, err)
+		case errors.Is(err, reservation.ErrorInsufficientStock), errors.Is(err, reservation.ErrorAlreadyReserved):
+			response.Conflict(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, res)
+}
+
+// commitReservation godoc
+// @Summary Commit reserved stock
+// @Description Decrement stock held by the order reservation
+// @Tags reservations
+// @Accept  json
+// @Produce  json
+// @Param orderId path string true "Order ID"
+// @Success 200 {array} reservation.Response
+// @Failure 404 {object} response.Object
+// @Failure 500 {object} response.Object
+// @Router /products/reservations/{orderId}/commit [post]
+func (h *ProductHandler) commitReservation(c *gin.Context) {
+	orderID := c.Param("orderId")
+
+	res, err := h.productService.CommitStock(c, orderID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrorNotFound):
+			response.NotFound(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, res)
+}
+
+// releaseReservation godoc
+// @Summary Release reserved stock
+// @Description Return stock held by the order reservation to the catalogue
+// @Tags reservations
+// @Accept  json
+// @Produce  json
+// @Param orderId path string true "Order ID"
+// @Success 200 {array} reservation.Response
+// @Failure 404 {object} response.Object
+// @Failure 500 {object} response.Object
+// @Router /products/reservations/{orderId}/release [post]
+func (h *ProductHandler) releaseReservation(c *gin.Context) {
+	orderID := c.Param("orderId")
+
+	res, err := h.productService.ReleaseStock(c, orderID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrorNotFound):
+			response.NotFound(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, res)
+}
@@ -0,0 +1,141 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/yrss1/my-shop/product/internal/domain/product"
+	"github.com/yrss1/my-shop/product/pkg/server/response"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// maxImportSize caps the size of an import upload.
+const maxImportSize = 32 << 20
+
+// StreamRoutes registers the routes that stream their response. They must not
+// run behind middleware that buffers the response.
+func (h *ProductHandler) StreamRoutes(r *gin.RouterGroup) {
+	api := r.Group("/products")
+	{
+		api.GET("/export", h.exportProducts)
+	}
+}
+
+// importProducts godoc
+// @Summary Import products
+// @Description Upsert products from a CSV or NDJSON file, by sku or else by name. The body is the file itself or a multipart form with a file field. Every row is validated and reported; when any row fails nothing is written.
+// @Tags products
+// @Accept  text/csv
+// @Accept  application/x-ndjson
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param format query string false "File format, taken from the content type or file name when omitted" Enums(csv, ndjson)
+// @Param file formData file false "Import file"
+// @Success 200 {object} product.ImportResponse
+// @Failure 400 {object} response.Object
+// @Failure 422 {object} response.Object{data=product.ImportResponse}
+// @Failure 500 {object} response.Object
+// @Router /products/import [post]
+func (h *ProductHandler) importProducts(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+
+	format, body, err := importFile(c)
+	if err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+	defer body.Close()
+
+	rows, err := product.DecodeImport(format, body)
+	if err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+	if len(rows) == 0 {
+		response.BadRequest(c, errors.New("file: no rows to import"), nil)
+		return
+	}
+
+	res, err := h.productService.ImportProducts(c, rows)
+	if err != nil {
+		response.InternalServerError(c, err)
+		return
+	}
+
+	if !res.Committed {
+		response.UnprocessableEntity(c, fmt.Errorf("%d of %d rows failed, no product was imported", res.Failed, len(rows)), res)
+		return
+	}
+
+	response.OK(c, res)
+}
+
+// exportProducts godoc
+// @Summary Export products
+// @Description Stream every product as a CSV or NDJSON file that can be imported again
+// @Tags products
+// @Produce  text/csv
+// @Produce  application/x-ndjson
+// @Param format query string false "File format" Enums(csv, ndjson) default(csv)
+// @Success 200 {file} file
+// @Failure 400 {object} response.Object
+// @Failure 500 {object} response.Object
+// @Router /products/export [get]
+func (h *ProductHandler) exportProducts(c *gin.Context) {
+	format := c.DefaultQuery("format", product.FormatCSV)
+
+	contentType := "text/csv"
+	if format == product.FormatNDJSON {
+		contentType = "application/x-ndjson"
+	}
+
+	enc, err := product.NewEncoder(format, c.Writer)
+	if err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="products.%s"`, format))
+	c.Status(http.StatusOK)
+
+	if err = h.productService.ExportProducts(c, enc); err != nil {
+		// the status line is already sent, cut the stream short
+		c.Abort()
+	}
+}
+
+// importFile returns the uploaded file and its format. The format comes from
+// the format query parameter, else from the content type or file name.
+func importFile(c *gin.Context) (format string, body io.ReadCloser, err error) {
+	format = c.Query("format")
+
+	if c.ContentType() == "multipart/form-data" {
+		header, err := c.FormFile("file")
+		if err != nil {
+			return "", nil, errors.New("file: cannot be blank")
+		}
+		if format == "" {
+			format = strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
+			if format == "jsonl" {
+				format = product.FormatNDJSON
+			}
+		}
+		body, err = header.Open()
+		return format, body, err
+	}
+
+	if format == "" {
+		switch c.ContentType() {
+		case "text/csv":
+			format = product.FormatCSV
+		case "application/x-ndjson", "application/jsonl", "application/x-jsonlines":
+			format = product.FormatNDJSON
+		}
+	}
+
+	return format, c.Request.Body, nil
+}
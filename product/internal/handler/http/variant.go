@@ -0,0 +1,184 @@
+package http
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/yrss1/my-shop/product/internal/domain/variant"
+	"github.com/yrss1/my-shop/product/pkg/server/response"
+	"github.com/yrss1/my-shop/product/pkg/store"
+)
+
+// listVariants godoc
+// @Summary List product variants
+// @Description Get the variants of a product ordered by SKU
+// @Tags variants
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Product ID"
+// @Success 200 {array} variant.Response
+// @Failure 404 {object} response.Object
+// @Failure 500 {object} response.Object
+// @Router /products/{id}/variants [get]
+func (h *ProductHandler) listVariants(c *gin.Context) {
+	productID := c.Param("id")
+
+	res, err := h.productService.ListVariants(c, productID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrorNotFound):
+			response.NotFound(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, res)
+}
+
+// addVariant godoc
+// @Summary Add a product variant
+// @Description Add a variant with its own attributes and stock; without a price it sells at the product price
+// @Tags variants
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Product ID"
+// @Param variant body variant.Request true "Variant request"
+// @Success 200 {object} variant.Response
+// @Failure 400 {object} response.Object
+// @Failure 404 {object} response.Object
+// @Failure 409 {object} response.Object
+// @Failure 500 {object} response.Object
+// @Router /products/{id}/variants [post]
+func (h *ProductHandler) addVariant(c *gin.Context) {
+	productID := c.Param("id")
+	req := variant.Request{}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err, req)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		response.BadRequest(c, err, req)
+		return
+	}
+
+	res, err := h.productService.CreateVariant(c, productID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrorNotFound):
+			response.NotFound(c, err)
+		case errors.Is(err, variant.ErrorDuplicateSKU):
+			response.Conflict(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, res)
+}
+
+// getVariant godoc
+// @Summary Get a product variant
+// @Description Get variant by ID
+// @Tags variants
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Product ID"
+// @Param variantId path string true "Variant ID"
+// @Success 200 {object} variant.Response
+// @Failure 404 {object} response.Object
+// @Failure 500 {object} response.Object
+// @Router /products/{id}/variants/{variantId} [get]
+func (h *ProductHandler) getVariant(c *gin.Context) {
+	productID, id := c.Param("id"), c.Param("variantId")
+
+	res, err := h.productService.GetVariant(c, productID, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrorNotFound):
+			response.NotFound(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, res)
+}
+
+// updateVariant godoc
+// @Summary Update a product variant
+// @Description Update the SKU, attributes, price override or stock of a variant
+// @Tags variants
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Product ID"
+// @Param variantId path string true "Variant ID"
+// @Param variant body variant.Request true "Variant request"
+// @Success 200 {string} string "ok"
+// @Failure 400 {object} response.Object
+// @Failure 404 {object} response.Object
+// @Failure 409 {object} response.Object
+// @Failure 500 {object} response.Object
+// @Router /products/{id}/variants/{variantId} [put]
+func (h *ProductHandler) updateVariant(c *gin.Context) {
+	productID, id := c.Param("id"), c.Param("variantId")
+	req := variant.Request{}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err, req)
+		return
+	}
+
+	if err := req.IsEmpty(); err != nil {
+		response.BadRequest(c, err, req)
+		return
+	}
+
+	if err := h.productService.UpdateVariant(c, productID, id, req); err != nil {
+		switch {
+		case errors.Is(err, store.ErrorNotFound):
+			response.NotFound(c, err)
+		case errors.Is(err, variant.ErrorDuplicateSKU):
+			response.Conflict(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, "ok")
+}
+
+// deleteVariant godoc
+// @Summary Delete a product variant
+// @Description Delete a variant that has never been reserved
+// @Tags variants
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Product ID"
+// @Param variantId path string true "Variant ID"
+// @Success 200 {string} string "Variant deleted"
+// @Failure 404 {object} response.Object
+// @Failure 409 {object} response.Object
+// @Failure 500 {object} response.Object
+// @Router /products/{id}/variants/{variantId} [delete]
+func (h *ProductHandler) deleteVariant(c *gin.Context) {
+	productID, id := c.Param("id"), c.Param("variantId")
+
+	if err := h.productService.DeleteVariant(c, productID, id); err != nil {
+		switch {
+		case errors.Is(err, store.ErrorNotFound):
+			response.NotFound(c, err)
+		case errors.Is(err, variant.ErrorInUse):
+			response.Conflict(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, id)
+}
@@ -0,0 +1,163 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/yrss1/my-shop/product/internal/domain/category"
+	"github.com/yrss1/my-shop/product/pkg/pagination"
+	"github.com/yrss1/my-shop/product/pkg/store"
+	"strings"
+)
+
+type CategoryRepository struct {
+	db *sqlx.DB
+}
+
+func NewCategoryRepository(db *sqlx.DB) *CategoryRepository {
+	return &CategoryRepository{db: db}
+}
+
+type categoryRow struct {
+	category.Entity
+	SortKey string `db:"sort_key"`
+}
+
+func (r *CategoryRepository) List(ctx context.Context, page pagination.Request) (dest []category.Entity, next string, err error) {
+	query := `
+		SELECT id, parent_id, name, slug, ` + page.SortKey() + `
+		FROM categories
+		WHERE 1=1`
+
+	query, args := page.Keyset(query, nil)
+
+	var rows []categoryRow
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return
+	}
+
+	rows, next = pagination.Next(page, rows, func(row categoryRow) (string, string) {
+		return row.SortKey, row.ID
+	})
+
+	dest = make([]category.Entity, 0, len(rows))
+	for _, row := range rows {
+		dest = append(dest, row.Entity)
+	}
+
+	return
+}
+
+func (r *CategoryRepository) Add(ctx context.Context, data category.Entity) (id string, err error) {
+	query := `
+		INSERT INTO categories (parent_id, name, slug)
+		VALUES ($1, $2, $3)
+		RETURNING id`
+
+	args := []any{data.ParentID, data.Name, data.Slug}
+
+	if err = r.db.QueryRowContext(ctx, query, args...).Scan(&id); err != nil {
+		err = r.mapError(err, category.ErrorUnknownParent)
+	}
+
+	return
+}
+
+func (r *CategoryRepository) Get(ctx context.Context, id string) (dest category.Entity, err error) {
+	query := `
+		SELECT id, parent_id, name, slug
+		FROM categories
+		WHERE id=$1`
+
+	if err = r.db.GetContext(ctx, &dest, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = store.ErrorNotFound
+		}
+	}
+
+	return
+}
+
+func (r *CategoryRepository) Update(ctx context.Context, id string, data category.Entity) (err error) {
+	sets, args := r.prepareArgs(data)
+
+	if len(sets) > 0 {
+		args = append(args, id)
+		sets = append(sets, "updated_at=CURRENT_TIMESTAMP")
+
+		query := fmt.Sprintf("UPDATE categories SET %s WHERE id=$%d RETURNING id", strings.Join(sets, ", "), len(args))
+
+		if err = r.db.QueryRowContext(ctx, query, args...).Scan(&id); err != nil {
+			err = r.mapError(err, category.ErrorUnknownParent)
+		}
+	}
+
+	return
+}
+
+func (r *CategoryRepository) Delete(ctx context.Context, id string) (err error) {
+	query := `
+		DELETE FROM categories
+		WHERE id=$1
+		RETURNING id`
+
+	if err = r.db.QueryRowContext(ctx, query, id).Scan(&id); err != nil {
+		err = r.mapError(err, category.ErrorInUse)
+	}
+
+	return
+}
+
+// IsDescendant reports whether id sits somewhere below ancestorID.
+func (r *CategoryRepository) IsDescendant(ctx context.Context, id, ancestorID string) (ok bool, err error) {
+	query := `
+		WITH RECURSIVE tree AS (
+			SELECT id FROM categories WHERE parent_id = $1
+			UNION
+			SELECT c.id FROM categories c JOIN tree t ON c.parent_id = t.id
+		)
+		SELECT EXISTS(SELECT 1 FROM tree WHERE id = $2)`
+
+	err = r.db.GetContext(ctx, &ok, query, ancestorID, id)
+
+	return
+}
+
+func (r *CategoryRepository) prepareArgs(data category.Entity) (sets []string, args []any) {
+	if data.ParentID != nil {
+		if *data.ParentID == "" {
+			sets = append(sets, "parent_id=NULL")
+		} else {
+			args = append(args, data.ParentID)
+			sets = append(sets, fmt.Sprintf("parent_id=$%d", len(args)))
+		}
+	}
+
+	if data.Name != nil {
+		args = append(args, data.Name)
+		sets = append(sets, fmt.Sprintf("name=$%d", len(args)))
+	}
+
+	if data.Slug != nil {
+		args = append(args, data.Slug)
+		sets = append(sets, fmt.Sprintf("slug=$%d", len(args)))
+	}
+
+	return
+}
+
+// mapError translates constraint violations. A foreign key violation means
+// fkErr: a missing parent on writes, remaining children or products on delete.
+func (r *CategoryRepository) mapError(err, fkErr error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return store.ErrorNotFound
+	case isViolation(err, uniqueViolation):
+		return category.ErrorDuplicateSlug
+	case isViolation(err, foreignKeyViolation):
+		return fkErr
+	}
+	return err
+}
@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+	"github.com/yrss1/my-shop/product/pkg/store"
+)
+
+const (
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+)
+
+func isViolation(err error, code pq.ErrorCode) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == code
+}
+
+// versionError tells why a versioned write of the row id of table matched no
+// row: the row is missing or deleted, or it is at another version.
+func versionError(ctx context.Context, conn store.Querier, table, id string) (err error) {
+	var exists bool
+	query := "SELECT EXISTS(SELECT 1 FROM " + table + " WHERE id=$1 AND deleted_at IS NULL)"
+	if err = conn.GetContext(ctx, &exists, query, id); err != nil {
+		return
+	}
+
+	if exists {
+		return store.ErrorVersionMismatch
+	}
+	return store.ErrorNotFound
+}
+
+// checkVersion fails like a versioned write of the row id of table would, for
+// writes that have nothing to change.
+func checkVersion(ctx context.Context, conn store.Querier, table, id string, version int) (err error) {
+	var current int
+	query := "SELECT version FROM " + table + " WHERE id=$1 AND deleted_at IS NULL"
+	if err = conn.GetContext(ctx, &current, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = store.ErrorNotFound
+		}
+		return
+	}
+
+	if current != version {
+		return store.ErrorVersionMismatch
+	}
+	return
+}
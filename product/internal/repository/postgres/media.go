@@ -0,0 +1,135 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/yrss1/my-shop/product/internal/domain/media"
+	"github.com/yrss1/my-shop/product/pkg/store"
+)
+
+type MediaRepository struct {
+	db *sqlx.DB
+	tx store.UnitOfWork
+}
+
+func NewMediaRepository(db *sqlx.DB, tx store.UnitOfWork) *MediaRepository {
+	return &MediaRepository{db: db, tx: tx}
+}
+
+const mediaColumns = "id, product_id, position, content_type, size, width, height, key, thumbnail_key"
+
+// List returns the images of the given products ordered by product and
+// position, in one query for any number of products.
+func (r *MediaRepository) List(ctx context.Context, productIDs ...string) (dest []media.Entity, err error) {
+	if len(productIDs) == 0 {
+		return
+	}
+
+	query := `
+		SELECT ` + mediaColumns + `
+		FROM product_media
+		WHERE product_id = ANY($1::uuid[])
+		ORDER BY product_id, position`
+
+	err = store.Conn(ctx, r.db).SelectContext(ctx, &dest, query, pq.Array(productIDs))
+
+	return
+}
+
+// Add appends an image after the last image of its product.
+func (r *MediaRepository) Add(ctx context.Context, data media.Entity) (dest media.Entity, err error) {
+	query := `
+		INSERT INTO product_media (product_id, position, content_type, size, width, height, key, thumbnail_key)
+		VALUES ($1, (SELECT COALESCE(MAX(position) + 1, 0) FROM product_media WHERE product_id = $1), $2, $3, $4, $5, $6, $7)
+		RETURNING ` + mediaColumns
+
+	args := []any{data.ProductID, data.ContentType, data.Size, data.Width, data.Height, data.Key, data.ThumbnailKey}
+
+	err = r.tx.Do(ctx, func(ctx context.Context) (err error) {
+		if err = r.lockProduct(ctx, data.ProductID); err != nil {
+			return
+		}
+
+		return store.Conn(ctx, r.db).GetContext(ctx, &dest, query, args...)
+	})
+
+	return
+}
+
+// Delete removes an image and closes the gap it leaves in the positions.
+func (r *MediaRepository) Delete(ctx context.Context, productID, id string) (dest media.Entity, err error) {
+	query := `
+		DELETE FROM product_media
+		WHERE product_id=$1 AND id=$2
+		RETURNING ` + mediaColumns
+
+	err = r.tx.Do(ctx, func(ctx context.Context) (err error) {
+		if err = r.lockProduct(ctx, productID); err != nil {
+			return
+		}
+
+		conn := store.Conn(ctx, r.db)
+		if err = conn.GetContext(ctx, &dest, query, productID, id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				err = store.ErrorNotFound
+			}
+			return
+		}
+
+		_, err = conn.ExecContext(ctx, "UPDATE product_media SET position = position - 1 WHERE product_id=$1 AND position > $2", productID, dest.Position)
+		return
+	})
+
+	return
+}
+
+// Reorder gives the images of a product the order of ids, which must list
+// each of them once.
+func (r *MediaRepository) Reorder(ctx context.Context, productID string, ids []string) (err error) {
+	err = r.tx.Do(ctx, func(ctx context.Context) (err error) {
+		if err = r.lockProduct(ctx, productID); err != nil {
+			return
+		}
+
+		conn := store.Conn(ctx, r.db)
+
+		var current []string
+		if err = conn.SelectContext(ctx, &current, "SELECT id FROM product_media WHERE product_id=$1", productID); err != nil {
+			return
+		}
+		if len(current) != len(ids) {
+			return media.ErrorInvalidOrder
+		}
+
+		query := "UPDATE product_media SET position=$1 WHERE product_id=$2 AND id=$3 RETURNING id"
+		for position, id := range ids {
+			if err = conn.QueryRowContext(ctx, query, position, productID, id).Scan(&id); err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					err = fmt.Errorf("%w: unknown id %s", media.ErrorInvalidOrder, id)
+				}
+				return
+			}
+		}
+
+		return
+	})
+
+	return
+}
+
+// lockProduct serialises the image changes of one product and reports a
+// missing product.
+func (r *MediaRepository) lockProduct(ctx context.Context, productID string) (err error) {
+	var id string
+	if err = store.Conn(ctx, r.db).GetContext(ctx, &id, "SELECT id FROM products WHERE id=$1 FOR UPDATE", productID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = fmt.Errorf("%w: product %s", store.ErrorNotFound, productID)
+		}
+	}
+
+	return
+}
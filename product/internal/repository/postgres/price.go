@@ -0,0 +1,288 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/yrss1/my-shop/product/internal/domain/price"
+	"github.com/yrss1/my-shop/product/pkg/pagination"
+	"github.com/yrss1/my-shop/product/pkg/store"
+	"math"
+	"time"
+)
+
+type PriceRepository struct {
+	db *sqlx.DB
+	tx store.UnitOfWork
+}
+
+func NewPriceRepository(db *sqlx.DB, tx store.UnitOfWork) *PriceRepository {
+	return &PriceRepository{db: db, tx: tx}
+}
+
+const scheduleColumns = "id, product_id, price, starts_at, ends_at, previous_price, status"
+
+type historyRow struct {
+	price.History
+	SortKey string `db:"sort_key"`
+}
+
+func (r *PriceRepository) ListHistory(ctx context.Context, productID string, page pagination.Request) (dest []price.History, next string, err error) {
+	query := `
+		SELECT id, product_id, old_price, price, source, schedule_id, created_at, ` + page.SortKey() + `
+		FROM product_price_history
+		WHERE product_id=$1`
+
+	query, args := page.Keyset(query, []any{productID})
+
+	var rows []historyRow
+	if err = store.Conn(ctx, r.db).SelectContext(ctx, &rows, query, args...); err != nil {
+		return
+	}
+
+	rows, next = pagination.Next(page, rows, func(row historyRow) (string, string) {
+		return row.SortKey, row.ID
+	})
+
+	dest = make([]price.History, 0, len(rows))
+	for _, row := range rows {
+		dest = append(dest, row.History)
+	}
+
+	return
+}
+
+func (r *PriceRepository) ListSchedules(ctx context.Context, productID string) (dest []price.Schedule, err error) {
+	query := `
+		SELECT ` + scheduleColumns + `
+		FROM product_price_schedules
+		WHERE product_id=$1
+		ORDER BY starts_at, id`
+
+	err = store.Conn(ctx, r.db).SelectContext(ctx, &dest, query, productID)
+
+	return
+}
+
+// AddSchedule adds a pending schedule unless it overlaps a pending or active
+// schedule of the same product. A schedule without an end only occupies its
+// start time.
+func (r *PriceRepository) AddSchedule(ctx context.Context, data price.Schedule) (dest price.Schedule, err error) {
+	overlapQuery := `
+		SELECT EXISTS(
+			SELECT 1 FROM product_price_schedules
+			WHERE product_id=$1 AND status IN ($2, $3)
+			AND tstzrange(starts_at, COALESCE(ends_at, starts_at), '[]') &&
+				tstzrange($4::timestamptz, COALESCE($5::timestamptz, $4::timestamptz), '[]'))`
+
+	query := `
+		INSERT INTO product_price_schedules (product_id, price, starts_at, ends_at, status)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + scheduleColumns
+
+	err = r.tx.Do(ctx, func(ctx context.Context) (err error) {
+		conn := store.Conn(ctx, r.db)
+
+		if _, err = lockProductPrice(ctx, conn, data.ProductID); err != nil {
+			return
+		}
+
+		var overlap bool
+		args := []any{data.ProductID, price.StatusPending, price.StatusActive, data.StartsAt, data.EndsAt}
+		if err = conn.GetContext(ctx, &overlap, overlapQuery, args...); err != nil {
+			return
+		}
+		if overlap {
+			return price.ErrorOverlap
+		}
+
+		args = []any{data.ProductID, data.Price, data.StartsAt, data.EndsAt, price.StatusPending}
+		return conn.GetContext(ctx, &dest, query, args...)
+	})
+
+	return
+}
+
+// CancelSchedule drops a pending schedule. An active sale is ended at once and
+// the price it replaced is restored.
+func (r *PriceRepository) CancelSchedule(ctx context.Context, productID, id string) (dest price.Schedule, err error) {
+	query := `
+		SELECT ` + scheduleColumns + `
+		FROM product_price_schedules
+		WHERE product_id=$1 AND id=$2
+		FOR UPDATE`
+
+	err = r.tx.Do(ctx, func(ctx context.Context) (err error) {
+		conn := store.Conn(ctx, r.db)
+
+		if err = conn.GetContext(ctx, &dest, query, productID, id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				err = store.ErrorNotFound
+			}
+			return
+		}
+
+		switch dest.Status {
+		case price.StatusPending:
+		case price.StatusActive:
+			if err = r.restore(ctx, conn, dest, price.SourceScheduleCancel); err != nil {
+				return
+			}
+		default:
+			return price.ErrorNotCancellable
+		}
+
+		dest.Status = price.StatusCancelled
+		return r.setStatus(ctx, conn, dest)
+	})
+
+	return
+}
+
+// StartDue applies the price of every pending schedule that has started. A
+// schedule whose whole window has already passed finishes without a change.
+// Locked schedules are skipped, so several instances can run the scheduler.
+func (r *PriceRepository) StartDue(ctx context.Context, now time.Time) (dest []price.Schedule, err error) {
+	query := `
+		SELECT ` + scheduleColumns + `
+		FROM product_price_schedules
+		WHERE status=$1 AND starts_at <= $2
+		ORDER BY starts_at, id
+		FOR UPDATE SKIP LOCKED`
+
+	err = r.tx.Do(ctx, func(ctx context.Context) (err error) {
+		conn := store.Conn(ctx, r.db)
+
+		if err = conn.SelectContext(ctx, &dest, query, price.StatusPending, now); err != nil {
+			return
+		}
+
+		for i, schedule := range dest {
+			schedule.Status = price.StatusFinished
+
+			if schedule.EndsAt == nil || schedule.EndsAt.After(now) {
+				current, err := lockProductPrice(ctx, conn, schedule.ProductID)
+				if err != nil {
+					return err
+				}
+				if err = r.setPrice(ctx, conn, schedule, &current, schedule.Price, price.SourceSchedule); err != nil {
+					return err
+				}
+
+				schedule.PreviousPrice = &current
+				if schedule.EndsAt != nil {
+					schedule.Status = price.StatusActive
+				}
+			}
+
+			if err = r.setStatus(ctx, conn, schedule); err != nil {
+				return
+			}
+			dest[i] = schedule
+		}
+
+		return
+	})
+
+	return
+}
+
+// EndDue restores the previous price of every active sale that has ended.
+func (r *PriceRepository) EndDue(ctx context.Context, now time.Time) (dest []price.Schedule, err error) {
+	query := `
+		SELECT ` + scheduleColumns + `
+		FROM product_price_schedules
+		WHERE status=$1 AND ends_at <= $2
+		ORDER BY ends_at, id
+		FOR UPDATE SKIP LOCKED`
+
+	err = r.tx.Do(ctx, func(ctx context.Context) (err error) {
+		conn := store.Conn(ctx, r.db)
+
+		if err = conn.SelectContext(ctx, &dest, query, price.StatusActive, now); err != nil {
+			return
+		}
+
+		for i, schedule := range dest {
+			if err = r.restore(ctx, conn, schedule, price.SourceScheduleEnd); err != nil {
+				return
+			}
+
+			schedule.Status = price.StatusFinished
+			if err = r.setStatus(ctx, conn, schedule); err != nil {
+				return
+			}
+			dest[i] = schedule
+		}
+
+		return
+	})
+
+	return
+}
+
+// restore puts back the price a sale replaced. A price changed by hand during
+// the sale is kept.
+func (r *PriceRepository) restore(ctx context.Context, conn store.Querier, schedule price.Schedule, source string) (err error) {
+	current, err := lockProductPrice(ctx, conn, schedule.ProductID)
+	if err != nil || schedule.PreviousPrice == nil || !samePrice(current, schedule.Price) {
+		return
+	}
+
+	return r.setPrice(ctx, conn, schedule, &current, *schedule.PreviousPrice, source)
+}
+
+func (r *PriceRepository) setPrice(ctx context.Context, conn store.Querier, schedule price.Schedule, old *float64, value float64, source string) (err error) {
+	query := "UPDATE products SET price=$1, updated_at=CURRENT_TIMESTAMP, version=version+1 WHERE id=$2"
+	if _, err = conn.ExecContext(ctx, query, value, schedule.ProductID); err != nil {
+		return
+	}
+
+	return insertPriceHistory(ctx, conn, schedule.ProductID, old, value, source, &schedule.ID)
+}
+
+func (r *PriceRepository) setStatus(ctx context.Context, conn store.Querier, schedule price.Schedule) (err error) {
+	query := `
+		UPDATE product_price_schedules
+		SET status=$1, previous_price=$2, updated_at=CURRENT_TIMESTAMP
+		WHERE id=$3`
+
+	_, err = conn.ExecContext(ctx, query, schedule.Status, schedule.PreviousPrice, schedule.ID)
+
+	return
+}
+
+// lockProductPrice locks the product row for a price change and returns the
+// current price.
+func lockProductPrice(ctx context.Context, conn store.Querier, productID string) (current float64, err error) {
+	if err = conn.GetContext(ctx, &current, "SELECT price FROM products WHERE id=$1 FOR UPDATE", productID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = fmt.Errorf("%w: product %s", store.ErrorNotFound, productID)
+		}
+	}
+
+	return
+}
+
+// insertPriceHistory records a change of the price of a product. Writes that
+// keep the price as it was are not recorded.
+func insertPriceHistory(ctx context.Context, conn store.Querier, productID string, old *float64, value float64, source string, scheduleID *string) (err error) {
+	if old != nil && samePrice(*old, value) {
+		return
+	}
+
+	query := `
+		INSERT INTO product_price_history (product_id, old_price, price, source, schedule_id)
+		VALUES ($1, $2, $3, $4, $5)`
+
+	_, err = conn.ExecContext(ctx, query, productID, old, value, source, scheduleID)
+
+	return
+}
+
+// samePrice compares prices in cents, the precision they are stored with.
+func samePrice(a, b float64) bool {
+	return math.Round(a*100) == math.Round(b*100)
+}
@@ -6,56 +6,77 @@ import (
 	"errors"
 	"fmt"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/yrss1/my-shop/product/internal/domain/price"
 	"github.com/yrss1/my-shop/product/internal/domain/product"
+	"github.com/yrss1/my-shop/product/pkg/pagination"
 	"github.com/yrss1/my-shop/product/pkg/store"
 	"strings"
 )
 
 type ProductRepository struct {
 	db *sqlx.DB
+	tx store.UnitOfWork
 }
 
-func NewProductRepository(db *sqlx.DB) *ProductRepository {
-	return &ProductRepository{db: db}
+func NewProductRepository(db *sqlx.DB, tx store.UnitOfWork) *ProductRepository {
+	return &ProductRepository{db: db, tx: tx}
 }
 
-func (r *ProductRepository) List(ctx context.Context) (dest []product.Entity, err error) {
+// productColumns resolves the category name next to its id, so that no join
+// makes the paginated columns ambiguous.
+const productColumns = `id, sku, name, description, price, category_id,
+			(SELECT name FROM categories WHERE categories.id = products.category_id) AS category,
+			quantity, reserved, version, deleted_at`
+
+type productRow struct {
+	product.Entity
+	SortKey string `db:"sort_key"`
+}
+
+func (r *ProductRepository) List(ctx context.Context, page pagination.Request) (dest []product.Entity, next string, err error) {
 	query := `
-			SELECT id, name, description, price, category, quantity 
+			SELECT ` + productColumns + `, ` + page.SortKey() + `
 			FROM products
-			ORDER BY id`
-
-	err = r.db.SelectContext(ctx, &dest, query)
+			WHERE ` + store.DeletedFilter(ctx, "products")
 
-	return
+	return r.selectPage(ctx, page, query, nil)
 }
 
 func (r *ProductRepository) Add(ctx context.Context, data product.Entity) (id string, err error) {
 	query := `
-		INSERT INTO products (name, description, price, category, quantity) 
-		VALUES ($1, $2, $3, $4, $5) 
+		INSERT INTO products (sku, name, description, price, category_id, quantity) 
+		VALUES ($1, $2, $3, $4, $5, $6) 
 		RETURNING id`
 
-	args := []any{data.Name, data.Description, data.Price, data.Category, data.Quantity}
+	args := []any{data.SKU, data.Name, data.Description, data.Price, data.CategoryID, data.Quantity}
 
-	if err = r.db.QueryRowContext(ctx, query, args...).Scan(&id); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			err = store.ErrorNotFound
+	err = r.tx.Do(ctx, func(ctx context.Context) (err error) {
+		conn := store.Conn(ctx, r.db)
+
+		if err = conn.QueryRowContext(ctx, query, args...).Scan(&id); err != nil {
+			return r.mapError(err)
+		}
+
+		if data.Price != nil {
+			err = insertPriceHistory(ctx, conn, id, nil, *data.Price, price.SourceManual, nil)
 		}
-	}
+
+		return
+	})
 
 	return
 }
 
 func (r *ProductRepository) Get(ctx context.Context, id string) (dest product.Entity, err error) {
 	query := `
-			SELECT id, name, description, price, category, quantity 
+			SELECT ` + productColumns + `
 			FROM products
-			WHERE id=$1`
+			WHERE id=$1 AND ` + store.DeletedFilter(ctx, "products")
 
 	args := []any{id}
 
-	if err = r.db.GetContext(ctx, &dest, query, args...); err != nil {
+	if err = store.Conn(ctx, r.db).GetContext(ctx, &dest, query, args...); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			err = store.ErrorNotFound
 		}
@@ -64,26 +85,130 @@ func (r *ProductRepository) Get(ctx context.Context, id string) (dest product.En
 	return
 }
 
+// Update changes the set fields of the product. With data.Version set it only
+// updates the product at that version and fails with store.ErrorVersionMismatch
+// otherwise.
 func (r *ProductRepository) Update(ctx context.Context, id string, data product.Entity) (err error) {
 	sets, args := r.prepareArgs(data)
 
-	if len(args) > 0 {
+	// an empty update changes nothing, but a stale version still fails
+	if len(sets) == 0 && data.Version != nil {
+		return checkVersion(ctx, store.Conn(ctx, r.db), "products", id, *data.Version)
+	}
+
+	if len(sets) > 0 {
 		args = append(args, id)
-		sets = append(sets, "updated_at=CURRENT_TIMESTAMP")
+		sets = append(sets, "updated_at=CURRENT_TIMESTAMP", "version=version+1")
+		conds := fmt.Sprintf("id=$%d AND deleted_at IS NULL", len(args))
+
+		if data.Version != nil {
+			args = append(args, *data.Version)
+			conds += fmt.Sprintf(" AND version=$%d", len(args))
+		}
 
-		query := fmt.Sprintf("UPDATE products SET %s WHERE id=$%d RETURNING id", strings.Join(sets, ", "), len(args))
+		query := fmt.Sprintf("UPDATE products SET %s WHERE %s RETURNING id", strings.Join(sets, ", "), conds)
 
-		if err = r.db.QueryRowContext(ctx, query, args...).Scan(&id); err != nil {
-			if errors.Is(err, sql.ErrNoRows) {
-				err = store.ErrorNotFound
+		err = r.tx.Do(ctx, func(ctx context.Context) (err error) {
+			conn := store.Conn(ctx, r.db)
+
+			// a price change is recorded against the price it replaces
+			var old float64
+			if data.Price != nil {
+				if old, err = lockProductPrice(ctx, conn, id); err != nil {
+					return
+				}
 			}
-		}
+
+			if err = conn.QueryRowContext(ctx, query, args...).Scan(&id); err != nil {
+				if errors.Is(err, sql.ErrNoRows) && data.Version != nil {
+					return versionError(ctx, conn, "products", id)
+				}
+				return r.mapError(err)
+			}
+
+			if data.Price != nil {
+				err = insertPriceHistory(ctx, conn, id, &old, *data.Price, price.SourceManual, nil)
+			}
+
+			return
+		})
+	}
+
+	return
+}
+
+// Upsert updates the product with the SKU of data, or with its name when data
+// has no SKU, and adds a new product when there is none. Inside a transaction
+// a failed upsert leaves the transaction usable.
+func (r *ProductRepository) Upsert(ctx context.Context, data product.Entity) (id string, created bool, err error) {
+	query, arg := "SELECT id FROM products WHERE name=$1 AND deleted_at IS NULL FOR UPDATE", any(data.Name)
+	if data.SKU != nil {
+		query, arg = "SELECT id FROM products WHERE sku=$1 AND deleted_at IS NULL FOR UPDATE", data.SKU
 	}
 
+	err = store.Savepoint(ctx, "product_upsert", func(ctx context.Context) (err error) {
+		err = store.Conn(ctx, r.db).GetContext(ctx, &id, query, arg)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			created = true
+			id, err = r.Add(ctx, data)
+		case err == nil:
+			err = r.Update(ctx, id, data)
+		}
+		return
+	})
+
 	return
 }
 
+// Export calls fn for every product in id order while reading them from the
+// database, so that exports do not hold the whole catalogue in memory.
+func (r *ProductRepository) Export(ctx context.Context, fn func(data product.Entity) error) (err error) {
+	query := `
+			SELECT ` + productColumns + `
+			FROM products
+			WHERE deleted_at IS NULL
+			ORDER BY id`
+
+	rows, err := store.Conn(ctx, r.db).QueryxContext(ctx, query)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var data product.Entity
+		if err = rows.StructScan(&data); err != nil {
+			return
+		}
+		if err = fn(data); err != nil {
+			return
+		}
+	}
+
+	return rows.Err()
+}
+
+// mapError translates constraint violations of product writes. category_id is
+// the only foreign key.
+func (r *ProductRepository) mapError(err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return store.ErrorNotFound
+	case isViolation(err, uniqueViolation):
+		return product.ErrorDuplicate
+	case isViolation(err, foreignKeyViolation):
+		return product.ErrorUnknownCategory
+	}
+	return err
+}
+
 func (r *ProductRepository) prepareArgs(data product.Entity) (sets []string, args []any) {
+	if data.SKU != nil {
+		args = append(args, data.SKU)
+		sets = append(sets, fmt.Sprintf("sku=$%d", len(args)))
+	}
+
 	if data.Name != nil {
 		args = append(args, data.Name)
 		sets = append(sets, fmt.Sprintf("name=$%d", len(args)))
@@ -99,9 +224,13 @@ func (r *ProductRepository) prepareArgs(data product.Entity) (sets []string, arg
 		sets = append(sets, fmt.Sprintf("price=$%d", len(args)))
 	}
 
-	if data.Category != nil {
-		args = append(args, data.Category)
-		sets = append(sets, fmt.Sprintf("category=$%d", len(args)))
+	if data.CategoryID != nil {
+		if *data.CategoryID == "" {
+			sets = append(sets, "category_id=NULL")
+		} else {
+			args = append(args, data.CategoryID)
+			sets = append(sets, fmt.Sprintf("category_id=$%d", len(args)))
+		}
 	}
 
 	if data.Quantity != nil {
@@ -112,32 +241,138 @@ func (r *ProductRepository) prepareArgs(data product.Entity) (sets []string, arg
 	return
 }
 
-func (r *ProductRepository) Delete(ctx context.Context, id string) (err error) {
+// Delete soft deletes the product: it is hidden from reads and writes but its
+// variants, images and price history are kept for Restore. With a version it
+// only deletes the product at that version.
+func (r *ProductRepository) Delete(ctx context.Context, id string, version *int) (err error) {
 	query := `
-		DELETE FROM products
-		WHERE id=$1
+		UPDATE products
+		SET deleted_at=CURRENT_TIMESTAMP, version=version+1
+		WHERE id=$1 AND deleted_at IS NULL AND ($2::integer IS NULL OR version=$2)
 		RETURNING id`
 
-	args := []any{id}
+	args := []any{id, version}
 
-	if err = r.db.QueryRowContext(ctx, query, args...).Scan(&id); err != nil {
+	conn := store.Conn(ctx, r.db)
+	if err = conn.QueryRowContext(ctx, query, args...).Scan(&id); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			err = store.ErrorNotFound
+			err = versionError(ctx, conn, "products", id)
 		}
 	}
 
 	return
 }
 
-func (r *ProductRepository) Search(ctx context.Context, data product.Entity) (dest []product.Entity, err error) {
-	query := "SELECT id, name, description, price, category, quantity FROM products WHERE 1=1"
+// Restore undoes Delete. Restoring a product that is not deleted gives
+// store.ErrorNotFound, and restoring one while a live product has its name or
+// SKU fails as a duplicate.
+func (r *ProductRepository) Restore(ctx context.Context, id string) (err error) {
+	query := `
+		UPDATE products
+		SET deleted_at=NULL, version=version+1
+		WHERE id=$1 AND deleted_at IS NOT NULL
+		RETURNING id`
 
-	sets, args := r.prepareArgs(data)
-	if len(sets) > 0 {
-		query += " AND " + strings.Join(sets, " AND ")
+	args := []any{id}
+
+	if err = store.Conn(ctx, r.db).QueryRowContext(ctx, query, args...).Scan(&id); err != nil {
+		err = r.mapError(err)
+	}
+
+	return
+}
+
+// Search filters products by a partial name, a full-text query over name and
+// description, price range, availability and categories. The relevance sort
+// field ranks rows by how well they match the full-text query.
+func (r *ProductRepository) Search(ctx context.Context, data product.Filter, page pagination.Request) (dest []product.Entity, next string, err error) {
+	var conds []string
+	var args []any
+
+	if data.Name != nil {
+		args = append(args, "%"+escapeLike(*data.Name)+"%")
+		conds = append(conds, fmt.Sprintf("name ILIKE $%d", len(args)))
+	}
+
+	if data.Query != nil {
+		args = append(args, *data.Query)
+		tsquery := fmt.Sprintf("websearch_to_tsquery('english', $%d)", len(args))
+		conds = append(conds, "search_vector @@ "+tsquery)
+		page = page.Expression("relevance", "ts_rank(search_vector, "+tsquery+")")
+	}
+
+	if len(data.Categories) > 0 {
+		args = append(args, pq.Array(data.Categories))
+		conds = append(conds, "category_id IN "+categoryTree(len(args), data.Descendants))
 	}
 
-	err = r.db.SelectContext(ctx, &dest, query, args...)
+	if data.MinPrice != nil {
+		args = append(args, *data.MinPrice)
+		conds = append(conds, fmt.Sprintf("price >= $%d", len(args)))
+	}
+
+	if data.MaxPrice != nil {
+		args = append(args, *data.MaxPrice)
+		conds = append(conds, fmt.Sprintf("price <= $%d", len(args)))
+	}
+
+	if data.InStock != nil {
+		// a product is in stock when it or any of its variants is
+		inStock := `(quantity - reserved > 0 OR EXISTS (
+			SELECT 1 FROM product_variants v WHERE v.product_id = products.id AND v.quantity - v.reserved > 0))`
+		if *data.InStock {
+			conds = append(conds, inStock)
+		} else {
+			conds = append(conds, "NOT "+inStock)
+		}
+	}
+
+	query := "SELECT " + productColumns + ", " + page.SortKey() + " FROM products WHERE " + store.DeletedFilter(ctx, "products")
+	if len(conds) > 0 {
+		query += " AND " + strings.Join(conds, " AND ")
+	}
+
+	return r.selectPage(ctx, page, query, args)
+}
+
+// categoryTree selects the ids of the categories whose id or slug is in the
+// array parameter arg, together with all their subcategories when descendants
+// is set.
+func categoryTree(arg int, descendants bool) string {
+	roots := fmt.Sprintf("SELECT id FROM categories WHERE id::text = ANY($%[1]d) OR slug = ANY($%[1]d)", arg)
+	if !descendants {
+		return "(" + roots + ")"
+	}
+
+	return `(
+		WITH RECURSIVE tree AS (
+			` + roots + `
+			UNION
+			SELECT c.id FROM categories c JOIN tree t ON c.parent_id = t.id
+		)
+		SELECT id FROM tree)`
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+func (r *ProductRepository) selectPage(ctx context.Context, page pagination.Request, query string, args []any) (dest []product.Entity, next string, err error) {
+	query, args = page.Keyset(query, args)
+
+	var rows []productRow
+	if err = store.Conn(ctx, r.db).SelectContext(ctx, &rows, query, args...); err != nil {
+		return
+	}
+
+	rows, next = pagination.Next(page, rows, func(row productRow) (string, string) {
+		return row.SortKey, row.ID
+	})
+
+	dest = make([]product.Entity, 0, len(rows))
+	for _, row := range rows {
+		dest = append(dest, row.Entity)
+	}
 
 	return
 }
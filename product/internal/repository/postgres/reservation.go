@@ -0,0 +1,177 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/yrss1/my-shop/product/internal/domain/reservation"
+	"github.com/yrss1/my-shop/product/pkg/store"
+	"sort"
+)
+
+type ReservationRepository struct {
+	db *sqlx.DB
+	tx store.UnitOfWork
+}
+
+func NewReservationRepository(db *sqlx.DB, tx store.UnitOfWork) *ReservationRepository {
+	return &ReservationRepository{db: db, tx: tx}
+}
+
+// Reserve holds stock for every item of an order. The availability check and
+// the increment happen in one conditional UPDATE, so concurrent reservations
+// can never push reserved above quantity.
+func (r *ReservationRepository) Reserve(ctx context.Context, orderID string, data []reservation.Entity) (dest []reservation.Entity, err error) {
+	items := append([]reservation.Entity(nil), data...)
+	// lock products and variants in a stable order to avoid deadlocks between orders
+	sort.Slice(items, func(i, j int) bool {
+		return stockKey(items[i]) < stockKey(items[j])
+	})
+
+	err = r.tx.Do(ctx, func(ctx context.Context) (err error) {
+		conn := store.Conn(ctx, r.db)
+
+		if _, err = conn.ExecContext(ctx, "DELETE FROM stock_reservations WHERE order_id=$1 AND status=$2", orderID, reservation.StatusReleased); err != nil {
+			return
+		}
+
+		var active int
+		if err = conn.GetContext(ctx, &active, "SELECT COUNT(*) FROM stock_reservations WHERE order_id=$1", orderID); err != nil {
+			return
+		}
+		if active > 0 {
+			return reservation.ErrorAlreadyReserved
+		}
+
+		productQuery := `
+			UPDATE products
+			SET reserved = reserved + $1, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $2 AND quantity - reserved >= $1 AND deleted_at IS NULL
+			RETURNING id`
+
+		variantQuery := `
+			UPDATE product_variants
+			SET reserved = reserved + $1, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $2 AND product_id = $3 AND quantity - reserved >= $1
+			AND EXISTS (SELECT 1 FROM products WHERE id = $3 AND deleted_at IS NULL)
+			RETURNING id`
+
+		reservationQuery := `
+			INSERT INTO stock_reservations (order_id, product_id, variant_id, quantity, status)
+			VALUES ($1, $2, $3, $4, $5)`
+
+		for _, item := range items {
+			var id string
+			if item.VariantID == nil {
+				err = conn.QueryRowContext(ctx, productQuery, item.Quantity, item.ProductID).Scan(&id)
+			} else {
+				err = conn.QueryRowContext(ctx, variantQuery, item.Quantity, *item.VariantID, item.ProductID).Scan(&id)
+			}
+			if err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					err = r.stockError(ctx, item)
+				}
+				return
+			}
+
+			args := []any{orderID, item.ProductID, item.VariantID, item.Quantity, reservation.StatusReserved}
+			if _, err = conn.ExecContext(ctx, reservationQuery, args...); err != nil {
+				return
+			}
+
+			item.OrderID = orderID
+			item.Status = reservation.StatusReserved
+			dest = append(dest, item)
+		}
+
+		return
+	})
+
+	return
+}
+
+// Commit turns the reserved stock of an order into a real decrement.
+func (r *ReservationRepository) Commit(ctx context.Context, orderID string) (dest []reservation.Entity, err error) {
+	stockQuery := `
+		UPDATE %s
+		SET quantity = quantity - $1, reserved = reserved - $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2`
+
+	return r.settle(ctx, orderID, reservation.StatusCommitted, stockQuery)
+}
+
+// Release gives the reserved stock of an order back to the catalogue.
+func (r *ReservationRepository) Release(ctx context.Context, orderID string) (dest []reservation.Entity, err error) {
+	stockQuery := `
+		UPDATE %s
+		SET reserved = reserved - $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2`
+
+	return r.settle(ctx, orderID, reservation.StatusReleased, stockQuery)
+}
+
+// settle moves the active reservation of an order to status and applies
+// stockQuery to every reserved line. The %s in stockQuery is the table that
+// holds the stock of the line: products, or product_variants for variants.
+func (r *ReservationRepository) settle(ctx context.Context, orderID, status, stockQuery string) (dest []reservation.Entity, err error) {
+	query := `
+		UPDATE stock_reservations
+		SET status = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE order_id = $2 AND status = $3
+		RETURNING order_id, product_id, variant_id, quantity, status`
+
+	err = r.tx.Do(ctx, func(ctx context.Context) (err error) {
+		conn := store.Conn(ctx, r.db)
+
+		if err = conn.SelectContext(ctx, &dest, query, status, orderID, reservation.StatusReserved); err != nil {
+			return
+		}
+		if len(dest) == 0 {
+			return store.ErrorNotFound
+		}
+
+		for _, item := range dest {
+			table, id := "products", item.ProductID
+			if item.VariantID != nil {
+				table, id = "product_variants", *item.VariantID
+			}
+			if _, err = conn.ExecContext(ctx, fmt.Sprintf(stockQuery, table), item.Quantity, id); err != nil {
+				return
+			}
+		}
+
+		return
+	})
+
+	return
+}
+
+func (r *ReservationRepository) stockError(ctx context.Context, item reservation.Entity) error {
+	query, args, what := "SELECT EXISTS(SELECT 1 FROM products WHERE id=$1 AND deleted_at IS NULL)", []any{item.ProductID}, "product "+item.ProductID
+	if item.VariantID != nil {
+		query = `SELECT EXISTS(SELECT 1 FROM product_variants v JOIN products p ON p.id = v.product_id
+			WHERE v.id=$1 AND v.product_id=$2 AND p.deleted_at IS NULL)`
+		args = []any{*item.VariantID, item.ProductID}
+		what = "variant " + *item.VariantID
+	}
+
+	var exists bool
+	if err := store.Conn(ctx, r.db).GetContext(ctx, &exists, query, args...); err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%w: %s", store.ErrorNotFound, what)
+	}
+
+	return fmt.Errorf("%w: %s", reservation.ErrorInsufficientStock, what)
+}
+
+// stockKey identifies the row that holds the stock of a reservation line.
+func stockKey(item reservation.Entity) string {
+	if item.VariantID != nil {
+		return item.ProductID + "/" + *item.VariantID
+	}
+	return item.ProductID
+}
@@ -0,0 +1,138 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/yrss1/my-shop/product/internal/domain/variant"
+	"github.com/yrss1/my-shop/product/pkg/store"
+	"strings"
+)
+
+type VariantRepository struct {
+	db *sqlx.DB
+}
+
+func NewVariantRepository(db *sqlx.DB) *VariantRepository {
+	return &VariantRepository{db: db}
+}
+
+// variantColumns falls back to the product price when the variant does not
+// override it.
+const variantColumns = `v.id, v.product_id, v.sku, v.attributes, v.price,
+			COALESCE(v.price, p.price) AS effective_price, v.quantity, v.reserved`
+
+func (r *VariantRepository) List(ctx context.Context, productID string) (dest []variant.Entity, err error) {
+	query := `
+		SELECT ` + variantColumns + `
+		FROM product_variants v
+		JOIN products p ON p.id = v.product_id
+		WHERE v.product_id=$1
+		ORDER BY v.sku`
+
+	err = r.db.SelectContext(ctx, &dest, query, productID)
+
+	return
+}
+
+func (r *VariantRepository) Add(ctx context.Context, data variant.Entity) (id string, err error) {
+	query := `
+		INSERT INTO product_variants (product_id, sku, attributes, price, quantity)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`
+
+	args := []any{data.ProductID, data.SKU, data.Attributes, data.Price, data.Quantity}
+
+	if err = r.db.QueryRowContext(ctx, query, args...).Scan(&id); err != nil {
+		err = r.mapError(err, fmt.Errorf("%w: product", store.ErrorNotFound))
+	}
+
+	return
+}
+
+func (r *VariantRepository) Get(ctx context.Context, productID, id string) (dest variant.Entity, err error) {
+	query := `
+		SELECT ` + variantColumns + `
+		FROM product_variants v
+		JOIN products p ON p.id = v.product_id
+		WHERE v.product_id=$1 AND v.id=$2`
+
+	if err = r.db.GetContext(ctx, &dest, query, productID, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = store.ErrorNotFound
+		}
+	}
+
+	return
+}
+
+func (r *VariantRepository) Update(ctx context.Context, productID, id string, data variant.Entity) (err error) {
+	sets, args := r.prepareArgs(data)
+
+	if len(sets) > 0 {
+		args = append(args, productID, id)
+		sets = append(sets, "updated_at=CURRENT_TIMESTAMP")
+
+		query := fmt.Sprintf("UPDATE product_variants SET %s WHERE product_id=$%d AND id=$%d RETURNING id",
+			strings.Join(sets, ", "), len(args)-1, len(args))
+
+		if err = r.db.QueryRowContext(ctx, query, args...).Scan(&id); err != nil {
+			err = r.mapError(err, fmt.Errorf("%w: product", store.ErrorNotFound))
+		}
+	}
+
+	return
+}
+
+func (r *VariantRepository) Delete(ctx context.Context, productID, id string) (err error) {
+	query := `
+		DELETE FROM product_variants
+		WHERE product_id=$1 AND id=$2
+		RETURNING id`
+
+	if err = r.db.QueryRowContext(ctx, query, productID, id).Scan(&id); err != nil {
+		err = r.mapError(err, variant.ErrorInUse)
+	}
+
+	return
+}
+
+func (r *VariantRepository) prepareArgs(data variant.Entity) (sets []string, args []any) {
+	if data.SKU != nil {
+		args = append(args, data.SKU)
+		sets = append(sets, fmt.Sprintf("sku=$%d", len(args)))
+	}
+
+	if data.Attributes != nil {
+		args = append(args, data.Attributes)
+		sets = append(sets, fmt.Sprintf("attributes=$%d", len(args)))
+	}
+
+	if data.Price != nil {
+		args = append(args, data.Price)
+		sets = append(sets, fmt.Sprintf("price=$%d", len(args)))
+	}
+
+	if data.Quantity != nil {
+		args = append(args, data.Quantity)
+		sets = append(sets, fmt.Sprintf("quantity=$%d", len(args)))
+	}
+
+	return
+}
+
+// mapError translates constraint violations. A foreign key violation means
+// fkErr: a missing product on writes, open reservations on delete.
+func (r *VariantRepository) mapError(err, fkErr error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return store.ErrorNotFound
+	case isViolation(err, uniqueViolation):
+		return variant.ErrorDuplicateSKU
+	case isViolation(err, foreignKeyViolation):
+		return fkErr
+	}
+	return err
+}
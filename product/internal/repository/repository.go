@@ -2,7 +2,12 @@ package repository
 
 import (
 	"fmt"
+	"github.com/yrss1/my-shop/product/internal/domain/category"
+	"github.com/yrss1/my-shop/product/internal/domain/media"
+	"github.com/yrss1/my-shop/product/internal/domain/price"
 	"github.com/yrss1/my-shop/product/internal/domain/product"
+	"github.com/yrss1/my-shop/product/internal/domain/reservation"
+	"github.com/yrss1/my-shop/product/internal/domain/variant"
 	"github.com/yrss1/my-shop/product/internal/repository/postgres"
 	"github.com/yrss1/my-shop/product/pkg/store"
 )
@@ -12,7 +17,13 @@ type Configuration func(r *Repository) error
 type Repository struct {
 	postgres store.SQLX
 
-	Product product.Repository
+	UnitOfWork  store.UnitOfWork
+	Product     product.Repository
+	Category    category.Repository
+	Variant     variant.Repository
+	Media       media.Repository
+	Price       price.Repository
+	Reservation reservation.Repository
 }
 
 func New(configs ...Configuration) (s *Repository, err error) {
@@ -38,7 +49,13 @@ func WithPostgresStore(dbName string) Configuration {
 		//	return
 		//}
 
-		r.Product = postgres.NewProductRepository(r.postgres.Client)
+		r.UnitOfWork = store.NewTxManager(r.postgres.Client)
+		r.Product = postgres.NewProductRepository(r.postgres.Client, r.UnitOfWork)
+		r.Category = postgres.NewCategoryRepository(r.postgres.Client)
+		r.Variant = postgres.NewVariantRepository(r.postgres.Client)
+		r.Media = postgres.NewMediaRepository(r.postgres.Client, r.UnitOfWork)
+		r.Price = postgres.NewPriceRepository(r.postgres.Client, r.UnitOfWork)
+		r.Reservation = postgres.NewReservationRepository(r.postgres.Client, r.UnitOfWork)
 
 		return
 	}
@@ -0,0 +1,149 @@
+package productService
+
+import (
+	"context"
+	"errors"
+	"github.com/yrss1/my-shop/product/internal/domain/category"
+	"github.com/yrss1/my-shop/product/internal/domain/product"
+	"github.com/yrss1/my-shop/product/pkg/log"
+	"github.com/yrss1/my-shop/product/pkg/pagination"
+	"github.com/yrss1/my-shop/product/pkg/store"
+	"go.uber.org/zap"
+)
+
+func (s *Service) ListCategories(ctx context.Context, page pagination.Request) (res []category.Response, next string, err error) {
+	logger := log.LoggerFromContext(ctx).Named("ListCategories")
+
+	data, next, err := s.categoryRepository.List(ctx, page)
+	if err != nil {
+		logger.Error("failed to select", zap.Error(err))
+		return
+	}
+
+	res = category.ParseFromEntities(data)
+
+	return
+}
+
+func (s *Service) CreateCategory(ctx context.Context, req category.Request) (res category.Response, err error) {
+	logger := log.LoggerFromContext(ctx).Named("CreateCategory")
+
+	data := category.Entity{
+		ParentID: req.ParentID,
+		Name:     req.Name,
+		Slug:     req.Slug,
+	}
+
+	data.ID, err = s.categoryRepository.Add(ctx, data)
+	if err != nil {
+		if !isCategoryError(err) {
+			logger.Error("failed to create", zap.Error(err))
+		}
+		return
+	}
+
+	res = category.ParseFromEntity(data)
+
+	return
+}
+
+func (s *Service) GetCategory(ctx context.Context, id string) (res category.Response, err error) {
+	logger := log.LoggerFromContext(ctx).Named("GetCategory").With(zap.String("id", id))
+
+	data, err := s.categoryRepository.Get(ctx, id)
+	if err != nil {
+		if !errors.Is(err, store.ErrorNotFound) {
+			logger.Error("failed to get by id", zap.Error(err))
+		}
+		return
+	}
+
+	res = category.ParseFromEntity(data)
+
+	return
+}
+
+// UpdateCategory renames a category or moves it under another parent. An
+// empty parent_id makes it a root category.
+func (s *Service) UpdateCategory(ctx context.Context, id string, req category.Request) (err error) {
+	logger := log.LoggerFromContext(ctx).Named("UpdateCategory").With(zap.String("id", id))
+
+	if req.ParentID != nil && *req.ParentID != "" {
+		if *req.ParentID == id {
+			return category.ErrorCycle
+		}
+
+		cycle, err := s.categoryRepository.IsDescendant(ctx, *req.ParentID, id)
+		if err != nil {
+			logger.Error("failed to check hierarchy", zap.Error(err))
+			return err
+		}
+		if cycle {
+			return category.ErrorCycle
+		}
+	}
+
+	data := category.Entity{
+		ParentID: req.ParentID,
+		Name:     req.Name,
+		Slug:     req.Slug,
+	}
+
+	err = s.categoryRepository.Update(ctx, id, data)
+	if err != nil && !errors.Is(err, store.ErrorNotFound) && !isCategoryError(err) {
+		logger.Error("failed to update by id", zap.Error(err))
+		return
+	}
+
+	return
+}
+
+func (s *Service) DeleteCategory(ctx context.Context, id string) (err error) {
+	logger := log.LoggerFromContext(ctx).Named("DeleteCategory").With(zap.String("id", id))
+
+	err = s.categoryRepository.Delete(ctx, id)
+	if err != nil && !errors.Is(err, store.ErrorNotFound) && !isCategoryError(err) {
+		logger.Error("failed to delete by id", zap.Error(err))
+		return
+	}
+
+	return
+}
+
+// ListCategoryProducts lists the products of a category and, with
+// descendants, of all its subcategories.
+func (s *Service) ListCategoryProducts(ctx context.Context, id string, descendants bool, page pagination.Request) (res []product.Response, next string, err error) {
+	logger := log.LoggerFromContext(ctx).Named("ListCategoryProducts").With(zap.String("id", id))
+
+	if _, err = s.categoryRepository.Get(ctx, id); err != nil {
+		if !errors.Is(err, store.ErrorNotFound) {
+			logger.Error("failed to get by id", zap.Error(err))
+		}
+		return
+	}
+
+	filter := product.Filter{
+		Categories:  []string{id},
+		Descendants: descendants,
+	}
+
+	data, next, err := s.productRepository.Search(ctx, filter, page)
+	if err != nil {
+		logger.Error("failed to select", zap.Error(err))
+		return
+	}
+
+	res = product.ParseFromEntities(data)
+	if err = s.attachImages(ctx, res); err != nil {
+		logger.Error("failed to select images", zap.Error(err))
+		return
+	}
+
+	return
+}
+
+func isCategoryError(err error) bool {
+	return errors.Is(err, category.ErrorDuplicateSlug) ||
+		errors.Is(err, category.ErrorUnknownParent) ||
+		errors.Is(err, category.ErrorInUse)
+}
@@ -0,0 +1,211 @@
+package productService
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/yrss1/my-shop/product/internal/domain/media"
+	"github.com/yrss1/my-shop/product/internal/domain/product"
+	"github.com/yrss1/my-shop/product/pkg/log"
+	"github.com/yrss1/my-shop/product/pkg/store"
+	"go.uber.org/zap"
+	"image"
+	"image/jpeg"
+	"io"
+	"net/http"
+	"path"
+)
+
+// maxImagePixels bounds the decoded size of an upload, whatever its file size.
+const maxImagePixels = 50_000_000
+
+func (s *Service) ListMedia(ctx context.Context, productID string) (res []media.Response, err error) {
+	logger := log.LoggerFromContext(ctx).Named("ListMedia").With(zap.String("product_id", productID))
+
+	if _, err = s.productRepository.Get(ctx, productID); err != nil {
+		if !errors.Is(err, store.ErrorNotFound) {
+			logger.Error("failed to get product by id", zap.Error(err))
+		}
+		return
+	}
+
+	data, err := s.mediaRepository.List(ctx, productID)
+	if err != nil {
+		logger.Error("failed to select", zap.Error(err))
+		return
+	}
+
+	res = media.ParseFromEntities(data, s.mediaStorage.URL)
+
+	return
+}
+
+// UploadMedia checks that file is a JPEG, PNG or GIF image within the size
+// limit, stores it together with a JPEG thumbnail and appends it to the
+// images of the product.
+func (s *Service) UploadMedia(ctx context.Context, productID string, file io.Reader) (res media.Response, err error) {
+	logger := log.LoggerFromContext(ctx).Named("UploadMedia").With(zap.String("product_id", productID))
+
+	if _, err = s.productRepository.Get(ctx, productID); err != nil {
+		if !errors.Is(err, store.ErrorNotFound) {
+			logger.Error("failed to get product by id", zap.Error(err))
+		}
+		return
+	}
+
+	content, err := io.ReadAll(io.LimitReader(file, s.mediaMaxSize+1))
+	if err != nil {
+		return
+	}
+	if int64(len(content)) > s.mediaMaxSize {
+		return res, fmt.Errorf("%w: the limit is %d bytes", media.ErrorTooLarge, s.mediaMaxSize)
+	}
+
+	contentType := http.DetectContentType(content)
+	ext, ok := media.Extensions[contentType]
+	if !ok {
+		return res, media.ErrorUnsupportedType
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return res, media.ErrorInvalidImage
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return res, fmt.Errorf("%w: %dx%d pixels", media.ErrorTooLarge, config.Width, config.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return res, media.ErrorInvalidImage
+	}
+
+	thumbnail := bytes.Buffer{}
+	if err = jpeg.Encode(&thumbnail, media.Thumbnail(img, s.thumbnailSize), &jpeg.Options{Quality: 85}); err != nil {
+		logger.Error("failed to encode thumbnail", zap.Error(err))
+		return
+	}
+
+	name, err := randomName()
+	if err != nil {
+		return
+	}
+
+	data := media.Entity{
+		ProductID:    productID,
+		ContentType:  contentType,
+		Size:         int64(len(content)),
+		Width:        config.Width,
+		Height:       config.Height,
+		Key:          path.Join("products", productID, name+ext),
+		ThumbnailKey: path.Join("products", productID, name+"_thumb.jpg"),
+	}
+
+	if err = s.mediaStorage.Put(ctx, data.Key, contentType, bytes.NewReader(content)); err != nil {
+		logger.Error("failed to store image", zap.Error(err))
+		return
+	}
+	if err = s.mediaStorage.Put(ctx, data.ThumbnailKey, "image/jpeg", &thumbnail); err != nil {
+		logger.Error("failed to store thumbnail", zap.Error(err))
+		s.deleteBlobs(ctx, data.Key)
+		return
+	}
+
+	// Add returns a zero entity on failure, so the keys are kept to clean up.
+	key, thumbnailKey := data.Key, data.ThumbnailKey
+	if data, err = s.mediaRepository.Add(ctx, data); err != nil {
+		if !errors.Is(err, store.ErrorNotFound) {
+			logger.Error("failed to create", zap.Error(err))
+		}
+		s.deleteBlobs(ctx, key, thumbnailKey)
+		return
+	}
+
+	res = media.ParseFromEntity(data, s.mediaStorage.URL)
+
+	return
+}
+
+func (s *Service) ReorderMedia(ctx context.Context, productID string, req media.OrderRequest) (res []media.Response, err error) {
+	logger := log.LoggerFromContext(ctx).Named("ReorderMedia").With(zap.String("product_id", productID))
+
+	if err = s.mediaRepository.Reorder(ctx, productID, req.IDs); err != nil {
+		if !errors.Is(err, store.ErrorNotFound) && !errors.Is(err, media.ErrorInvalidOrder) {
+			logger.Error("failed to reorder", zap.Error(err))
+		}
+		return
+	}
+
+	data, err := s.mediaRepository.List(ctx, productID)
+	if err != nil {
+		logger.Error("failed to select", zap.Error(err))
+		return
+	}
+
+	res = media.ParseFromEntities(data, s.mediaStorage.URL)
+
+	return
+}
+
+func (s *Service) DeleteMedia(ctx context.Context, productID, id string) (err error) {
+	logger := log.LoggerFromContext(ctx).Named("DeleteMedia").With(zap.String("product_id", productID), zap.String("id", id))
+
+	data, err := s.mediaRepository.Delete(ctx, productID, id)
+	if err != nil {
+		if !errors.Is(err, store.ErrorNotFound) {
+			logger.Error("failed to delete by id", zap.Error(err))
+		}
+		return
+	}
+
+	s.deleteBlobs(ctx, data.Key, data.ThumbnailKey)
+
+	return
+}
+
+// attachImages fills in the ordered images of products with one query.
+func (s *Service) attachImages(ctx context.Context, products []product.Response) (err error) {
+	ids := make([]string, 0, len(products))
+	for _, object := range products {
+		ids = append(ids, object.ID)
+	}
+
+	data, err := s.mediaRepository.List(ctx, ids...)
+	if err != nil {
+		return
+	}
+
+	byProduct := make(map[string][]media.Entity, len(products))
+	for _, object := range data {
+		byProduct[object.ProductID] = append(byProduct[object.ProductID], object)
+	}
+
+	for i := range products {
+		products[i].Images = media.ParseFromEntities(byProduct[products[i].ID], s.mediaStorage.URL)
+	}
+
+	return
+}
+
+// deleteBlobs removes stored files. A file that cannot be removed is only
+// orphaned, so failures are logged and not returned.
+func (s *Service) deleteBlobs(ctx context.Context, keys ...string) {
+	logger := log.LoggerFromContext(ctx).Named("deleteBlobs")
+
+	for _, key := range keys {
+		if err := s.mediaStorage.Delete(ctx, key); err != nil {
+			logger.Error("failed to delete blob", zap.String("key", key), zap.Error(err))
+		}
+	}
+}
+
+func randomName() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
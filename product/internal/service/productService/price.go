@@ -0,0 +1,132 @@
+package productService
+
+import (
+	"context"
+	"errors"
+	"github.com/yrss1/my-shop/product/internal/domain/price"
+	"github.com/yrss1/my-shop/product/pkg/log"
+	"github.com/yrss1/my-shop/product/pkg/pagination"
+	"github.com/yrss1/my-shop/product/pkg/store"
+	"go.uber.org/zap"
+	"time"
+)
+
+func (s *Service) ListPriceHistory(ctx context.Context, productID string, page pagination.Request) (res []price.HistoryResponse, next string, err error) {
+	logger := log.LoggerFromContext(ctx).Named("ListPriceHistory").With(zap.String("product_id", productID))
+
+	if _, err = s.productRepository.Get(ctx, productID); err != nil {
+		if !errors.Is(err, store.ErrorNotFound) {
+			logger.Error("failed to get product by id", zap.Error(err))
+		}
+		return
+	}
+
+	data, next, err := s.priceRepository.ListHistory(ctx, productID, page)
+	if err != nil {
+		logger.Error("failed to select", zap.Error(err))
+		return
+	}
+
+	res = price.ParseFromHistory(data)
+
+	return
+}
+
+func (s *Service) ListPriceSchedules(ctx context.Context, productID string) (res []price.ScheduleResponse, err error) {
+	logger := log.LoggerFromContext(ctx).Named("ListPriceSchedules").With(zap.String("product_id", productID))
+
+	if _, err = s.productRepository.Get(ctx, productID); err != nil {
+		if !errors.Is(err, store.ErrorNotFound) {
+			logger.Error("failed to get product by id", zap.Error(err))
+		}
+		return
+	}
+
+	data, err := s.priceRepository.ListSchedules(ctx, productID)
+	if err != nil {
+		logger.Error("failed to select", zap.Error(err))
+		return
+	}
+
+	res = price.ParseFromSchedules(data)
+
+	return
+}
+
+func (s *Service) SchedulePrice(ctx context.Context, productID string, req price.ScheduleRequest) (res price.ScheduleResponse, err error) {
+	logger := log.LoggerFromContext(ctx).Named("SchedulePrice").With(zap.String("product_id", productID))
+
+	data := price.Schedule{
+		ProductID: productID,
+		Price:     *req.Price,
+		StartsAt:  *req.StartsAt,
+		EndsAt:    req.EndsAt,
+	}
+
+	data, err = s.priceRepository.AddSchedule(ctx, data)
+	if err != nil {
+		if !errors.Is(err, store.ErrorNotFound) && !errors.Is(err, price.ErrorOverlap) {
+			logger.Error("failed to create", zap.Error(err))
+		}
+		return
+	}
+
+	res = price.ParseFromSchedule(data)
+
+	return
+}
+
+func (s *Service) CancelPriceSchedule(ctx context.Context, productID, id string) (res price.ScheduleResponse, err error) {
+	logger := log.LoggerFromContext(ctx).Named("CancelPriceSchedule").With(zap.String("product_id", productID), zap.String("id", id))
+
+	data, err := s.priceRepository.CancelSchedule(ctx, productID, id)
+	if err != nil {
+		if !errors.Is(err, store.ErrorNotFound) && !errors.Is(err, price.ErrorNotCancellable) {
+			logger.Error("failed to cancel", zap.Error(err))
+		}
+		return
+	}
+
+	res = price.ParseFromSchedule(data)
+
+	return
+}
+
+// RunPriceScheduler applies due price schedules every interval until ctx is
+// done. Sales that ended are closed before new schedules start, so that a sale
+// followed directly by another hands the price over in one run.
+func (s *Service) RunPriceScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.applyPriceSchedules(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) applyPriceSchedules(ctx context.Context, now time.Time) {
+	logger := log.LoggerFromContext(ctx).Named("RunPriceScheduler")
+
+	ended, err := s.priceRepository.EndDue(ctx, now)
+	if err != nil && ctx.Err() == nil {
+		logger.Error("failed to end schedules", zap.Error(err))
+	}
+
+	started, err := s.priceRepository.StartDue(ctx, now)
+	if err != nil && ctx.Err() == nil {
+		logger.Error("failed to start schedules", zap.Error(err))
+	}
+
+	for _, data := range append(ended, started...) {
+		logger.Info("price schedule applied",
+			zap.String("id", data.ID),
+			zap.String("product_id", data.ProductID),
+			zap.String("status", data.Status))
+	}
+}
@@ -3,22 +3,29 @@ package productService
 import (
 	"context"
 	"errors"
+	"github.com/yrss1/my-shop/product/internal/domain/media"
 	"github.com/yrss1/my-shop/product/internal/domain/product"
+	"github.com/yrss1/my-shop/product/internal/domain/variant"
 	"github.com/yrss1/my-shop/product/pkg/log"
+	"github.com/yrss1/my-shop/product/pkg/pagination"
 	"github.com/yrss1/my-shop/product/pkg/store"
 	"go.uber.org/zap"
 )
 
-func (s *Service) ListProducts(ctx context.Context) (res []product.Response, err error) {
+func (s *Service) ListProducts(ctx context.Context, page pagination.Request) (res []product.Response, next string, err error) {
 	logger := log.LoggerFromContext(ctx).Named("ListProducts")
 
-	data, err := s.productRepository.List(ctx)
+	data, next, err := s.productRepository.List(ctx, page)
 	if err != nil {
 		logger.Error("failed to select", zap.Error(err))
 		return
 	}
 
 	res = product.ParseFromEntities(data)
+	if err = s.attachImages(ctx, res); err != nil {
+		logger.Error("failed to select images", zap.Error(err))
+		return
+	}
 
 	return
 }
@@ -27,20 +34,30 @@ func (s *Service) CreateProduct(ctx context.Context, req product.Request) (res p
 	logger := log.LoggerFromContext(ctx).Named("CreateProduct")
 
 	data := product.Entity{
+		SKU:         req.SKU,
 		Name:        req.Name,
 		Description: req.Description,
 		Price:       req.Price,
-		Category:    req.Category,
+		CategoryID:  req.CategoryID,
 		Quantity:    req.Quantity,
 	}
 
 	data.ID, err = s.productRepository.Add(ctx, data)
 	if err != nil {
-		logger.Error("failed to create", zap.Error(err))
+		if !errors.Is(err, product.ErrorUnknownCategory) && !errors.Is(err, product.ErrorDuplicate) {
+			logger.Error("failed to create", zap.Error(err))
+		}
+		return
+	}
+
+	// read back the category name
+	if data, err = s.productRepository.Get(ctx, data.ID); err != nil {
+		logger.Error("failed to get by id", zap.Error(err))
 		return
 	}
 
 	res = product.ParseFromEntity(data)
+	res.Images = []media.Response{}
 
 	return
 }
@@ -54,24 +71,43 @@ func (s *Service) GetProduct(ctx context.Context, id string) (res product.Respon
 		return
 	}
 
+	variants, err := s.variantRepository.List(ctx, id)
+	if err != nil {
+		logger.Error("failed to select variants", zap.Error(err))
+		return
+	}
+
+	images, err := s.mediaRepository.List(ctx, id)
+	if err != nil {
+		logger.Error("failed to select images", zap.Error(err))
+		return
+	}
+
 	res = product.ParseFromEntity(data)
+	res.Images = media.ParseFromEntities(images, s.mediaStorage.URL)
+	res.Variants = variant.ParseFromEntities(variants)
 
 	return
 }
 
-func (s *Service) UpdateProduct(ctx context.Context, id string, req product.Request) (err error) {
+// UpdateProduct updates the product at version, or at any version when version
+// is nil.
+func (s *Service) UpdateProduct(ctx context.Context, id string, version *int, req product.Request) (err error) {
 	logger := log.LoggerFromContext(ctx).Named("UpdateProduct").With(zap.String("id", id))
 
 	data := product.Entity{
+		SKU:         req.SKU,
 		Name:        req.Name,
 		Description: req.Description,
 		Price:       req.Price,
-		Category:    req.Category,
+		CategoryID:  req.CategoryID,
 		Quantity:    req.Quantity,
+		Version:     version,
 	}
 
 	err = s.productRepository.Update(ctx, id, data)
-	if err != nil && !errors.Is(err, store.ErrorNotFound) {
+	if err != nil && !errors.Is(err, store.ErrorNotFound) && !errors.Is(err, store.ErrorVersionMismatch) &&
+		!errors.Is(err, product.ErrorUnknownCategory) && !errors.Is(err, product.ErrorDuplicate) {
 		logger.Error("failed to update by id", zap.Error(err))
 		return
 	}
@@ -79,11 +115,12 @@ func (s *Service) UpdateProduct(ctx context.Context, id string, req product.Requ
 	return
 }
 
-func (s *Service) DeleteProduct(ctx context.Context, id string) (err error) {
+func (s *Service) DeleteProduct(ctx context.Context, id string, version *int) (err error) {
 	logger := log.LoggerFromContext(ctx).Named("DeleteProduct").With(zap.String("id", id))
 
-	err = s.productRepository.Delete(ctx, id)
-	if err != nil && !errors.Is(err, store.ErrorNotFound) {
+	// the product is only hidden, its images stay for a restore
+	err = s.productRepository.Delete(ctx, id, version)
+	if err != nil && !errors.Is(err, store.ErrorNotFound) && !errors.Is(err, store.ErrorVersionMismatch) {
 		logger.Error("failed to delete by id", zap.Error(err))
 		return
 	}
@@ -91,27 +128,51 @@ func (s *Service) DeleteProduct(ctx context.Context, id string) (err error) {
 	return
 }
 
-func (s *Service) SearchProduct(ctx context.Context, req product.Request) (res []product.Response, err error) {
+func (s *Service) RestoreProduct(ctx context.Context, id string) (err error) {
+	logger := log.LoggerFromContext(ctx).Named("RestoreProduct").With(zap.String("id", id))
+
+	err = s.productRepository.Restore(ctx, id)
+	if err != nil && !errors.Is(err, store.ErrorNotFound) && !errors.Is(err, product.ErrorDuplicate) {
+		logger.Error("failed to restore by id", zap.Error(err))
+		return
+	}
+
+	return
+}
+
+func (s *Service) SearchProduct(ctx context.Context, req product.SearchRequest, page pagination.Request) (res []product.Response, next string, err error) {
 	logger := log.LoggerFromContext(ctx).Named("SearchProduct")
 
 	if req.Name != nil {
 		logger = logger.With(zap.String("name", *(req.Name)))
 	}
-	if req.Category != nil {
-		logger = logger.With(zap.String("category", *(req.Category)))
+	if req.Query != nil {
+		logger = logger.With(zap.String("q", *(req.Query)))
+	}
+	if len(req.Categories) > 0 {
+		logger = logger.With(zap.Strings("category", req.Categories))
 	}
 
-	searchData := product.Entity{
-		Name:     req.Name,
-		Category: req.Category,
+	searchData := product.Filter{
+		Name:        req.Name,
+		Query:       req.Query,
+		Categories:  req.Categories,
+		Descendants: req.Descendants,
+		MinPrice:    req.MinPrice,
+		MaxPrice:    req.MaxPrice,
+		InStock:     req.InStock,
 	}
-	data, err := s.productRepository.Search(ctx, searchData)
+	data, next, err := s.productRepository.Search(ctx, searchData, page)
 	if err != nil {
 		logger.Error("failed to search products", zap.Error(err))
 		return
 	}
 
 	res = product.ParseFromEntities(data)
+	if err = s.attachImages(ctx, res); err != nil {
+		logger.Error("failed to select images", zap.Error(err))
+		return
+	}
 
 	return
 }
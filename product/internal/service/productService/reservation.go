@@ -0,0 +1,64 @@
+package productService
+
+import (
+	"context"
+	"errors"
+	"github.com/yrss1/my-shop/product/internal/domain/reservation"
+	"github.com/yrss1/my-shop/product/pkg/log"
+	"github.com/yrss1/my-shop/product/pkg/store"
+	"go.uber.org/zap"
+)
+
+func (s *Service) ReserveStock(ctx context.Context, req reservation.Request) (res []reservation.Response, err error) {
+	logger := log.LoggerFromContext(ctx).Named("ReserveStock").With(zap.String("order_id", req.OrderID))
+
+	items := make([]reservation.Entity, 0, len(req.Items))
+	for _, item := range req.Items {
+		items = append(items, reservation.Entity{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Quantity:  item.Quantity,
+		})
+	}
+
+	data, err := s.reservationRepository.Reserve(ctx, req.OrderID, items)
+	if err != nil {
+		if !errors.Is(err, reservation.ErrorInsufficientStock) && !errors.Is(err, reservation.ErrorAlreadyReserved) &&
+			!errors.Is(err, store.ErrorNotFound) {
+			logger.Error("failed to reserve", zap.Error(err))
+		}
+		return
+	}
+
+	res = reservation.ParseFromEntities(data)
+
+	return
+}
+
+func (s *Service) CommitStock(ctx context.Context, orderID string) (res []reservation.Response, err error) {
+	logger := log.LoggerFromContext(ctx).Named("CommitStock").With(zap.String("order_id", orderID))
+
+	data, err := s.reservationRepository.Commit(ctx, orderID)
+	if err != nil && !errors.Is(err, store.ErrorNotFound) {
+		logger.Error("failed to commit", zap.Error(err))
+		return
+	}
+
+	res = reservation.ParseFromEntities(data)
+
+	return
+}
+
+func (s *Service) ReleaseStock(ctx context.Context, orderID string) (res []reservation.Response, err error) {
+	logger := log.LoggerFromContext(ctx).Named("ReleaseStock").With(zap.String("order_id", orderID))
+
+	data, err := s.reservationRepository.Release(ctx, orderID)
+	if err != nil && !errors.Is(err, store.ErrorNotFound) {
+		logger.Error("failed to release", zap.Error(err))
+		return
+	}
+
+	res = reservation.ParseFromEntities(data)
+
+	return
+}
@@ -1,11 +1,30 @@
 package productService
 
-import "github.com/yrss1/my-shop/product/internal/domain/product"
+import (
+	"github.com/yrss1/my-shop/product/internal/domain/category"
+	"github.com/yrss1/my-shop/product/internal/domain/media"
+	"github.com/yrss1/my-shop/product/internal/domain/price"
+	"github.com/yrss1/my-shop/product/internal/domain/product"
+	"github.com/yrss1/my-shop/product/internal/domain/reservation"
+	"github.com/yrss1/my-shop/product/internal/domain/variant"
+	"github.com/yrss1/my-shop/product/pkg/store"
+)
 
 type Configuration func(s *Service) error
 
 type Service struct {
-	productRepository product.Repository
+	unitOfWork store.UnitOfWork
+
+	productRepository     product.Repository
+	categoryRepository    category.Repository
+	variantRepository     variant.Repository
+	reservationRepository reservation.Repository
+	mediaRepository       media.Repository
+	priceRepository       price.Repository
+
+	mediaStorage  media.Storage
+	mediaMaxSize  int64
+	thumbnailSize int
 }
 
 func New(configs ...Configuration) (s *Service, err error) {
@@ -20,9 +39,62 @@ func New(configs ...Configuration) (s *Service, err error) {
 	return
 }
 
+func WithUnitOfWork(unitOfWork store.UnitOfWork) Configuration {
+	return func(s *Service) error {
+		s.unitOfWork = unitOfWork
+		return nil
+	}
+}
+
 func WithProductRepository(productRepository product.Repository) Configuration {
 	return func(s *Service) error {
 		s.productRepository = productRepository
 		return nil
 	}
 }
+
+func WithReservationRepository(reservationRepository reservation.Repository) Configuration {
+	return func(s *Service) error {
+		s.reservationRepository = reservationRepository
+		return nil
+	}
+}
+
+func WithCategoryRepository(categoryRepository category.Repository) Configuration {
+	return func(s *Service) error {
+		s.categoryRepository = categoryRepository
+		return nil
+	}
+}
+
+func WithVariantRepository(variantRepository variant.Repository) Configuration {
+	return func(s *Service) error {
+		s.variantRepository = variantRepository
+		return nil
+	}
+}
+
+func WithMediaRepository(mediaRepository media.Repository) Configuration {
+	return func(s *Service) error {
+		s.mediaRepository = mediaRepository
+		return nil
+	}
+}
+
+func WithPriceRepository(priceRepository price.Repository) Configuration {
+	return func(s *Service) error {
+		s.priceRepository = priceRepository
+		return nil
+	}
+}
+
+// WithMediaStorage sets where images are stored, the largest accepted upload
+// in bytes and the size of the longer side of thumbnails in pixels.
+func WithMediaStorage(storage media.Storage, maxSize int64, thumbnailSize int) Configuration {
+	return func(s *Service) error {
+		s.mediaStorage = storage
+		s.mediaMaxSize = maxSize
+		s.thumbnailSize = thumbnailSize
+		return nil
+	}
+}
@@ -0,0 +1,92 @@
+package productService
+
+import (
+	"context"
+	"errors"
+	"github.com/yrss1/my-shop/product/internal/domain/product"
+	"github.com/yrss1/my-shop/product/pkg/log"
+	"go.uber.org/zap"
+)
+
+// errImportFailed rolls back an import with failed rows.
+var errImportFailed = errors.New("import has failed rows")
+
+// ImportProducts validates every row and upserts it by SKU or name. All rows
+// run in one transaction that is only committed when none of them failed, so
+// the report always covers the whole file.
+func (s *Service) ImportProducts(ctx context.Context, rows []product.ImportRow) (res product.ImportResponse, err error) {
+	logger := log.LoggerFromContext(ctx).Named("ImportProducts").With(zap.Int("rows", len(rows)))
+
+	err = s.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		res = product.ImportResponse{Rows: make([]product.ImportResult, 0, len(rows))}
+
+		for _, row := range rows {
+			result := product.ImportResult{Line: row.Line, Key: row.Key()}
+
+			created, err := s.importRow(ctx, row)
+			switch {
+			case err != nil:
+				result.Action, result.Error = product.ActionFailed, err.Error()
+				res.Failed++
+			case created:
+				result.Action = product.ActionCreated
+				res.Created++
+			default:
+				result.Action = product.ActionUpdated
+				res.Updated++
+			}
+
+			res.Rows = append(res.Rows, result)
+		}
+
+		if res.Failed > 0 {
+			return errImportFailed
+		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, errImportFailed):
+		err = nil
+	case err != nil:
+		logger.Error("failed to import", zap.Error(err))
+	default:
+		res.Committed = true
+	}
+
+	return
+}
+
+func (s *Service) importRow(ctx context.Context, row product.ImportRow) (created bool, err error) {
+	if row.Err != nil {
+		return false, row.Err
+	}
+
+	if err = row.Request.Validate(); err != nil {
+		return
+	}
+
+	data := product.Entity{
+		SKU:         row.Request.SKU,
+		Name:        row.Request.Name,
+		Description: row.Request.Description,
+		Price:       row.Request.Price,
+		CategoryID:  row.Request.CategoryID,
+		Quantity:    row.Request.Quantity,
+	}
+
+	_, created, err = s.productRepository.Upsert(ctx, data)
+
+	return
+}
+
+// ExportProducts writes every product to enc while reading the catalogue.
+func (s *Service) ExportProducts(ctx context.Context, enc product.Encoder) (err error) {
+	logger := log.LoggerFromContext(ctx).Named("ExportProducts")
+
+	if err = s.productRepository.Export(ctx, enc.Encode); err != nil {
+		logger.Error("failed to export", zap.Error(err))
+		return
+	}
+
+	return enc.Flush()
+}
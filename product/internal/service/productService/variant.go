@@ -0,0 +1,108 @@
+package productService
+
+import (
+	"context"
+	"errors"
+	"github.com/yrss1/my-shop/product/internal/domain/variant"
+	"github.com/yrss1/my-shop/product/pkg/log"
+	"github.com/yrss1/my-shop/product/pkg/store"
+	"go.uber.org/zap"
+)
+
+func (s *Service) ListVariants(ctx context.Context, productID string) (res []variant.Response, err error) {
+	logger := log.LoggerFromContext(ctx).Named("ListVariants").With(zap.String("product_id", productID))
+
+	if _, err = s.productRepository.Get(ctx, productID); err != nil {
+		if !errors.Is(err, store.ErrorNotFound) {
+			logger.Error("failed to get product by id", zap.Error(err))
+		}
+		return
+	}
+
+	data, err := s.variantRepository.List(ctx, productID)
+	if err != nil {
+		logger.Error("failed to select", zap.Error(err))
+		return
+	}
+
+	res = variant.ParseFromEntities(data)
+
+	return
+}
+
+func (s *Service) CreateVariant(ctx context.Context, productID string, req variant.Request) (res variant.Response, err error) {
+	logger := log.LoggerFromContext(ctx).Named("CreateVariant").With(zap.String("product_id", productID))
+
+	data := variant.Entity{
+		ProductID:  productID,
+		SKU:        req.SKU,
+		Attributes: req.Attributes,
+		Price:      req.Price,
+		Quantity:   req.Quantity,
+	}
+
+	data.ID, err = s.variantRepository.Add(ctx, data)
+	if err != nil {
+		if !errors.Is(err, store.ErrorNotFound) && !errors.Is(err, variant.ErrorDuplicateSKU) {
+			logger.Error("failed to create", zap.Error(err))
+		}
+		return
+	}
+
+	// read back the effective price
+	if data, err = s.variantRepository.Get(ctx, productID, data.ID); err != nil {
+		logger.Error("failed to get by id", zap.Error(err))
+		return
+	}
+
+	res = variant.ParseFromEntity(data)
+
+	return
+}
+
+func (s *Service) GetVariant(ctx context.Context, productID, id string) (res variant.Response, err error) {
+	logger := log.LoggerFromContext(ctx).Named("GetVariant").With(zap.String("product_id", productID), zap.String("id", id))
+
+	data, err := s.variantRepository.Get(ctx, productID, id)
+	if err != nil {
+		if !errors.Is(err, store.ErrorNotFound) {
+			logger.Error("failed to get by id", zap.Error(err))
+		}
+		return
+	}
+
+	res = variant.ParseFromEntity(data)
+
+	return
+}
+
+func (s *Service) UpdateVariant(ctx context.Context, productID, id string, req variant.Request) (err error) {
+	logger := log.LoggerFromContext(ctx).Named("UpdateVariant").With(zap.String("product_id", productID), zap.String("id", id))
+
+	data := variant.Entity{
+		SKU:        req.SKU,
+		Attributes: req.Attributes,
+		Price:      req.Price,
+		Quantity:   req.Quantity,
+	}
+
+	err = s.variantRepository.Update(ctx, productID, id, data)
+	if err != nil && !errors.Is(err, store.ErrorNotFound) && !errors.Is(err, variant.ErrorDuplicateSKU) {
+		logger.Error("failed to update by id", zap.Error(err))
+		return
+	}
+
+	return
+}
+
+func (s *Service) DeleteVariant(ctx context.Context, productID, id string) (err error) {
+	logger := log.LoggerFromContext(ctx).Named("DeleteVariant").With(zap.String("product_id", productID), zap.String("id", id))
+
+	err = s.variantRepository.Delete(ctx, productID, id)
+	if err != nil && !errors.Is(err, store.ErrorNotFound) && !errors.Is(err, variant.ErrorInUse) {
+		logger.Error("failed to delete by id", zap.Error(err))
+		return
+	}
+
+	return
+}
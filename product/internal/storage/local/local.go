@@ -0,0 +1,90 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Storage keeps blobs as files below a directory that is served at url.
+type Storage struct {
+	dir string
+	url string
+}
+
+func New(dir, url string) (s *Storage, err error) {
+	if dir == "" {
+		err = errors.New("local storage: undefined directory")
+		return
+	}
+
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		return
+	}
+
+	s = &Storage{
+		dir: dir,
+		url: strings.TrimRight(url, "/"),
+	}
+
+	return
+}
+
+// Put writes r to a temporary file first, so that a failed upload never
+// leaves a partial file under key.
+func (s *Storage) Put(ctx context.Context, key, contentType string, r io.Reader) (err error) {
+	name := s.path(key)
+	if err = os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(file.Name())
+		}
+	}()
+
+	if _, err = io.Copy(file, r); err != nil {
+		file.Close()
+		return
+	}
+	if err = file.Close(); err != nil {
+		return
+	}
+	if err = os.Chmod(file.Name(), 0o644); err != nil {
+		return
+	}
+
+	return os.Rename(file.Name(), name)
+}
+
+func (s *Storage) Delete(ctx context.Context, key string) (err error) {
+	// a blank key maps to the storage directory itself
+	if strings.Trim(path.Clean("/"+key), "/") == "" {
+		return errors.New("local storage: blank key")
+	}
+
+	if err = os.Remove(s.path(key)); errors.Is(err, os.ErrNotExist) {
+		err = nil
+	}
+
+	return
+}
+
+func (s *Storage) URL(key string) string {
+	return s.url + "/" + strings.TrimLeft(path.Clean("/"+key), "/")
+}
+
+// path maps key below the storage directory; cleaning it as an absolute path
+// drops any .. that would escape it.
+func (s *Storage) path(key string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(path.Clean("/"+key)))
+}
@@ -0,0 +1,128 @@
+package pagination
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor Cursor
+	}{
+		{"id", Cursor{Sort: "id", ID: "42"}},
+		{"ascending", Cursor{Sort: "price", Key: "19.99", ID: "7"}},
+		{"descending", Cursor{Sort: "-created_at", Key: "2024-08-17 10:00:00", ID: "c9a0"}},
+		{"unicode key", Cursor{Sort: "name", Key: "Kaffee & Crème/ü", ID: "1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decode(tt.cursor.encode())
+			if err != nil {
+				t.Fatalf("decode() error = %v", err)
+			}
+			if !reflect.DeepEqual(*got, tt.cursor) {
+				t.Errorf("decode() = %+v, want %+v", *got, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"not base64", "not a cursor!"},
+		{"padded base64", "eyJzIjoiaWQifQ=="},
+		{"not json", "bm90IGpzb24"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decode(tt.value); err == nil {
+				t.Errorf("decode(%q) succeeded, want an error", tt.value)
+			}
+		})
+	}
+}
+
+func TestParseCursor(t *testing.T) {
+	fields := []string{"id", "price"}
+
+	tests := []struct {
+		name    string
+		sort    string
+		cursor  string
+		want    *Cursor
+		wantErr error
+	}{
+		{"no cursor", "price", "", nil, nil},
+		{"same sort", "price", Cursor{Sort: "price", Key: "5", ID: "1"}.encode(), &Cursor{Sort: "price", Key: "5", ID: "1"}, nil},
+		{"same descending sort", "-price", Cursor{Sort: "-price", Key: "5", ID: "1"}.encode(), &Cursor{Sort: "-price", Key: "5", ID: "1"}, nil},
+		{"default sort", "", Cursor{Sort: "id", ID: "1"}.encode(), &Cursor{Sort: "id", ID: "1"}, nil},
+		{"other sort", "price", Cursor{Sort: "id", ID: "1"}.encode(), nil, ErrorInvalidCursor},
+		{"other direction", "-price", Cursor{Sort: "price", Key: "5", ID: "1"}.encode(), nil, ErrorInvalidCursor},
+		{"garbage", "price", "%%%", nil, ErrorInvalidCursor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := Parse("", tt.sort, tt.cursor, fields...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if !reflect.DeepEqual(req.Cursor, tt.want) {
+				t.Errorf("Parse() cursor = %+v, want %+v", req.Cursor, tt.want)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	type row struct{ key, id string }
+	key := func(r row) (string, string) { return r.key, r.id }
+	rows := []row{{"1", "a"}, {"2", "b"}, {"3", "c"}}
+
+	tests := []struct {
+		name     string
+		req      Request
+		wantRows int
+		want     *Cursor
+	}{
+		{"unlimited", Request{}, 3, nil},
+		{"last page", Request{Limit: 3, Sort: "price"}, 3, nil},
+		{"more pages", Request{Limit: 2, Sort: "price"}, 2, &Cursor{Sort: "price", Key: "2", ID: "b"}},
+		{"more pages descending", Request{Limit: 1, Sort: "price", Desc: true}, 1, &Cursor{Sort: "-price", Key: "1", ID: "a"}},
+		{"more pages by id", Request{Limit: 2}, 2, &Cursor{Sort: "id", Key: "2", ID: "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, next := Next(tt.req, rows, key)
+			if len(got) != tt.wantRows {
+				t.Errorf("Next() kept %d rows, want %d", len(got), tt.wantRows)
+			}
+
+			if tt.want == nil {
+				if next != "" {
+					t.Errorf("Next() cursor = %q, want none", next)
+				}
+				return
+			}
+
+			cursor, err := decode(next)
+			if err != nil {
+				t.Fatalf("decode() error = %v", err)
+			}
+			if !reflect.DeepEqual(cursor, tt.want) {
+				t.Errorf("Next() cursor = %+v, want %+v", cursor, tt.want)
+			}
+		})
+	}
+}
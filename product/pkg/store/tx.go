@@ -0,0 +1,97 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+)
+
+// Querier is the subset of sqlx shared by *sqlx.DB and *sqlx.Tx.
+type Querier interface {
+	GetContext(ctx context.Context, dest any, query string, args ...any) error
+	SelectContext(ctx context.Context, dest any, query string, args ...any) error
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	QueryxContext(ctx context.Context, query string, args ...any) (*sqlx.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// UnitOfWork runs fn inside a single transaction. Repositories called with
+// the ctx passed to fn join that transaction instead of opening their own.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type transaction struct{}
+
+type TxManager struct {
+	db *sqlx.DB
+}
+
+func NewTxManager(db *sqlx.DB) *TxManager {
+	return &TxManager{db: db}
+}
+
+func (m *TxManager) Do(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(transaction{}).(*sqlx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := m.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+				err = fmt.Errorf("%w (rollback: %v)", err, rbErr)
+			}
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	err = fn(context.WithValue(ctx, transaction{}, tx))
+
+	return
+}
+
+// Conn returns the transaction bound to ctx by TxManager.Do, or db when
+// there is none.
+func Conn(ctx context.Context, db *sqlx.DB) Querier {
+	if tx, ok := ctx.Value(transaction{}).(*sqlx.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+// Savepoint runs fn inside a savepoint of the transaction bound to ctx. When
+// fn fails only its own statements are rolled back and the transaction stays
+// usable. Without a transaction fn runs as is.
+func Savepoint(ctx context.Context, name string, fn func(ctx context.Context) error) (err error) {
+	tx, ok := ctx.Value(transaction{}).(*sqlx.Tx)
+	if !ok {
+		return fn(ctx)
+	}
+
+	if _, err = tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return
+	}
+
+	if err = fn(ctx); err != nil {
+		if _, rbErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); rbErr != nil {
+			err = fmt.Errorf("%w (rollback to savepoint: %v)", err, rbErr)
+		}
+		return
+	}
+
+	_, err = tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+
+	return
+}
@@ -0,0 +1,4 @@
+BEGIN;
+DELETE FROM users WHERE deleted_at IS NOT NULL;
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
+END;
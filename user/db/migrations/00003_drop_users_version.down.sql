@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE users DROP COLUMN IF EXISTS version;
+END;
@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
+END;
@@ -0,0 +1,12 @@
+DO $$
+    BEGIN
+        -- CONSTRAINTS --
+        -- an email only has to be unique among users that are not deleted, so
+        -- that it can be registered again once its user is deleted
+        ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+
+        -- INDEXES --
+        CREATE UNIQUE INDEX IF NOT EXISTS users_email_live_idx ON users (email) WHERE deleted_at IS NULL;
+
+        COMMIT;
+    END $$;
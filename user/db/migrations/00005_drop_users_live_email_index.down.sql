@@ -0,0 +1,4 @@
+BEGIN;
+DROP INDEX IF EXISTS users_email_live_idx;
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
+END;
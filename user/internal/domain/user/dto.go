@@ -2,8 +2,12 @@ package user
 
 import (
 	"errors"
+	"time"
 )
 
+// SortFields are the fields user lists can be sorted by.
+var SortFields = []string{"id", "created_at", "name", "email"}
+
 type Request struct {
 	ID       string  `json:"id"`
 	Name     *string `json:"name"`
@@ -26,6 +30,16 @@ func (s *Request) Validate() error {
 		return errors.New("password: cannot be blank")
 	}
 
+	return s.validatePassword()
+}
+
+// validatePassword rejects passwords bcrypt can't hash. Its input is limited to
+// 72 bytes.
+func (s *Request) validatePassword() error {
+	if s.Password != nil && len(*s.Password) > 72 {
+		return errors.New("password: cannot be longer than 72 bytes")
+	}
+
 	return nil
 }
 
@@ -35,6 +49,7 @@ func (s *Request) IsEmpty(check string) error {
 			s.Address == nil && s.Role == nil && s.Password == nil {
 			return errors.New("data cannot be blank")
 		}
+		return s.validatePassword()
 	}
 
 	if check == "search" {
@@ -52,6 +67,10 @@ type Response struct {
 	Email   string `json:"email"`
 	Address string `json:"address"`
 	Role    string `json:"role"`
+	Version int    `json:"version"`
+
+	EmailVerified bool       `json:"email_verified"`
+	DeletedAt     *time.Time `json:"deleted_at,omitempty"`
 }
 
 func ParseFromEntity(data Entity) (res Response) {
@@ -66,6 +85,11 @@ func ParseFromEntity(data Entity) (res Response) {
 	if data.Role != nil {
 		res.Role = *data.Role
 	}
+	if data.Version != nil {
+		res.Version = *data.Version
+	}
+	res.EmailVerified = data.EmailVerifiedAt != nil
+	res.DeletedAt = data.DeletedAt
 	return
 }
 
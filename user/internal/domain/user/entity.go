@@ -1,5 +1,7 @@
 package user
 
+import "time"
+
 type Entity struct {
 	ID       string  `db:"id"`
 	Name     *string `db:"name"`
@@ -7,4 +9,8 @@ type Entity struct {
 	Password *string `db:"password"`
 	Address  *string `db:"address"`
 	Role     *string `db:"role"`
+	Version  *int    `db:"version"`
+
+	EmailVerifiedAt *time.Time `db:"email_verified_at"`
+	DeletedAt       *time.Time `db:"deleted_at"`
 }
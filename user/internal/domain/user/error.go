@@ -0,0 +1,8 @@
+package user
+
+import "errors"
+
+var (
+	ErrorDuplicateEmail = errors.New("email is already in use")
+	ErrorWrongPassword  = errors.New("wrong password")
+)
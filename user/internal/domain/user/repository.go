@@ -1,13 +1,18 @@
 package user
 
-import "context"
+import (
+	"context"
+	"github.com/yrss1/my-shop/user/pkg/pagination"
+)
 
 type Repository interface {
-	List(ctx context.Context) (dest []Entity, err error)
+	List(ctx context.Context, page pagination.Request) (dest []Entity, next string, err error)
 	Add(ctx context.Context, data Entity) (id string, err error)
 	Get(ctx context.Context, id string) (dest Entity, err error)
 	Update(ctx context.Context, id string, dest Entity) (err error)
-	Delete(ctx context.Context, id string) (err error)
-	Search(ctx context.Context, data Entity) (dest []Entity, err error)
+	Delete(ctx context.Context, id string, version *int) (err error)
+	Restore(ctx context.Context, id string) (err error)
+	MarkEmailVerified(ctx context.Context, id string) (err error)
+	Search(ctx context.Context, data Entity, page pagination.Request) (dest []Entity, next string, err error)
 	GetByEmail(ctx context.Context, id string) (dest Entity, err error)
 }
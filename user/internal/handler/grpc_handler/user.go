@@ -3,11 +3,11 @@ package grpc_handler
 import (
 	"context"
 	"errors"
+	pb "github.com/yrss1/my-shop/proto/user"
 	"github.com/yrss1/my-shop/user/internal/domain/user"
 	"github.com/yrss1/my-shop/user/internal/service/userService"
 	"github.com/yrss1/my-shop/user/pkg/helpers"
 	"github.com/yrss1/my-shop/user/pkg/store"
-	pb "github.com/yrss1/proto-definitions/user"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -21,6 +21,22 @@ func NewUserServiceServer(s *userService.Service) *UserServiceServer {
 	return &UserServiceServer{userService: s}
 }
 
+func (s *UserServiceServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (res *pb.UserResponse, err error) {
+	found, err := s.userService.GetUser(ctx, req.UserId)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrorNotFound):
+			return nil, status.Errorf(codes.NotFound, req.UserId)
+		default:
+			return nil, status.Errorf(codes.Internal, "internal server error: %v", err)
+		}
+	}
+
+	res = &pb.UserResponse{User: parseToProto(found)}
+
+	return
+}
+
 func (s *UserServiceServer) GetUserByEmail(ctx context.Context, req *pb.GetUserByEmailRequest) (res *pb.UserResponse, err error) {
 	user, err := s.userService.GetUserByEmail(ctx, req.Email)
 	if err != nil {
@@ -30,18 +46,9 @@ func (s *UserServiceServer) GetUserByEmail(ctx context.Context, req *pb.GetUserB
 		default:
 			return nil, status.Errorf(codes.Internal, "internal server error: %v", err)
 		}
-		return
 	}
 
-	res = &pb.UserResponse{
-		User: &pb.Response{
-			Id:      user.ID,
-			Name:    user.Name,
-			Email:   user.Email,
-			Address: user.Address,
-			Role:    user.Role,
-		},
-	}
+	res = &pb.UserResponse{User: parseToProto(user)}
 
 	return
 }
@@ -65,17 +72,81 @@ func (s *UserServiceServer) RegisterUser(ctx context.Context, req *pb.UserReques
 
 	createdUser, err := s.userService.CreateUser(ctx, userRequest)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "internal server error: %v", err)
+		switch {
+		case errors.Is(err, user.ErrorDuplicateEmail):
+			return nil, status.Errorf(codes.AlreadyExists, err.Error())
+		default:
+			return nil, status.Errorf(codes.Internal, "internal server error: %v", err)
+		}
 	}
 
-	res = &pb.UserResponse{User: &pb.Response{
-		Id:      createdUser.ID,
-		Name:    createdUser.Name,
-		Email:   createdUser.Email,
-		Address: createdUser.Address,
-		Role:    createdUser.Role,
-	}}
+	res = &pb.UserResponse{User: parseToProto(createdUser)}
 
 	return
 
 }
+
+func (s *UserServiceServer) VerifyPassword(ctx context.Context, req *pb.VerifyPasswordRequest) (res *pb.UserResponse, err error) {
+	verified, err := s.userService.VerifyPassword(ctx, req.Email, req.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrorNotFound):
+			return nil, status.Errorf(codes.NotFound, req.Email)
+		case errors.Is(err, user.ErrorWrongPassword):
+			return nil, status.Errorf(codes.Unauthenticated, err.Error())
+		default:
+			return nil, status.Errorf(codes.Internal, "internal server error: %v", err)
+		}
+	}
+
+	res = &pb.UserResponse{User: parseToProto(verified)}
+
+	return
+}
+
+func (s *UserServiceServer) MarkEmailVerified(ctx context.Context, req *pb.MarkEmailVerifiedRequest) (res *pb.UserResponse, err error) {
+	verified, err := s.userService.MarkEmailVerified(ctx, req.UserId)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrorNotFound):
+			return nil, status.Errorf(codes.NotFound, req.UserId)
+		default:
+			return nil, status.Errorf(codes.Internal, "internal server error: %v", err)
+		}
+	}
+
+	res = &pb.UserResponse{User: parseToProto(verified)}
+
+	return
+}
+
+func (s *UserServiceServer) SetPassword(ctx context.Context, req *pb.SetPasswordRequest) (res *pb.UserResponse, err error) {
+	if req.Password == "" || len(req.Password) > 72 {
+		return nil, status.Errorf(codes.InvalidArgument, "password: must be 1 to 72 bytes long")
+	}
+
+	updated, err := s.userService.SetPassword(ctx, req.UserId, req.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrorNotFound):
+			return nil, status.Errorf(codes.NotFound, req.UserId)
+		default:
+			return nil, status.Errorf(codes.Internal, "internal server error: %v", err)
+		}
+	}
+
+	res = &pb.UserResponse{User: parseToProto(updated)}
+
+	return
+}
+
+func parseToProto(data user.Response) *pb.Response {
+	return &pb.Response{
+		Id:            data.ID,
+		Name:          data.Name,
+		Email:         data.Email,
+		Address:       data.Address,
+		Role:          data.Role,
+		EmailVerified: data.EmailVerified,
+	}
+}
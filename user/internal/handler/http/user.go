@@ -1,13 +1,16 @@
 package http
 
 import (
+	"context"
 	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/yrss1/my-shop/user/internal/domain/user"
 	"github.com/yrss1/my-shop/user/internal/service/userService"
 	"github.com/yrss1/my-shop/user/pkg/helpers"
+	"github.com/yrss1/my-shop/user/pkg/pagination"
 	"github.com/yrss1/my-shop/user/pkg/server/response"
 	"github.com/yrss1/my-shop/user/pkg/store"
+	"strconv"
 )
 
 type UserHandler struct {
@@ -27,6 +30,7 @@ func (h *UserHandler) Routes(r *gin.RouterGroup) {
 		api.GET("/:id", h.get)
 		api.PUT("/:id", h.update)
 		api.DELETE("/:id", h.delete)
+		api.POST("/:id/restore", h.restore)
 
 		api.GET("/search", h.search)
 		api.GET("/email", h.getByEmail)
@@ -36,21 +40,38 @@ func (h *UserHandler) Routes(r *gin.RouterGroup) {
 
 // list godoc
 // @Summary List users
-// @Description Get all users
+// @Description Get a page of users
 // @Tags users
 // @Accept  json
 // @Produce  json
+// @Param limit query int false "Page size"
+// @Param sort query string false "Sort field, prefixed with - for descending" Enums(id, -id, created_at, -created_at, name, -name, email, -email)
+// @Param cursor query string false "Cursor of the next page"
+// @Param include_deleted query bool false "Also list deleted users"
 // @Success 200 {array} user.Response
+// @Failure 400 {object} response.Object
 // @Failure 500 {object} response.Object
 // @Router /users [get]
 func (h *UserHandler) list(c *gin.Context) {
-	res, err := h.userService.ListUsers(c)
+	page, err := pagination.Parse(c.Query("limit"), c.Query("sort"), c.Query("cursor"), user.SortFields...)
+	if err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	ctx, err := deletedContext(c)
+	if err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	res, next, err := h.userService.ListUsers(ctx, page)
 	if err != nil {
 		response.InternalServerError(c, err)
 		return
 	}
 
-	response.OK(c, res)
+	response.OKPage(c, res, next)
 }
 
 // add godoc
@@ -62,6 +83,7 @@ func (h *UserHandler) list(c *gin.Context) {
 // @Param user body user.Request true "User request"
 // @Success 200 {object} user.Response
 // @Failure 400 {object} response.Object
+// @Failure 409 {object} response.Object
 // @Failure 500 {object} response.Object
 // @Router /users [post]
 func (h *UserHandler) add(c *gin.Context) {
@@ -77,7 +99,12 @@ func (h *UserHandler) add(c *gin.Context) {
 
 	res, err := h.userService.CreateUser(c, req)
 	if err != nil {
-		response.InternalServerError(c, err)
+		switch {
+		case errors.Is(err, user.ErrorDuplicateEmail):
+			response.Conflict(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
 		return
 	}
 
@@ -91,14 +118,23 @@ func (h *UserHandler) add(c *gin.Context) {
 // @Accept  json
 // @Produce  json
 // @Param id path string true "User ID"
+// @Param include_deleted query bool false "Also get a deleted user"
 // @Success 200 {object} user.Response
+// @Header 200 {string} ETag "Version of the user, for If-Match"
+// @Failure 400 {object} response.Object
 // @Failure 404 {object} response.Object
 // @Failure 500 {object} response.Object
 // @Router /users/{id} [get]
 func (h *UserHandler) get(c *gin.Context) {
 	id := c.Param("id")
 
-	res, err := h.userService.GetUser(c, id)
+	ctx, err := deletedContext(c)
+	if err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	res, err := h.userService.GetUser(ctx, id)
 	if err != nil {
 		switch {
 		case errors.Is(err, store.ErrorNotFound):
@@ -109,6 +145,7 @@ func (h *UserHandler) get(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", helpers.ETag(res.Version))
 	response.OK(c, res)
 }
 
@@ -119,16 +156,25 @@ func (h *UserHandler) get(c *gin.Context) {
 // @Accept  json
 // @Produce  json
 // @Param id path string true "User ID"
+// @Param If-Match header string true "ETag of the user version being updated, or *"
 // @Param user body user.Request true "User request"
 // @Success 200 {string} string "ok"
 // @Failure 400 {object} response.Object
 // @Failure 404 {object} response.Object
+// @Failure 409 {object} response.Object
+// @Failure 412 {object} response.Object
+// @Failure 428 {object} response.Object
 // @Failure 500 {object} response.Object
 // @Router /users/{id} [put]
 func (h *UserHandler) update(c *gin.Context) {
 	id := c.Param("id")
 	req := user.Request{}
 
+	version, ok := ifMatch(c)
+	if !ok {
+		return
+	}
+
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.BadRequest(c, err, req)
 		return
@@ -139,10 +185,14 @@ func (h *UserHandler) update(c *gin.Context) {
 		return
 	}
 
-	if err := h.userService.UpdateUser(c, id, req); err != nil {
+	if err := h.userService.UpdateUser(c, id, version, req); err != nil {
 		switch {
 		case errors.Is(err, store.ErrorNotFound):
 			response.NotFound(c, err)
+		case errors.Is(err, store.ErrorVersionMismatch):
+			response.PreconditionFailed(c, err)
+		case errors.Is(err, user.ErrorDuplicateEmail):
+			response.Conflict(c, err)
 		default:
 			response.InternalServerError(c, err)
 		}
@@ -154,22 +204,62 @@ func (h *UserHandler) update(c *gin.Context) {
 
 // delete godoc
 // @Summary Delete a user
-// @Description Delete user by ID
+// @Description Soft delete user by ID; it can be restored later
 // @Tags users
 // @Accept  json
 // @Produce  json
 // @Param id path string true "User ID"
+// @Param If-Match header string true "ETag of the user version being deleted, or *"
 // @Success 200 {string} string "User deleted"
 // @Failure 404 {object} response.Object
+// @Failure 412 {object} response.Object
+// @Failure 428 {object} response.Object
 // @Failure 500 {object} response.Object
 // @Router /users/{id} [delete]
 func (h *UserHandler) delete(c *gin.Context) {
 	id := c.Param("id")
 
-	if err := h.userService.DeleteUser(c, id); err != nil {
+	version, ok := ifMatch(c)
+	if !ok {
+		return
+	}
+
+	if err := h.userService.DeleteUser(c, id, version); err != nil {
+		switch {
+		case errors.Is(err, store.ErrorNotFound):
+			response.NotFound(c, err)
+		case errors.Is(err, store.ErrorVersionMismatch):
+			response.PreconditionFailed(c, err)
+		default:
+			response.InternalServerError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, id)
+}
+
+// restore godoc
+// @Summary Restore a user
+// @Description Restore a deleted user by ID
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Param id path string true "User ID"
+// @Success 200 {string} string "User restored"
+// @Failure 404 {object} response.Object
+// @Failure 409 {object} response.Object
+// @Failure 500 {object} response.Object
+// @Router /users/{id}/restore [post]
+func (h *UserHandler) restore(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.userService.RestoreUser(c, id); err != nil {
 		switch {
 		case errors.Is(err, store.ErrorNotFound):
 			response.NotFound(c, err)
+		case errors.Is(err, user.ErrorDuplicateEmail):
+			response.Conflict(c, err)
 		default:
 			response.InternalServerError(c, err)
 		}
@@ -187,6 +277,10 @@ func (h *UserHandler) delete(c *gin.Context) {
 // @Produce  json
 // @Param name query string false "Name"
 // @Param email query string false "Email"
+// @Param limit query int false "Page size"
+// @Param sort query string false "Sort field, prefixed with - for descending" Enums(id, -id, created_at, -created_at, name, -name, email, -email)
+// @Param cursor query string false "Cursor of the next page"
+// @Param include_deleted query bool false "Also find deleted users"
 // @Success 200 {array} user.Response
 // @Failure 400 {object} response.Object
 // @Failure 500 {object} response.Object
@@ -202,13 +296,25 @@ func (h *UserHandler) search(c *gin.Context) {
 		return
 	}
 
-	res, err := h.userService.SearchUser(c, req)
+	page, err := pagination.Parse(c.Query("limit"), c.Query("sort"), c.Query("cursor"), user.SortFields...)
+	if err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	ctx, err := deletedContext(c)
+	if err != nil {
+		response.BadRequest(c, err, nil)
+		return
+	}
+
+	res, next, err := h.userService.SearchUser(ctx, req, page)
 	if err != nil {
 		response.InternalServerError(c, err)
 		return
 	}
 
-	response.OK(c, res)
+	response.OKPage(c, res, next)
 }
 
 func (h *UserHandler) getByEmail(c *gin.Context) {
@@ -227,3 +333,38 @@ func (h *UserHandler) getByEmail(c *gin.Context) {
 
 	response.OK(c, res)
 }
+
+// deletedContext returns the context of a read, marked to include soft deleted
+// users when the include_deleted query parameter is true.
+func deletedContext(c *gin.Context) (ctx context.Context, err error) {
+	ctx = c
+
+	if value := c.Query("include_deleted"); value != "" {
+		include, parseErr := strconv.ParseBool(value)
+		if parseErr != nil {
+			return nil, errors.New("include_deleted: invalid value")
+		}
+		if include {
+			ctx = store.WithDeleted(c)
+		}
+	}
+
+	return
+}
+
+// ifMatch returns the version a write expects from the If-Match header. When
+// the header is missing or invalid it writes the error response and ok is
+// false.
+func ifMatch(c *gin.Context) (version *int, ok bool) {
+	version, err := helpers.IfMatch(c.GetHeader("If-Match"))
+	switch {
+	case errors.Is(err, helpers.ErrorIfMatchRequired):
+		response.PreconditionRequired(c, err)
+	case err != nil:
+		response.PreconditionFailed(c, err)
+	default:
+		ok = true
+	}
+
+	return
+}
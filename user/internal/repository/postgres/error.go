@@ -0,0 +1,15 @@
+package postgres
+
+import (
+	"errors"
+	"github.com/lib/pq"
+)
+
+const (
+	uniqueViolation = "23505"
+)
+
+func isViolation(err error, code pq.ErrorCode) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == code
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"github.com/jmoiron/sqlx"
 	"github.com/yrss1/my-shop/user/internal/domain/user"
+	"github.com/yrss1/my-shop/user/pkg/pagination"
 	"github.com/yrss1/my-shop/user/pkg/store"
 	"strings"
 )
@@ -19,15 +20,18 @@ func NewUserRepository(db *sqlx.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
-func (r *UserRepository) List(ctx context.Context) (dest []user.Entity, err error) {
+type userRow struct {
+	user.Entity
+	SortKey string `db:"sort_key"`
+}
+
+func (r *UserRepository) List(ctx context.Context, page pagination.Request) (dest []user.Entity, next string, err error) {
 	query := `
-		SELECT id, name, email, address, role 
+		SELECT id, name, email, address, role, version, email_verified_at, deleted_at, ` + page.SortKey() + `
 		FROM users
-		ORDER BY id`
+		WHERE ` + store.DeletedFilter(ctx, "users")
 
-	err = r.db.SelectContext(ctx, &dest, query)
-
-	return
+	return r.selectPage(ctx, page, query, nil)
 }
 
 func (r *UserRepository) Add(ctx context.Context, data user.Entity) (id string, err error) {
@@ -39,9 +43,7 @@ func (r *UserRepository) Add(ctx context.Context, data user.Entity) (id string,
 	args := []any{data.Name, data.Email, data.Password, data.Address, data.Role}
 
 	if err = r.db.QueryRowContext(ctx, query, args...).Scan(&id); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			err = store.ErrorNotFound
-		}
+		err = r.mapError(err)
 	}
 
 	return
@@ -49,9 +51,9 @@ func (r *UserRepository) Add(ctx context.Context, data user.Entity) (id string,
 
 func (r *UserRepository) Get(ctx context.Context, id string) (dest user.Entity, err error) {
 	query := `
-		SELECT id, name, email, address, role 
+		SELECT id, name, email, address, role, version, email_verified_at, deleted_at
 		FROM users
-		WHERE id=$1`
+		WHERE id=$1 AND ` + store.DeletedFilter(ctx, "users")
 
 	args := []any{id}
 
@@ -64,18 +66,40 @@ func (r *UserRepository) Get(ctx context.Context, id string) (dest user.Entity,
 	return
 }
 
+// Update changes the set fields of the user. With data.Version set it only
+// updates the user at that version and fails with store.ErrorVersionMismatch
+// otherwise.
 func (r *UserRepository) Update(ctx context.Context, id string, data user.Entity) (err error) {
 	sets, args := r.prepareArgs(data)
 
+	// an empty update changes nothing, but a stale version still fails
+	if len(args) == 0 && data.Version != nil {
+		return r.checkVersion(ctx, id, *data.Version)
+	}
+
 	if len(args) > 0 {
+		if data.Email != nil {
+			// a new email has to be verified again
+			args = append(args, data.Email)
+			sets = append(sets, fmt.Sprintf("email_verified_at=CASE WHEN email=$%d THEN email_verified_at END", len(args)))
+		}
+
 		args = append(args, id)
-		sets = append(sets, "updated_at=CURRENT_TIMESTAMP")
+		sets = append(sets, "updated_at=CURRENT_TIMESTAMP", "version=version+1")
+		conds := fmt.Sprintf("id=$%d AND deleted_at IS NULL", len(args))
 
-		query := fmt.Sprintf("UPDATE users SET %s WHERE id=$%d RETURNING id", strings.Join(sets, ", "), len(args))
+		if data.Version != nil {
+			args = append(args, *data.Version)
+			conds += fmt.Sprintf(" AND version=$%d", len(args))
+		}
+
+		query := fmt.Sprintf("UPDATE users SET %s WHERE %s RETURNING id", strings.Join(sets, ", "), conds)
 
 		if err = r.db.QueryRowContext(ctx, query, args...).Scan(&id); err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
-				err = store.ErrorNotFound
+				err = r.versionError(ctx, id)
+			} else {
+				err = r.mapError(err)
 			}
 		}
 	}
@@ -83,10 +107,52 @@ func (r *UserRepository) Update(ctx context.Context, id string, data user.Entity
 	return
 }
 
-func (r *UserRepository) Delete(ctx context.Context, id string) (err error) {
+// Delete soft deletes the user, keeping the rows of its orders and payments.
+// With a version it only deletes the user at that version.
+func (r *UserRepository) Delete(ctx context.Context, id string, version *int) (err error) {
+	query := `
+		UPDATE users
+		SET deleted_at=CURRENT_TIMESTAMP, version=version+1
+		WHERE id=$1 AND deleted_at IS NULL AND ($2::integer IS NULL OR version=$2)
+		RETURNING id`
+
+	args := []any{id, version}
+
+	if err = r.db.QueryRowContext(ctx, query, args...).Scan(&id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = r.versionError(ctx, id)
+		}
+	}
+
+	return
+}
+
+// Restore undoes Delete. Restoring a user that is not deleted gives
+// store.ErrorNotFound, and restoring one while a live user has its email fails
+// as a duplicate.
+func (r *UserRepository) Restore(ctx context.Context, id string) (err error) {
+	query := `
+		UPDATE users
+		SET deleted_at=NULL, version=version+1
+		WHERE id=$1 AND deleted_at IS NOT NULL
+		RETURNING id`
+
+	args := []any{id}
+
+	if err = r.db.QueryRowContext(ctx, query, args...).Scan(&id); err != nil {
+		err = r.mapError(err)
+	}
+
+	return
+}
+
+// MarkEmailVerified records that the user confirmed its email. The time of the
+// first confirmation is kept.
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, id string) (err error) {
 	query := `
-		DELETE FROM users
-		WHERE id=$1
+		UPDATE users
+		SET email_verified_at=COALESCE(email_verified_at, CURRENT_TIMESTAMP), updated_at=CURRENT_TIMESTAMP, version=version+1
+		WHERE id=$1 AND deleted_at IS NULL
 		RETURNING id`
 
 	args := []any{id}
@@ -100,19 +166,82 @@ func (r *UserRepository) Delete(ctx context.Context, id string) (err error) {
 	return
 }
 
-func (r *UserRepository) Search(ctx context.Context, data user.Entity) (dest []user.Entity, err error) {
-	query := "SELECT id, name, email, address, role FROM users WHERE 1=1"
+func (r *UserRepository) Search(ctx context.Context, data user.Entity, page pagination.Request) (dest []user.Entity, next string, err error) {
+	query := "SELECT id, name, email, address, role, version, email_verified_at, deleted_at, " + page.SortKey() + " FROM users WHERE " + store.DeletedFilter(ctx, "users")
 
 	sets, args := r.prepareArgs(data)
 	if len(sets) > 0 {
 		query += " AND " + strings.Join(sets, " AND ")
 	}
 
-	err = r.db.SelectContext(ctx, &dest, query, args...)
+	return r.selectPage(ctx, page, query, args)
+}
+
+func (r *UserRepository) selectPage(ctx context.Context, page pagination.Request, query string, args []any) (dest []user.Entity, next string, err error) {
+	query, args = page.Keyset(query, args)
+
+	var rows []userRow
+	if err = r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return
+	}
+
+	rows, next = pagination.Next(page, rows, func(row userRow) (string, string) {
+		return row.SortKey, row.ID
+	})
+
+	dest = make([]user.Entity, 0, len(rows))
+	for _, row := range rows {
+		dest = append(dest, row.Entity)
+	}
+
+	return
+}
+
+// versionError tells why a versioned write of the user matched no row: the
+// user is missing or deleted, or it is at another version.
+func (r *UserRepository) versionError(ctx context.Context, id string) (err error) {
+	var exists bool
+	query := "SELECT EXISTS(SELECT 1 FROM users WHERE id=$1 AND deleted_at IS NULL)"
+	if err = r.db.GetContext(ctx, &exists, query, id); err != nil {
+		return
+	}
+
+	if exists {
+		return store.ErrorVersionMismatch
+	}
+	return store.ErrorNotFound
+}
 
+// checkVersion fails like a versioned write of the user would, for writes that
+// have nothing to change.
+func (r *UserRepository) checkVersion(ctx context.Context, id string, version int) (err error) {
+	var current int
+	query := "SELECT version FROM users WHERE id=$1 AND deleted_at IS NULL"
+	if err = r.db.GetContext(ctx, &current, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = store.ErrorNotFound
+		}
+		return
+	}
+
+	if current != version {
+		return store.ErrorVersionMismatch
+	}
 	return
 }
 
+// mapError translates constraint violations of user writes. email is the only
+// unique column.
+func (r *UserRepository) mapError(err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return store.ErrorNotFound
+	case isViolation(err, uniqueViolation):
+		return user.ErrorDuplicateEmail
+	}
+	return err
+}
+
 func (r *UserRepository) prepareArgs(data user.Entity) (sets []string, args []any) {
 	if data.Name != nil {
 		args = append(args, data.Name)
@@ -143,7 +272,7 @@ func (r *UserRepository) prepareArgs(data user.Entity) (sets []string, args []an
 }
 
 func (r *UserRepository) GetByEmail(ctx context.Context, email string) (dest user.Entity, err error) {
-	query := `SELECT id, name, email, address, role from users where email=$1`
+	query := `SELECT id, name, email, password, address, role, version, email_verified_at, deleted_at from users where email=$1 AND ` + store.DeletedFilter(ctx, "users")
 
 	args := []any{email}
 
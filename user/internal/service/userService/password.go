@@ -0,0 +1,103 @@
+package userService
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"github.com/yrss1/my-shop/user/internal/domain/user"
+	"github.com/yrss1/my-shop/user/pkg/log"
+	"github.com/yrss1/my-shop/user/pkg/store"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// dummyHash is compared against when the email is unknown, so that the time
+// VerifyPassword takes does not tell which emails are registered.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+
+// VerifyPassword returns the user with the email if password is theirs.
+// Passwords stored in plaintext before they were hashed are hashed once they
+// are verified.
+func (s *Service) VerifyPassword(ctx context.Context, email, password string) (res user.Response, err error) {
+	logger := log.LoggerFromContext(ctx).Named("VerifyPassword").With(zap.String("email", email))
+
+	data, err := s.userRepository.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, store.ErrorNotFound) {
+			bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		} else {
+			logger.Error("failed to get by email", zap.Error(err))
+		}
+		return
+	}
+
+	var stored []byte
+	if data.Password != nil {
+		stored = []byte(*data.Password)
+	}
+
+	if _, costErr := bcrypt.Cost(stored); costErr != nil {
+		if len(stored) == 0 || subtle.ConstantTimeCompare(stored, []byte(password)) != 1 {
+			err = user.ErrorWrongPassword
+			return
+		}
+
+		if rehashErr := s.rehashPassword(ctx, data.ID, password); rehashErr != nil {
+			logger.Error("failed to rehash plaintext password", zap.Error(rehashErr))
+		}
+	} else if err = bcrypt.CompareHashAndPassword(stored, []byte(password)); err != nil {
+		err = user.ErrorWrongPassword
+		return
+	}
+
+	res = user.ParseFromEntity(data)
+
+	return
+}
+
+// SetPassword replaces the password of the user without checking the old one,
+// as when it is reset.
+func (s *Service) SetPassword(ctx context.Context, id, password string) (res user.Response, err error) {
+	logger := log.LoggerFromContext(ctx).Named("SetPassword").With(zap.String("id", id))
+
+	hash, err := hashPassword(&password)
+	if err != nil {
+		logger.Error("failed to hash password", zap.Error(err))
+		return
+	}
+
+	if err = s.userRepository.Update(ctx, id, user.Entity{Password: hash}); err != nil {
+		if !errors.Is(err, store.ErrorNotFound) {
+			logger.Error("failed to update password", zap.Error(err))
+		}
+		return
+	}
+
+	return s.GetUser(ctx, id)
+}
+
+func (s *Service) rehashPassword(ctx context.Context, id, password string) (err error) {
+	hash, err := hashPassword(&password)
+	if err != nil {
+		return
+	}
+
+	return s.userRepository.Update(ctx, id, user.Entity{Password: hash})
+}
+
+// hashPassword hashes password with bcrypt. A nil password stays nil.
+func hashPassword(password *string) (hash *string, err error) {
+	if password == nil {
+		return
+	}
+
+	value, err := bcrypt.GenerateFromPassword([]byte(*password), bcrypt.DefaultCost)
+	if err != nil {
+		return
+	}
+
+	hash = new(string)
+	*hash = string(value)
+
+	return
+}
@@ -5,14 +5,15 @@ import (
 	"errors"
 	"github.com/yrss1/my-shop/user/internal/domain/user"
 	"github.com/yrss1/my-shop/user/pkg/log"
+	"github.com/yrss1/my-shop/user/pkg/pagination"
 	"github.com/yrss1/my-shop/user/pkg/store"
 	"go.uber.org/zap"
 )
 
-func (s *Service) ListUsers(ctx context.Context) (res []user.Response, err error) {
+func (s *Service) ListUsers(ctx context.Context, page pagination.Request) (res []user.Response, next string, err error) {
 	logger := log.LoggerFromContext(ctx).Named("ListUsers")
 
-	data, err := s.userRepository.List(ctx)
+	data, next, err := s.userRepository.List(ctx, page)
 	if err != nil {
 		logger.Error("failed to select", zap.Error(err))
 		return
@@ -26,17 +27,25 @@ func (s *Service) ListUsers(ctx context.Context) (res []user.Response, err error
 func (s *Service) CreateUser(ctx context.Context, req user.Request) (res user.Response, err error) {
 	logger := log.LoggerFromContext(ctx).Named("CreateUser")
 
+	password, err := hashPassword(req.Password)
+	if err != nil {
+		logger.Error("failed to hash password", zap.Error(err))
+		return
+	}
+
 	data := user.Entity{
 		Name:     req.Name,
 		Email:    req.Email,
-		Password: req.Password,
+		Password: password,
 		Address:  req.Address,
 		Role:     req.Role,
 	}
 
 	data.ID, err = s.userRepository.Add(ctx, data)
 	if err != nil {
-		logger.Error("failed to create", zap.Error(err))
+		if !errors.Is(err, user.ErrorDuplicateEmail) {
+			logger.Error("failed to create", zap.Error(err))
+		}
 		return
 	}
 
@@ -59,18 +68,29 @@ func (s *Service) GetUser(ctx context.Context, id string) (res user.Response, er
 	return
 }
 
-func (s *Service) UpdateUser(ctx context.Context, id string, req user.Request) (err error) {
+// UpdateUser updates the user at version, or at any version when version is
+// nil.
+func (s *Service) UpdateUser(ctx context.Context, id string, version *int, req user.Request) (err error) {
 	logger := log.LoggerFromContext(ctx).Named("UpdateBook").With(zap.String("id", id))
 
+	password, err := hashPassword(req.Password)
+	if err != nil {
+		logger.Error("failed to hash password", zap.Error(err))
+		return
+	}
+
 	data := user.Entity{
-		Name:    req.Name,
-		Email:   req.Email,
-		Address: req.Address,
-		Role:    req.Role,
+		Name:     req.Name,
+		Email:    req.Email,
+		Password: password,
+		Address:  req.Address,
+		Role:     req.Role,
+		Version:  version,
 	}
 
 	err = s.userRepository.Update(ctx, id, data)
-	if err != nil && !errors.Is(err, store.ErrorNotFound) {
+	if err != nil && !errors.Is(err, store.ErrorNotFound) && !errors.Is(err, store.ErrorVersionMismatch) &&
+		!errors.Is(err, user.ErrorDuplicateEmail) {
 		logger.Error("failed to update by id", zap.Error(err))
 		return
 	}
@@ -78,11 +98,11 @@ func (s *Service) UpdateUser(ctx context.Context, id string, req user.Request) (
 	return
 }
 
-func (s *Service) DeleteUser(ctx context.Context, id string) (err error) {
+func (s *Service) DeleteUser(ctx context.Context, id string, version *int) (err error) {
 	logger := log.LoggerFromContext(ctx).Named("DeleteUser").With(zap.String("id", id))
 
-	err = s.userRepository.Delete(ctx, id)
-	if err != nil && !errors.Is(err, store.ErrorNotFound) {
+	err = s.userRepository.Delete(ctx, id, version)
+	if err != nil && !errors.Is(err, store.ErrorNotFound) && !errors.Is(err, store.ErrorVersionMismatch) {
 		logger.Error("failed to delete by id", zap.Error(err))
 		return
 	}
@@ -90,7 +110,19 @@ func (s *Service) DeleteUser(ctx context.Context, id string) (err error) {
 	return
 }
 
-func (s *Service) SearchUser(ctx context.Context, req user.Request) (res []user.Response, err error) {
+func (s *Service) RestoreUser(ctx context.Context, id string) (err error) {
+	logger := log.LoggerFromContext(ctx).Named("RestoreUser").With(zap.String("id", id))
+
+	err = s.userRepository.Restore(ctx, id)
+	if err != nil && !errors.Is(err, store.ErrorNotFound) && !errors.Is(err, user.ErrorDuplicateEmail) {
+		logger.Error("failed to restore by id", zap.Error(err))
+		return
+	}
+
+	return
+}
+
+func (s *Service) SearchUser(ctx context.Context, req user.Request, page pagination.Request) (res []user.Response, next string, err error) {
 	logger := log.LoggerFromContext(ctx).Named("SearchUser")
 
 	if req.Name != nil {
@@ -104,7 +136,7 @@ func (s *Service) SearchUser(ctx context.Context, req user.Request) (res []user.
 		Name:  req.Name,
 		Email: req.Email,
 	}
-	data, err := s.userRepository.Search(ctx, searchData)
+	data, next, err := s.userRepository.Search(ctx, searchData, page)
 	if err != nil {
 		logger.Error("failed to search users", zap.Error(err))
 		return
@@ -128,3 +160,16 @@ func (s *Service) GetUserByEmail(ctx context.Context, email string) (res user.Re
 
 	return
 }
+
+func (s *Service) MarkEmailVerified(ctx context.Context, id string) (res user.Response, err error) {
+	logger := log.LoggerFromContext(ctx).Named("MarkEmailVerified").With(zap.String("id", id))
+
+	if err = s.userRepository.MarkEmailVerified(ctx, id); err != nil {
+		if !errors.Is(err, store.ErrorNotFound) {
+			logger.Error("failed to mark email verified", zap.Error(err))
+		}
+		return
+	}
+
+	return s.GetUser(ctx, id)
+}
@@ -0,0 +1,158 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+var (
+	ErrorInvalidLimit  = errors.New("limit: must be between 1 and " + strconv.Itoa(MaxLimit))
+	ErrorInvalidSort   = errors.New("sort: unknown field")
+	ErrorInvalidCursor = errors.New("cursor: invalid value")
+)
+
+// Request describes one page of a keyset-paginated list. Rows are ordered by
+// Sort and then by id, so the cursor always points at a single row.
+// The zero Request means every row ordered by id.
+type Request struct {
+	Limit  int
+	Sort   string
+	Desc   bool
+	Cursor *Cursor
+}
+
+// Cursor is the position of the last row of a page.
+type Cursor struct {
+	Sort string `json:"s"`
+	Key  string `json:"k"`
+	ID   string `json:"id"`
+}
+
+// Parse builds a Request from the limit, sort and cursor query values. sort
+// must be one of fields, prefixed with "-" for descending order; it defaults
+// to the first field.
+func Parse(limit, sort, cursor string, fields ...string) (req Request, err error) {
+	req.Limit = DefaultLimit
+	if limit != "" {
+		if req.Limit, err = strconv.Atoi(limit); err != nil || req.Limit < 1 || req.Limit > MaxLimit {
+			return req, ErrorInvalidLimit
+		}
+	}
+
+	if sort == "" && len(fields) > 0 {
+		sort = fields[0]
+	}
+	req.Sort, req.Desc = strings.TrimPrefix(sort, "-"), strings.HasPrefix(sort, "-")
+
+	valid := false
+	for _, field := range fields {
+		if field == req.Sort {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return req, fmt.Errorf("%w: %s", ErrorInvalidSort, req.Sort)
+	}
+
+	if cursor != "" {
+		if req.Cursor, err = decode(cursor); err != nil || req.Cursor.Sort != sort {
+			return req, ErrorInvalidCursor
+		}
+	}
+
+	return
+}
+
+// SortKey is the select expression that exposes the sort value of each row
+// as sort_key.
+func (r Request) SortKey() string {
+	return r.column() + "::text AS sort_key"
+}
+
+// Keyset appends the cursor condition, the ORDER BY and the LIMIT to query.
+// The query must already have a WHERE clause; args are the arguments it uses.
+// One extra row is fetched so that Next can tell whether a page follows.
+func (r Request) Keyset(query string, args []any) (string, []any) {
+	column, op, dir := r.column(), ">", "ASC"
+	if r.Desc {
+		op, dir = "<", "DESC"
+	}
+
+	if r.Cursor != nil {
+		if column == "id" {
+			args = append(args, r.Cursor.ID)
+			query += fmt.Sprintf(" AND id %s $%d", op, len(args))
+		} else {
+			args = append(args, r.Cursor.Key, r.Cursor.ID)
+			query += fmt.Sprintf(" AND (%[1]s %[2]s $%[3]d OR (%[1]s = $%[3]d AND id %[2]s $%[4]d))", column, op, len(args)-1, len(args))
+		}
+	}
+
+	if column == "id" {
+		query += " ORDER BY id " + dir
+	} else {
+		query += fmt.Sprintf(" ORDER BY %s %s, id %s", column, dir, dir)
+	}
+
+	if r.Limit > 0 {
+		args = append(args, r.Limit+1)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	return query, args
+}
+
+// Next trims the extra row fetched by Keyset and returns the cursor of the
+// following page, or an empty string on the last page.
+func Next[T any](r Request, rows []T, key func(T) (sortKey, id string)) ([]T, string) {
+	if r.Limit <= 0 || len(rows) <= r.Limit {
+		return rows, ""
+	}
+
+	rows = rows[:r.Limit]
+	cursor := Cursor{Sort: r.sort()}
+	cursor.Key, cursor.ID = key(rows[len(rows)-1])
+
+	return rows, cursor.encode()
+}
+
+func (r Request) column() string {
+	if r.Sort == "" {
+		return "id"
+	}
+	return r.Sort
+}
+
+func (r Request) sort() string {
+	if r.Desc {
+		return "-" + r.column()
+	}
+	return r.column()
+}
+
+func (c Cursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decode(value string) (cursor *Cursor, err error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return
+	}
+
+	cursor = &Cursor{}
+	err = json.Unmarshal(data, cursor)
+
+	return
+}
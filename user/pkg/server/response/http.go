@@ -6,9 +6,10 @@ import (
 )
 
 type Object struct {
-	Data    any    `json:"data,omitempty"`
-	Message string `json:"message,omitempty"`
-	Success bool   `json:"success"`
+	Data       any    `json:"data,omitempty"`
+	Message    string `json:"message,omitempty"`
+	Success    bool   `json:"success"`
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 func OK(c *gin.Context, data any) {
@@ -19,6 +20,15 @@ func OK(c *gin.Context, data any) {
 	c.JSON(http.StatusOK, h)
 }
 
+func OKPage(c *gin.Context, data any, nextCursor string) {
+	h := Object{
+		Success:    true,
+		Data:       data,
+		NextCursor: nextCursor,
+	}
+	c.JSON(http.StatusOK, h)
+}
+
 func Created(c *gin.Context, data any) {
 	h := Object{
 		Success: true,
@@ -44,6 +54,30 @@ func NotFound(c *gin.Context, err error) {
 	c.JSON(http.StatusNotFound, h)
 }
 
+func Conflict(c *gin.Context, err error) {
+	h := Object{
+		Success: false,
+		Message: err.Error(),
+	}
+	c.JSON(http.StatusConflict, h)
+}
+
+func PreconditionFailed(c *gin.Context, err error) {
+	h := Object{
+		Success: false,
+		Message: err.Error(),
+	}
+	c.JSON(http.StatusPreconditionFailed, h)
+}
+
+func PreconditionRequired(c *gin.Context, err error) {
+	h := Object{
+		Success: false,
+		Message: err.Error(),
+	}
+	c.JSON(http.StatusPreconditionRequired, h)
+}
+
 func InternalServerError(c *gin.Context, err error) {
 	h := Object{
 		Success: false,
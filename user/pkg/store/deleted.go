@@ -0,0 +1,19 @@
+package store
+
+import "context"
+
+type includeDeleted struct{}
+
+// WithDeleted marks ctx so that repositories also return soft deleted rows.
+func WithDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, includeDeleted{}, true)
+}
+
+// DeletedFilter returns the condition that hides soft deleted rows of table,
+// or an always true one when ctx was marked by WithDeleted.
+func DeletedFilter(ctx context.Context, table string) string {
+	if deleted, _ := ctx.Value(includeDeleted{}).(bool); deleted {
+		return "TRUE"
+	}
+	return table + ".deleted_at IS NULL"
+}